@@ -0,0 +1,18 @@
+package v1
+
+// AccessConfig restricts who can access a website, e.g. an internal-only site. Both restrictions
+// apply if both are set.
+type AccessConfig struct {
+	// BasicAuth requires visitors to log in as one of the users of a Secret.
+	BasicAuth *BasicAuth `json:"basicAuth,omitempty"`
+	// AllowedCIDRs restricts the access to clients from these networks, e.g. 10.0.0.0/8.
+	AllowedCIDRs []string `json:"allowedCIDRs,omitempty"`
+}
+
+// BasicAuth references the Secret in the namespace of the website which holds the passwords of
+// the users keyed by their name. The controller renders it into an htpasswd file.
+type BasicAuth struct {
+	SecretName string `json:"secretName"`
+	// Realm is shown by browsers when asking for the login, defaults to the hostname.
+	Realm string `json:"realm,omitempty"`
+}
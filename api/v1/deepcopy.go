@@ -1,17 +1,119 @@
 package v1
 
-import "k8s.io/apimachinery/pkg/runtime"
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
 
 // DeepCopyInto copies all properties of this object into another object of the
 // same type that is provided as a pointer.
 func (in *WebSite) DeepCopyInto(out *WebSite) {
 	out.TypeMeta = in.TypeMeta
-	out.ObjectMeta = in.ObjectMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	out.Spec = WebSiteSpec{
 		HtmlContent: in.Spec.HtmlContent,
 		Hostname:    in.Spec.Hostname,
 		NginxImage:  in.Spec.NginxImage,
+		Suspend:     in.Spec.Suspend,
 	}
+
+	if in.Spec.Files != nil {
+		out.Spec.Files = make(map[string][]byte, len(in.Spec.Files))
+		for path, content := range in.Spec.Files {
+			out.Spec.Files[path] = append([]byte(nil), content...)
+		}
+	}
+
+	if in.Spec.RevisionHistoryLimit != nil {
+		limit := *in.Spec.RevisionHistoryLimit
+		out.Spec.RevisionHistoryLimit = &limit
+	}
+
+	out.Spec.Server = in.Spec.Server.DeepCopy()
+	out.Spec.Access = in.Spec.Access.DeepCopy()
+
+	if in.Spec.Maintenance != nil {
+		maintenance := *in.Spec.Maintenance
+		out.Spec.Maintenance = &maintenance
+	}
+
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy returns a copy of the website. It shadows the DeepCopy of the embedded ObjectMeta.
+func (in *WebSite) DeepCopy() *WebSite {
+	out := WebSite{}
+	in.DeepCopyInto(&out)
+
+	return &out
+}
+
+// DeepCopy returns a copy of the server configuration, or nil if in is nil.
+func (in *ServerConfig) DeepCopy() *ServerConfig {
+	if in == nil {
+		return nil
+	}
+
+	out := &ServerConfig{SPA: in.SPA, Gzip: in.Gzip}
+	if in.Headers != nil {
+		out.Headers = make(map[string]string, len(in.Headers))
+		for name, value := range in.Headers {
+			out.Headers[name] = value
+		}
+	}
+	if in.Caching != nil {
+		out.Caching = make([]CachingRule, len(in.Caching))
+		for i, rule := range in.Caching {
+			out.Caching[i] = rule
+			out.Caching[i].Extensions = append([]string(nil), rule.Extensions...)
+		}
+	}
+	if in.ErrorPages != nil {
+		out.ErrorPages = make([]ErrorPage, len(in.ErrorPages))
+		for i, page := range in.ErrorPages {
+			out.ErrorPages[i] = page
+			out.ErrorPages[i].Codes = append([]int32(nil), page.Codes...)
+		}
+	}
+	out.Redirects = append([]Redirect(nil), in.Redirects...)
+
+	return out
+}
+
+// DeepCopy returns a copy of the access configuration, or nil if in is nil.
+func (in *AccessConfig) DeepCopy() *AccessConfig {
+	if in == nil {
+		return nil
+	}
+
+	out := &AccessConfig{AllowedCIDRs: append([]string(nil), in.AllowedCIDRs...)}
+	if in.BasicAuth != nil {
+		basicAuth := *in.BasicAuth
+		out.BasicAuth = &basicAuth
+	}
+	return out
+}
+
+// DeepCopyInto copies the status into another status provided as a pointer.
+func (in *WebSiteStatus) DeepCopyInto(out *WebSiteStatus) {
+	out.ObservedGeneration = in.ObservedGeneration
+	out.ImageDigest = in.ImageDigest
+	out.Conditions = nil
+
+	if in.Conditions != nil {
+		out.Conditions = make([]metav1.Condition, len(in.Conditions))
+		for i := range in.Conditions {
+			in.Conditions[i].DeepCopyInto(&out.Conditions[i])
+		}
+	}
+}
+
+// DeepCopy returns a copy of the status.
+func (in *WebSiteStatus) DeepCopy() *WebSiteStatus {
+	out := WebSiteStatus{}
+	in.DeepCopyInto(&out)
+
+	return &out
 }
 
 // DeepCopyObject returns a generically typed copy of an object
@@ -26,7 +128,7 @@ func (in *WebSite) DeepCopyObject() runtime.Object {
 func (in *WebSiteList) DeepCopyObject() runtime.Object {
 	out := WebSiteList{}
 	out.TypeMeta = in.TypeMeta
-	out.ListMeta = in.ListMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
 
 	if in.Items != nil {
 		out.Items = make([]WebSite, len(in.Items))
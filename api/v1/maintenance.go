@@ -0,0 +1,12 @@
+package v1
+
+// Maintenance swaps a website to a maintenance page, e.g. during a backend migration. Its
+// content is kept and served again once maintenance is disabled.
+type Maintenance struct {
+	// Enabled serves the maintenance page with status 503 for all requests.
+	Enabled bool `json:"enabled,omitempty"`
+	// HTML of the maintenance page, a default page is served if empty.
+	HTML string `json:"html,omitempty"`
+	// RetryAfterSeconds is sent as Retry-After header, which is omitted if zero.
+	RetryAfterSeconds int32 `json:"retryAfterSeconds,omitempty"`
+}
@@ -0,0 +1,49 @@
+package v1
+
+// ServerConfig configures the nginx server of a website. The controller renders it into the
+// nginx configuration of the website, without it the default configuration of the image is used.
+type ServerConfig struct {
+	// SPA serves index.html for paths without a file, e.g. for the client-side routes of a single
+	// page application.
+	SPA bool `json:"spa,omitempty"`
+	// Gzip compresses text responses.
+	Gzip bool `json:"gzip,omitempty"`
+	// Headers are added to all responses, e.g. security headers like Content-Security-Policy.
+	Headers map[string]string `json:"headers,omitempty"`
+	// Caching sets the Cache-Control header of the paths matched by a rule, the first matching
+	// rule applies. Paths matched by a rule don't fall back to index.html in SPA mode.
+	Caching []CachingRule `json:"caching,omitempty"`
+	// ErrorPages are the content files served for error status codes, e.g. /404.html.
+	ErrorPages []ErrorPage `json:"errorPages,omitempty"`
+	// Redirects are served instead of content for exact paths.
+	Redirects []Redirect `json:"redirects,omitempty"`
+}
+
+// CachingRule sets the Cache-Control header for the files below Path with one of Extensions.
+// Empty fields match all paths.
+type CachingRule struct {
+	// Path is the prefix of the paths the rule applies to, e.g. /assets/.
+	Path string `json:"path,omitempty"`
+	// Extensions of the files the rule applies to, e.g. css and js.
+	Extensions []string `json:"extensions,omitempty"`
+	// MaxAgeSeconds is how long the files may be cached.
+	MaxAgeSeconds int32 `json:"maxAgeSeconds,omitempty"`
+	// Immutable marks files which never change for a path, e.g. fingerprinted assets.
+	Immutable bool `json:"immutable,omitempty"`
+	// NoStore disallows caching, MaxAgeSeconds and Immutable are ignored.
+	NoStore bool `json:"noStore,omitempty"`
+}
+
+// ErrorPage serves the content file Path for the status codes Codes.
+type ErrorPage struct {
+	Codes []int32 `json:"codes"`
+	Path  string  `json:"path"`
+}
+
+// Redirect redirects requests for the exact path From to the path or URL To.
+type Redirect struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Permanent redirects with 301 instead of 302.
+	Permanent bool `json:"permanent,omitempty"`
+}
@@ -13,11 +13,73 @@ type WebSite struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	Spec WebSiteSpec `json:"spec"`
+	Spec   WebSiteSpec   `json:"spec"`
+	Status WebSiteStatus `json:"status,omitempty"`
 }
 
 type WebSiteSpec struct {
 	HtmlContent string `json:"htmlContent"`
 	Hostname    string `json:"hostname"`
 	NginxImage  string `json:"nginxImage"`
+
+	// Files holds additional site content keyed by its path relative to the document root.
+	// A file named index.html takes precedence over HtmlContent.
+	Files map[string][]byte `json:"files,omitempty"`
+
+	// RevisionHistoryLimit is the number of content revisions kept for rollbacks, defaults to 10.
+	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
+
+	// Suspend stops the controller from reconciling the website, leaving its objects as they are.
+	Suspend bool `json:"suspend,omitempty"`
+
+	// Server configures nginx, e.g. caching, headers and error pages. The default configuration
+	// of the image is used if empty.
+	Server *ServerConfig `json:"server,omitempty"`
+
+	// Access restricts the access to the website by basic auth or client networks. Restrictions
+	// aren't part of a revision, so a rollback doesn't lift them.
+	Access *AccessConfig `json:"access,omitempty"`
+
+	// Maintenance serves a maintenance page instead of the content while enabled. Like access
+	// restrictions, it isn't part of a revision.
+	Maintenance *Maintenance `json:"maintenance,omitempty"`
+}
+
+const (
+	// AnnotationReconcile set to ReconcilePaused pauses the reconciliation of a website like
+	// Suspend, without changing its spec, e.g. while hand-editing its deployment during an incident.
+	AnnotationReconcile = "anexia.com/reconcile"
+	ReconcilePaused     = "paused"
+)
+
+// Paused reports whether the reconciliation of the website is paused, by its annotation or spec.
+func (in *WebSite) Paused() bool {
+	return in.Spec.Suspend || in.Annotations[AnnotationReconcile] == ReconcilePaused
+}
+
+// Condition types reported in the status of a website.
+const (
+	// ConditionAvailable is true while the website is served by all of its replicas.
+	ConditionAvailable = "Available"
+	// ConditionProgressing is true while a spec change isn't rolled out yet.
+	ConditionProgressing = "Progressing"
+	// ConditionDegraded is true if the last reconciliation of the website failed.
+	ConditionDegraded = "Degraded"
+	// ConditionPaused is true while the reconciliation of the website is paused.
+	ConditionPaused = "Paused"
+	// ConditionHostnameConflict is true while the hostname of the website is claimed by an older
+	// website. The ingress of the website is withheld until the conflict is resolved.
+	ConditionHostnameConflict = "HostnameConflict"
+)
+
+// WebSiteStatus is the state of a website observed by the controller.
+type WebSiteStatus struct {
+	// ObservedGeneration is the generation of the spec the status was computed for.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// ImageDigest is the digest of the nginx image the website runs, if the image is pinned to a
+	// digest or the controller resolves tags to digests.
+	ImageDigest string `json:"imageDigest,omitempty"`
+
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
@@ -6,6 +6,7 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 )
@@ -46,6 +47,7 @@ type WebsiteInterface interface {
 	Create(ctx context.Context, site *webv1.WebSite, opts metav1.CreateOptions) (*webv1.WebSite, error)
 	Update(ctx context.Context, site *webv1.WebSite, opts metav1.UpdateOptions) (*webv1.WebSite, error)
 	Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
 }
 
 type websiteClient struct {
@@ -121,3 +123,13 @@ func (c *websiteClient) List(ctx context.Context, opts metav1.ListOptions) (*web
 
 	return &result, err
 }
+
+func (c *websiteClient) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	opts.Watch = true
+	return c.restClient.
+		Get().
+		Namespace(c.ns).
+		Resource("websites").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Watch(ctx)
+}
@@ -0,0 +1,417 @@
+// Package fake provides an in-memory implementation of the website clientset for tests.
+package fake
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+	webv1 "website-operator/api/v1"
+	"website-operator/clientset/v1"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+var websitesResource = schema.GroupResource{Group: webv1.GroupName, Resource: "websites"}
+
+// Verbs of the website client, used to inject errors with SetError.
+const (
+	VerbList   = "list"
+	VerbGet    = "get"
+	VerbCreate = "create"
+	VerbUpdate = "update"
+	VerbDelete = "delete"
+	VerbWatch  = "watch"
+)
+
+// Clientset stores websites in memory and behaves like the API server for the parts the
+// website client uses: resource versions, generations, conflicts, dry-run, selectors and
+// resumable watches.
+type Clientset struct {
+	mu              sync.Mutex
+	resourceVersion int64
+	sites           map[types.NamespacedName]*webv1.WebSite
+	history         []watch.Event
+	watchers        map[*watcher]struct{}
+	errors          map[string]error
+}
+
+var _ v1.WebsiteV1Interface = &Clientset{}
+
+// NewClientset returns a Clientset containing copies of sites. Sites without namespace are
+// put into the "default" namespace.
+func NewClientset(sites ...*webv1.WebSite) *Clientset {
+	c := &Clientset{
+		sites:    map[types.NamespacedName]*webv1.WebSite{},
+		watchers: map[*watcher]struct{}{},
+		errors:   map[string]error{},
+	}
+	for _, site := range sites {
+		namespace := site.Namespace
+		if namespace == "" {
+			namespace = metav1.NamespaceDefault
+		}
+		if _, err := c.Websites(namespace).Create(context.Background(), site, metav1.CreateOptions{}); err != nil {
+			panic(err)
+		}
+	}
+	return c
+}
+
+func (c *Clientset) Websites(namespace string) v1.WebsiteInterface {
+	return &websites{clientset: c, namespace: namespace}
+}
+
+// SetError makes all calls of verb fail with err, until it is reset with a nil error.
+func (c *Clientset) SetError(verb string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err == nil {
+		delete(c.errors, verb)
+		return
+	}
+	c.errors[verb] = err
+}
+
+// nextResourceVersion must be called with c.mu held.
+func (c *Clientset) nextResourceVersion() string {
+	c.resourceVersion++
+	return strconv.FormatInt(c.resourceVersion, 10)
+}
+
+// notify records an event and sends it to the watchers. c.mu must be held.
+func (c *Clientset) notify(eventType watch.EventType, site *webv1.WebSite) {
+	event := watch.Event{Type: eventType, Object: site.DeepCopyObject()}
+	c.history = append(c.history, event)
+	for w := range c.watchers {
+		w.send(event)
+	}
+}
+
+type websites struct {
+	clientset *Clientset
+	namespace string
+}
+
+func copyOf(site *webv1.WebSite) *webv1.WebSite {
+	return site.DeepCopyObject().(*webv1.WebSite)
+}
+
+func (w *websites) key(name string) types.NamespacedName {
+	return types.NamespacedName{Namespace: w.namespace, Name: name}
+}
+
+func (w *websites) Get(_ context.Context, name string, _ metav1.GetOptions) (*webv1.WebSite, error) {
+	c := w.clientset
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.errors[VerbGet]; err != nil {
+		return nil, err
+	}
+
+	site, ok := c.sites[w.key(name)]
+	if !ok {
+		return nil, apierrors.NewNotFound(websitesResource, name)
+	}
+	return copyOf(site), nil
+}
+
+func (w *websites) List(_ context.Context, opts metav1.ListOptions) (*webv1.WebSiteList, error) {
+	c := w.clientset
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.errors[VerbList]; err != nil {
+		return nil, err
+	}
+
+	matches, err := w.matcher(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &webv1.WebSiteList{
+		TypeMeta: metav1.TypeMeta{Kind: "WebSiteList", APIVersion: webv1.SchemeGroupVersion.String()},
+		ListMeta: metav1.ListMeta{ResourceVersion: strconv.FormatInt(c.resourceVersion, 10)},
+	}
+	for _, site := range c.sites {
+		if matches(site) {
+			list.Items = append(list.Items, *copyOf(site))
+		}
+	}
+	return list, nil
+}
+
+func (w *websites) Create(_ context.Context, site *webv1.WebSite, opts metav1.CreateOptions) (*webv1.WebSite, error) {
+	c := w.clientset
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.errors[VerbCreate]; err != nil {
+		return nil, err
+	}
+	if site.Name == "" {
+		return nil, apierrors.NewBadRequest("metadata.name is required")
+	}
+	if site.Namespace != "" && site.Namespace != w.namespace {
+		return nil, apierrors.NewBadRequest("the namespace of the object does not match the namespace of the request")
+	}
+	if _, ok := c.sites[w.key(site.Name)]; ok {
+		return nil, apierrors.NewAlreadyExists(websitesResource, site.Name)
+	}
+
+	created := copyOf(site)
+	created.Namespace = w.namespace
+	created.UID = types.UID(fmt.Sprintf("fake-uid-%d", c.resourceVersion+1))
+	created.Generation = 1
+	created.CreationTimestamp = metav1.NewTime(time.Now().Truncate(time.Second))
+	// the status is a subresource, written by the controller only
+	created.Status = webv1.WebSiteStatus{}
+
+	if isDryRun(opts.DryRun) {
+		created.ResourceVersion = ""
+		return created, nil
+	}
+
+	created.ResourceVersion = c.nextResourceVersion()
+	c.sites[w.key(site.Name)] = created
+	c.notify(watch.Added, created)
+	return copyOf(created), nil
+}
+
+func (w *websites) Update(_ context.Context, site *webv1.WebSite, opts metav1.UpdateOptions) (*webv1.WebSite, error) {
+	c := w.clientset
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.errors[VerbUpdate]; err != nil {
+		return nil, err
+	}
+
+	current, ok := c.sites[w.key(site.Name)]
+	if !ok {
+		return nil, apierrors.NewNotFound(websitesResource, site.Name)
+	}
+	if site.ResourceVersion != "" && site.ResourceVersion != current.ResourceVersion {
+		return nil, apierrors.NewConflict(websitesResource, site.Name,
+			fmt.Errorf("the object has been modified; please apply your changes to the latest version and try again"))
+	}
+
+	updated := copyOf(site)
+	updated.Namespace = current.Namespace
+	updated.UID = current.UID
+	updated.CreationTimestamp = current.CreationTimestamp
+	updated.Generation = current.Generation
+	updated.Status = *current.Status.DeepCopy()
+	if !equality.Semantic.DeepEqual(current.Spec, updated.Spec) {
+		updated.Generation++
+	}
+
+	if isDryRun(opts.DryRun) {
+		updated.ResourceVersion = current.ResourceVersion
+		return updated, nil
+	}
+
+	updated.ResourceVersion = c.nextResourceVersion()
+	c.sites[w.key(site.Name)] = updated
+	c.notify(watch.Modified, updated)
+	return copyOf(updated), nil
+}
+
+func (w *websites) Delete(_ context.Context, name string, opts metav1.DeleteOptions) error {
+	c := w.clientset
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.errors[VerbDelete]; err != nil {
+		return err
+	}
+
+	site, ok := c.sites[w.key(name)]
+	if !ok {
+		return apierrors.NewNotFound(websitesResource, name)
+	}
+	if isDryRun(opts.DryRun) {
+		return nil
+	}
+
+	delete(c.sites, w.key(name))
+	deleted := copyOf(site)
+	deleted.ResourceVersion = c.nextResourceVersion()
+	c.notify(watch.Deleted, deleted)
+	return nil
+}
+
+// Watch sends ADDED events for the existing websites if no resourceVersion is given, like
+// the API server does, otherwise all changes after the resourceVersion.
+func (w *websites) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	c := w.clientset
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.errors[VerbWatch]; err != nil {
+		return nil, err
+	}
+
+	matches, err := w.matcher(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	wa := newWatcher(func(event watch.Event) bool {
+		site, ok := event.Object.(*webv1.WebSite)
+		return !ok || matches(site)
+	})
+
+	switch opts.ResourceVersion {
+	case "", "0":
+		for _, site := range c.sites {
+			wa.send(watch.Event{Type: watch.Added, Object: site.DeepCopyObject()})
+		}
+	default:
+		since, err := strconv.ParseInt(opts.ResourceVersion, 10, 64)
+		if err != nil || since > c.resourceVersion {
+			return nil, apierrors.NewBadRequest(fmt.Sprintf("invalid resourceVersion '%s'", opts.ResourceVersion))
+		}
+		for _, event := range c.history {
+			rv, _ := strconv.ParseInt(event.Object.(*webv1.WebSite).ResourceVersion, 10, 64)
+			if rv > since {
+				wa.send(event)
+			}
+		}
+	}
+
+	c.watchers[wa] = struct{}{}
+	go func() {
+		select {
+		case <-ctx.Done():
+			wa.Stop()
+		case <-wa.done:
+		}
+		c.mu.Lock()
+		delete(c.watchers, wa)
+		c.mu.Unlock()
+	}()
+
+	return wa, nil
+}
+
+// matcher returns a filter for the namespace, label selector and field selector of opts.
+func (w *websites) matcher(opts metav1.ListOptions) (func(site *webv1.WebSite) bool, error) {
+	labelSelector := labels.Everything()
+	if opts.LabelSelector != "" {
+		var err error
+		if labelSelector, err = labels.Parse(opts.LabelSelector); err != nil {
+			return nil, apierrors.NewBadRequest(err.Error())
+		}
+	}
+
+	fieldSelector := fields.Everything()
+	if opts.FieldSelector != "" {
+		var err error
+		if fieldSelector, err = fields.ParseSelector(opts.FieldSelector); err != nil {
+			return nil, apierrors.NewBadRequest(err.Error())
+		}
+	}
+
+	return func(site *webv1.WebSite) bool {
+		if w.namespace != metav1.NamespaceAll && site.Namespace != w.namespace {
+			return false
+		}
+		return labelSelector.Matches(labels.Set(site.Labels)) && fieldSelector.Matches(SelectableFields(site))
+	}, nil
+}
+
+// SelectableFields returns the fields of a website field selectors can refer to.
+func SelectableFields(site *webv1.WebSite) fields.Set {
+	return fields.Set{
+		"metadata.name":      site.Name,
+		"metadata.namespace": site.Namespace,
+		"spec.hostname":      site.Spec.Hostname,
+		"spec.nginxImage":    site.Spec.NginxImage,
+	}
+}
+
+func isDryRun(dryRun []string) bool {
+	return len(dryRun) > 0
+}
+
+// watcher delivers events in order without blocking the Clientset on slow consumers.
+type watcher struct {
+	filter func(event watch.Event) bool
+	result chan watch.Event
+
+	mu      sync.Mutex
+	queue   []watch.Event
+	pending chan struct{}
+	done    chan struct{}
+	stop    sync.Once
+}
+
+func newWatcher(filter func(event watch.Event) bool) *watcher {
+	w := &watcher{
+		filter:  filter,
+		result:  make(chan watch.Event),
+		pending: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+func (w *watcher) send(event watch.Event) {
+	if !w.filter(event) {
+		return
+	}
+
+	w.mu.Lock()
+	w.queue = append(w.queue, event)
+	w.mu.Unlock()
+
+	select {
+	case w.pending <- struct{}{}:
+	default:
+	}
+}
+
+func (w *watcher) run() {
+	defer close(w.result)
+	for {
+		w.mu.Lock()
+		queue := w.queue
+		w.queue = nil
+		w.mu.Unlock()
+
+		for _, event := range queue {
+			select {
+			case w.result <- event:
+			case <-w.done:
+				return
+			}
+		}
+
+		select {
+		case <-w.pending:
+		case <-w.done:
+			return
+		}
+	}
+}
+
+func (w *watcher) Stop() {
+	w.stop.Do(func() { close(w.done) })
+}
+
+func (w *watcher) ResultChan() <-chan watch.Event {
+	return w.result
+}
@@ -5,11 +5,15 @@ import (
 	webv1 "website-operator/api/v1"
 	"website-operator/internal"
 	"website-operator/internal/controller"
+	"website-operator/internal/imagepolicy"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 )
 
 func main() {
@@ -23,24 +27,73 @@ func main() {
 	scheme := runtime.NewScheme()
 	log := ctrl.Log.WithName("setup website controller")
 	utilruntime.Must(webv1.AddToScheme(scheme))
+	// Secrets referenced for basic auth are watched
+	utilruntime.Must(corev1.AddToScheme(scheme))
 
-	mgr, err := ctrl.NewManager(config, ctrl.Options{
-		Scheme: scheme,
-	})
+	scope, err := readScope()
+	if err != nil {
+		log.Error(err, "invalid configuration")
+		os.Exit(1)
+	}
+
+	opts, err := managerOptions(scheme, scope)
+	if err != nil {
+		log.Error(err, "invalid configuration")
+		os.Exit(1)
+	}
+
+	images, err := imagepolicy.FromEnv()
+	if err != nil {
+		log.Error(err, "invalid configuration")
+		os.Exit(1)
+	}
+	resolver, err := imageResolver()
+	if err != nil {
+		log.Error(err, "invalid configuration")
+		os.Exit(1)
+	}
+	exposure, err := readExposure()
+	if err != nil {
+		log.Error(err, "invalid configuration")
+		os.Exit(1)
+	}
+
+	mgr, err := ctrl.NewManager(config, opts)
 	if err != nil {
 		log.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
-	err = ctrl.NewControllerManagedBy(mgr).
-		For(&webv1.WebSite{}).
-		Complete(controller.NewWebsiteController(mgr, clientset))
+	if err := mgr.AddHealthzCheck("ping", healthz.Ping); err != nil {
+		log.Error(err, "unable to set up health check")
+		os.Exit(1)
+	}
+	if err := mgr.AddReadyzCheck("informers", cacheSyncCheck(mgr)); err != nil {
+		log.Error(err, "unable to set up ready check")
+		os.Exit(1)
+	}
+
+	metrics := controller.NewMetrics(mgr.GetCache())
+	if err := ctrlmetrics.Registry.Register(metrics); err != nil {
+		log.Error(err, "unable to register metrics")
+		os.Exit(1)
+	}
+
+	err = controller.NewWebsiteController(mgr, clientset, metrics, images, resolver, exposure).SetupWithManager(mgr)
 	if err != nil {
 		log.Error(err, "unable to create controller")
 		os.Exit(1)
 	}
 
-	log.Info("starting manager")
+	// websites created without the HTTP API lack a shard label, so one shard labels them
+	if scope.sharded() && scope.shard == 0 {
+		if err := mgr.Add(controller.NewShardLabeler(mgr, scope.namespaces)); err != nil {
+			log.Error(err, "unable to set up shard labeler")
+			os.Exit(1)
+		}
+	}
+
+	log.Info("starting manager", "namespaces", scope.namespaces, "selector", scope.selector.String())
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
 		log.Error(err, "error running manager")
 		os.Exit(1)
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+	webv1 "website-operator/api/v1"
+	"website-operator/internal"
+	"website-operator/internal/controller"
+	"website-operator/internal/imagepolicy"
+	"website-operator/internal/shard"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+)
+
+// managerOptions reads the manager configuration from the environment:
+//
+//	CONTROLLER_METRICS_ADDR                bind address of the metrics endpoint, default :8080, 0 disables it
+//	CONTROLLER_HEALTH_PROBE_ADDR           bind address of /healthz and /readyz, default :8081
+//	CONTROLLER_LEADER_ELECT                enables leader election for running multiple replicas, default false
+//	CONTROLLER_LEADER_ELECTION_ID          name of the lease, default website-controller.anexia.com,
+//	                                       suffixed with the shard if sharded
+//	CONTROLLER_LEADER_ELECTION_NAMESPACE   namespace of the lease, defaults to the namespace of the pod
+//	CONTROLLER_LEADER_ELECTION_LEASE       lease duration, default 15s
+//	CONTROLLER_LEADER_ELECTION_RENEW       renew deadline, default 10s
+func managerOptions(scheme *runtime.Scheme, scope scope) (ctrl.Options, error) {
+	leaderElection, err := internal.BoolFromEnvWithDefault("CONTROLLER_LEADER_ELECT", false)
+	if err != nil {
+		return ctrl.Options{}, err
+	}
+	leaseDuration, err := internal.DurationFromEnvWithDefault("CONTROLLER_LEADER_ELECTION_LEASE", 15*time.Second)
+	if err != nil {
+		return ctrl.Options{}, err
+	}
+	renewDeadline, err := internal.DurationFromEnvWithDefault("CONTROLLER_LEADER_ELECTION_RENEW", 10*time.Second)
+	if err != nil {
+		return ctrl.Options{}, err
+	}
+	if renewDeadline >= leaseDuration {
+		return ctrl.Options{}, errors.New("CONTROLLER_LEADER_ELECTION_RENEW must be shorter than CONTROLLER_LEADER_ELECTION_LEASE")
+	}
+
+	// every shard elects its own leader
+	leaderElectionID := internal.FromEnvWithDefault("CONTROLLER_LEADER_ELECTION_ID", "website-controller.anexia.com")
+	if scope.sharded() {
+		leaderElectionID = fmt.Sprintf("%s-shard-%d", leaderElectionID, scope.shard)
+	}
+
+	return ctrl.Options{
+		Scheme: scheme,
+		Cache:  scope.cacheOptions(),
+		Metrics: metricsserver.Options{
+			BindAddress: internal.FromEnvWithDefault("CONTROLLER_METRICS_ADDR", ":8080"),
+		},
+		HealthProbeBindAddress:  internal.FromEnvWithDefault("CONTROLLER_HEALTH_PROBE_ADDR", ":8081"),
+		LeaderElection:          leaderElection,
+		LeaderElectionID:        leaderElectionID,
+		LeaderElectionNamespace: internal.FromEnvWithDefault("CONTROLLER_LEADER_ELECTION_NAMESPACE", ""),
+		LeaseDuration:           &leaseDuration,
+		RenewDeadline:           &renewDeadline,
+		// a new leader takes over right away when the current one shuts down, which is safe
+		// because main returns right after the manager stopped
+		LeaderElectionReleaseOnCancel: true,
+		// a website failing in a hot loop would flood its events, so similar events are combined
+		// early and each website is limited to a burst of 10 events, then one every 5 minutes.
+		// The broadcaster lives as long as the process, since main returns once the manager stopped.
+		EventBroadcaster: record.NewBroadcasterWithCorrelatorOptions(record.CorrelatorOptions{
+			MaxEvents: 5,
+			BurstSize: 10,
+			QPS:       1. / 300,
+		}),
+	}, nil
+}
+
+// cacheSyncCheck reports ready once the informer caches are synced, so rollouts wait until a
+// new replica has loaded the websites.
+func cacheSyncCheck(mgr ctrl.Manager) healthz.Checker {
+	return func(req *http.Request) error {
+		ctx, cancel := context.WithTimeout(req.Context(), time.Second)
+		defer cancel()
+
+		if !mgr.GetCache().WaitForCacheSync(ctx) {
+			return errors.New("informer caches not synced")
+		}
+		return nil
+	}
+}
+
+// scope limits the websites handled by the controller, read from the environment:
+//
+//	CONTROLLER_NAMESPACES         comma-separated namespaces to watch, default all namespaces
+//	CONTROLLER_WEBSITE_SELECTOR   label selector of the websites to handle, e.g. tier=public
+//	CONTROLLER_SHARDS             number of controller instances splitting the websites, default 1
+//	CONTROLLER_SHARD              shard handled by this instance, 0 to CONTROLLER_SHARDS-1
+type scope struct {
+	namespaces []string
+	selector   labels.Selector
+
+	shard, shards int
+}
+
+func readScope() (scope, error) {
+	s := scope{selector: labels.Everything()}
+
+	for _, namespace := range strings.Split(internal.FromEnvWithDefault("CONTROLLER_NAMESPACES", ""), ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" {
+			s.namespaces = append(s.namespaces, namespace)
+		}
+	}
+
+	selector, err := labels.Parse(internal.FromEnvWithDefault("CONTROLLER_WEBSITE_SELECTOR", ""))
+	if err != nil {
+		return scope{}, fmt.Errorf("invalid label selector in CONTROLLER_WEBSITE_SELECTOR: %w", err)
+	}
+	s.selector = selector
+
+	if s.shards, err = internal.IntFromEnvWithDefault("CONTROLLER_SHARDS", 1); err != nil {
+		return scope{}, err
+	}
+	if s.shard, err = internal.IntFromEnvWithDefault("CONTROLLER_SHARD", 0); err != nil {
+		return scope{}, err
+	}
+	if s.sharded() {
+		requirement, err := shard.Requirement(s.shard, s.shards)
+		if err != nil {
+			return scope{}, err
+		}
+		s.selector = s.selector.Add(*requirement)
+	} else if s.shard != 0 {
+		return scope{}, errors.New("CONTROLLER_SHARD requires CONTROLLER_SHARDS")
+	}
+
+	return s, nil
+}
+
+func (s scope) sharded() bool {
+	return s.shards > 1
+}
+
+// cacheOptions restricts the cache, and so the websites the controller sees, to the scope.
+func (s scope) cacheOptions() cache.Options {
+	opts := cache.Options{}
+	if len(s.namespaces) > 0 {
+		opts.DefaultNamespaces = make(map[string]cache.Config, len(s.namespaces))
+		for _, namespace := range s.namespaces {
+			opts.DefaultNamespaces[namespace] = cache.Config{}
+		}
+	}
+	if !s.selector.Empty() {
+		opts.ByObject = map[client.Object]cache.ByObject{
+			&webv1.WebSite{}: {Label: s.selector},
+		}
+	}
+	return opts
+}
+
+// imageResolver creates the resolver pinning the images of websites to digests, read from the
+// environment, or nil if disabled:
+//
+//	CONTROLLER_RESOLVE_IMAGE_DIGESTS   resolves image tags to digests via the registry, default false
+//	CONTROLLER_INSECURE_REGISTRIES     comma-separated registries accessed via plain HTTP
+//	CONTROLLER_REGISTRY_TIMEOUT        timeout of registry requests, default 10s
+func imageResolver() (*imagepolicy.Resolver, error) {
+	enabled, err := internal.BoolFromEnvWithDefault("CONTROLLER_RESOLVE_IMAGE_DIGESTS", false)
+	if err != nil || !enabled {
+		return nil, err
+	}
+	timeout, err := internal.DurationFromEnvWithDefault("CONTROLLER_REGISTRY_TIMEOUT", 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	var insecure []string
+	for _, registry := range strings.Split(internal.FromEnvWithDefault("CONTROLLER_INSECURE_REGISTRIES", ""), ",") {
+		if registry = strings.TrimSpace(registry); registry != "" {
+			insecure = append(insecure, registry)
+		}
+	}
+	return imagepolicy.NewResolver(&http.Client{Timeout: timeout}, insecure), nil
+}
+
+// readExposure reads how websites are exposed from the environment:
+//
+//	CONTROLLER_EXPOSURE_MODE      ingress-nginx (default) enforces access restrictions by ingress
+//	                              annotations, ingress by the nginx of each website
+//	CONTROLLER_TRUSTED_PROXIES    comma-separated CIDRs of the ingress controller, whose
+//	                              X-Forwarded-For header is trusted, required for mode ingress
+func readExposure() (controller.Exposure, error) {
+	exposure := controller.Exposure{
+		Mode: controller.ExposureMode(internal.FromEnvWithDefault("CONTROLLER_EXPOSURE_MODE", string(controller.ExposureIngressNginx))),
+	}
+	if exposure.Mode != controller.ExposureIngressNginx && exposure.Mode != controller.ExposureIngress {
+		return controller.Exposure{}, fmt.Errorf("CONTROLLER_EXPOSURE_MODE must be %s or %s, got '%s'",
+			controller.ExposureIngressNginx, controller.ExposureIngress, exposure.Mode)
+	}
+
+	for _, cidr := range strings.Split(internal.FromEnvWithDefault("CONTROLLER_TRUSTED_PROXIES", ""), ",") {
+		if cidr = strings.TrimSpace(cidr); cidr == "" {
+			continue
+		}
+		if _, err := netip.ParsePrefix(cidr); err != nil {
+			return controller.Exposure{}, fmt.Errorf("invalid CIDR in CONTROLLER_TRUSTED_PROXIES: %w", err)
+		}
+		exposure.TrustedProxies = append(exposure.TrustedProxies, cidr)
+	}
+	// without trusted proxies, nginx sees the address of the ingress controller as client address
+	if exposure.Mode == controller.ExposureIngress && len(exposure.TrustedProxies) == 0 {
+		return controller.Exposure{}, errors.New("CONTROLLER_TRUSTED_PROXIES is required for CONTROLLER_EXPOSURE_MODE ingress")
+	}
+	return exposure, nil
+}
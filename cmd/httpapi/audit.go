@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"website-operator/internal"
+	"website-operator/internal/httpapi/audit"
+)
+
+// auditSink configures where audit events of mutating calls are written:
+//
+//	HTTPAPI_AUDIT_SINK                 none | stdout | file | webhook
+//	HTTPAPI_AUDIT_FILE                 path of the audit log (file)
+//	HTTPAPI_AUDIT_FILE_MAX_SIZE_MB     rotate the file once it exceeds this size, 0 disables rotation (file)
+//	HTTPAPI_AUDIT_FILE_MAX_BACKUPS     number of rotated files to keep (file)
+//	HTTPAPI_AUDIT_WEBHOOK_URL          endpoint the events are posted to (webhook)
+//
+// A nil sink is returned if auditing is disabled.
+func auditSink() (audit.Sink, error) {
+	mode := internal.FromEnvWithDefault("HTTPAPI_AUDIT_SINK", "none")
+	switch mode {
+	case "none":
+		return nil, nil
+	case "stdout":
+		return audit.NewWriterSink(os.Stdout), nil
+	case "file":
+		maxSize, err := internal.IntFromEnvWithDefault("HTTPAPI_AUDIT_FILE_MAX_SIZE_MB", 100)
+		if err != nil {
+			return nil, err
+		}
+		maxBackups, err := internal.IntFromEnvWithDefault("HTTPAPI_AUDIT_FILE_MAX_BACKUPS", 5)
+		if err != nil {
+			return nil, err
+		}
+
+		return audit.NewFileSink(internal.FromEnvWithDefault("HTTPAPI_AUDIT_FILE", "audit.log"), audit.RotationOptions{
+			MaxSize:    int64(maxSize) << 20,
+			MaxBackups: maxBackups,
+		})
+	case "webhook":
+		url := internal.FromEnvWithDefault("HTTPAPI_AUDIT_WEBHOOK_URL", "")
+		if url == "" {
+			return nil, fmt.Errorf("HTTPAPI_AUDIT_WEBHOOK_URL is required for the webhook audit sink")
+		}
+		return audit.NewWebhookSink(url, nil), nil
+	default:
+		return nil, fmt.Errorf("unknown audit sink '%s'", mode)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"time"
+	webv1client "website-operator/clientset/v1"
+	"website-operator/internal"
+	"website-operator/internal/httpapi"
+	"website-operator/internal/httpapi/auth"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/client-go/rest"
+)
+
+// authMiddleware configures authentication and authorization from the environment:
+//
+//	HTTPAPI_AUTHN                 none | token | jwt
+//	HTTPAPI_AUTHN_TOKEN_FILE      CSV file with token,user,"groups" per line (token)
+//	HTTPAPI_AUTHN_JWKS_FILE       JWKS with the trusted signing keys (jwt)
+//	HTTPAPI_AUTHN_JWT_ISSUER      required iss claim (jwt, optional)
+//	HTTPAPI_AUTHN_JWT_AUDIENCE    required aud claim (jwt, optional)
+//	HTTPAPI_AUTHZ                 none | namespaces | impersonate
+//	HTTPAPI_AUTHZ_NAMESPACE_FILE  YAML mapping users and groups to namespaces (namespaces)
+//
+// With impersonate, the returned client acts as the caller against the Kubernetes API.
+func authMiddleware(config *rest.Config, clientSet webv1client.WebsiteV1Interface) ([]gin.HandlerFunc, webv1client.WebsiteV1Interface, error) {
+	var middleware []gin.HandlerFunc
+
+	authnMode := internal.FromEnvWithDefault("HTTPAPI_AUTHN", "none")
+	switch authnMode {
+	case "none":
+	case "token":
+		authn, err := auth.NewStaticTokenAuthenticatorFromFile(internal.FromEnvWithDefault("HTTPAPI_AUTHN_TOKEN_FILE", "tokens.csv"))
+		if err != nil {
+			return nil, nil, err
+		}
+		middleware = append(middleware, auth.Authenticate(authn))
+	case "jwt":
+		authn, err := auth.NewJWTAuthenticatorFromFile(internal.FromEnvWithDefault("HTTPAPI_AUTHN_JWKS_FILE", "jwks.json"), auth.JWTOptions{
+			Issuer:   internal.FromEnvWithDefault("HTTPAPI_AUTHN_JWT_ISSUER", ""),
+			Audience: internal.FromEnvWithDefault("HTTPAPI_AUTHN_JWT_AUDIENCE", ""),
+			Leeway:   30 * time.Second,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		middleware = append(middleware, auth.Authenticate(authn))
+	default:
+		return nil, nil, fmt.Errorf("unknown authentication mode '%s'", authnMode)
+	}
+
+	authzMode := internal.FromEnvWithDefault("HTTPAPI_AUTHZ", "none")
+	if authzMode != "none" && authnMode == "none" {
+		return nil, nil, fmt.Errorf("authorization mode '%s' requires authentication", authzMode)
+	}
+
+	switch authzMode {
+	case "none":
+	case "namespaces":
+		authz, err := auth.NewNamespaceAuthorizerFromFile(internal.FromEnvWithDefault("HTTPAPI_AUTHZ_NAMESPACE_FILE", "namespaces.yaml"))
+		if err != nil {
+			return nil, nil, err
+		}
+		middleware = append(middleware, auth.Authorize(authz, httpapi.RequestNamespace))
+	case "impersonate":
+		impersonating, err := auth.NewImpersonatingClient(config)
+		if err != nil {
+			return nil, nil, err
+		}
+		clientSet = impersonating
+	default:
+		return nil, nil, fmt.Errorf("unknown authorization mode '%s'", authzMode)
+	}
+
+	return middleware, clientSet, nil
+}
@@ -1,17 +1,25 @@
 package main
 
 import (
+	"context"
+	"log"
+	"os/signal"
+	"syscall"
 	webv1 "website-operator/api/v1"
 	webv1client "website-operator/clientset/v1"
 	"website-operator/internal"
 	"website-operator/internal/httpapi"
+	"website-operator/internal/httpapi/audit"
+	"website-operator/internal/httpserver"
+	"website-operator/internal/imagepolicy"
 
+	"github.com/gin-gonic/gin"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
 )
 
 func main() {
-	_, config, err := internal.GetLocalOrInClusterKubernetes()
+	kubeClient, config, err := internal.GetLocalOrInClusterKubernetes()
 	if err != nil {
 		panic(err.Error())
 	}
@@ -22,11 +30,59 @@ func main() {
 		panic(err)
 	}
 
-	handler := httpapi.NewWebsiteHandler(clientSet)
+	middleware, websiteClient, err := authMiddleware(config, clientSet)
+	if err != nil {
+		panic(err)
+	}
+
+	sink, err := auditSink()
+	if err != nil {
+		panic(err)
+	}
+	if sink != nil {
+		middleware = append(middleware, audit.Middleware(sink, httpapi.RequestNamespace))
+	}
 
-	router := httpapi.NewRouter(handler)
+	images, err := imagepolicy.FromEnv()
+	if err != nil {
+		panic(err)
+	}
 
-	addr := internal.FromEnvWithDefault("HTTPAPI_LISTEN_ADDR", ":8082")
+	metrics := httpapi.NewMetrics()
 
-	panic(router.Run(addr))
+	handler := httpapi.NewWebsiteHandler(metrics.InstrumentClient(websiteClient), kubeClient.CoreV1(), images)
+
+	router := httpapi.NewRouter(handler, append([]gin.HandlerFunc{metrics.Middleware()}, middleware...)...)
+	metrics.Register(router)
+	httpapi.RegisterDocsRoutes(router, internal.FromEnvWithDefault("HTTPAPI_SWAGGER_UI_URL", httpapi.DefaultSwaggerUIURL))
+
+	opts, err := serverOptions()
+	if err != nil {
+		panic(err)
+	}
+
+	server, err := httpserver.New(router, opts)
+	if err != nil {
+		panic(err)
+	}
+
+	httpapi.RegisterHealthRoutes(router, map[string]httpapi.ReadinessCheck{
+		"kubernetes-api": func(ctx context.Context) error {
+			return kubeClient.Discovery().RESTClient().Get().AbsPath("/readyz").Do(ctx).Error()
+		},
+		"server": server.Ready,
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	err = server.Run(ctx)
+
+	// flush pending audit events of the drained requests
+	if sink != nil {
+		sink.Close()
+	}
+	if err != nil {
+		log.Fatalf("server stopped: %v", err)
+	}
 }
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"time"
+	"website-operator/internal"
+	"website-operator/internal/httpserver"
+)
+
+// serverOptions reads the server configuration from the environment:
+//
+//	HTTPAPI_LISTEN_ADDR          listen address, default :8082
+//	HTTPAPI_READ_HEADER_TIMEOUT  default 10s
+//	HTTPAPI_READ_TIMEOUT         default 60s, covers content uploads
+//	HTTPAPI_WRITE_TIMEOUT        default 60s, lifted for watch streams
+//	HTTPAPI_IDLE_TIMEOUT         default 120s
+//	HTTPAPI_DRAIN_PERIOD         time to report not ready before shutting down, default 5s
+//	HTTPAPI_SHUTDOWN_TIMEOUT     time in-flight requests get to finish, default 30s
+//	HTTPAPI_TLS_CERT_FILE        enables HTTPS together with HTTPAPI_TLS_KEY_FILE
+//	HTTPAPI_TLS_KEY_FILE
+func serverOptions() (httpserver.Options, error) {
+	opts := httpserver.Options{
+		Addr:        internal.FromEnvWithDefault("HTTPAPI_LISTEN_ADDR", ":8082"),
+		TLSCertFile: internal.FromEnvWithDefault("HTTPAPI_TLS_CERT_FILE", ""),
+		TLSKeyFile:  internal.FromEnvWithDefault("HTTPAPI_TLS_KEY_FILE", ""),
+	}
+
+	durations := []struct {
+		key    string
+		target *time.Duration
+		value  time.Duration
+	}{
+		{"HTTPAPI_READ_HEADER_TIMEOUT", &opts.ReadHeaderTimeout, 10 * time.Second},
+		{"HTTPAPI_READ_TIMEOUT", &opts.ReadTimeout, 60 * time.Second},
+		{"HTTPAPI_WRITE_TIMEOUT", &opts.WriteTimeout, 60 * time.Second},
+		{"HTTPAPI_IDLE_TIMEOUT", &opts.IdleTimeout, 120 * time.Second},
+		{"HTTPAPI_DRAIN_PERIOD", &opts.DrainPeriod, 5 * time.Second},
+		{"HTTPAPI_SHUTDOWN_TIMEOUT", &opts.ShutdownTimeout, 30 * time.Second},
+	}
+	for _, d := range durations {
+		var err error
+		*d.target, err = internal.DurationFromEnvWithDefault(d.key, d.value)
+		if err != nil {
+			return opts, err
+		}
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,732 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"website-operator/httpapiclient"
+
+	"sigs.k8s.io/yaml"
+)
+
+const defaultNginxImage = "docker.io/nginx:latest"
+
+var (
+	createFlags struct {
+		hostname             string
+		image                string
+		html                 string
+		htmlFile             string
+		revisionHistoryLimit int
+		dryRun               bool
+	}
+	applyFlags struct {
+		file   string
+		dryRun bool
+	}
+	maintenanceFlags struct {
+		htmlFile   string
+		retryAfter int
+	}
+	setContextFlags struct {
+		tokenFile string
+	}
+)
+
+// commands is populated in init, because the completion command refers to it.
+var commands []command
+
+func init() {
+	commands = []command{
+		{
+			name: "list", usage: "list", summary: "List websites",
+			run: runList,
+		},
+		{
+			name: "get", usage: "get NAME", summary: "Show a website",
+			run: runGet, completeNames: true,
+		},
+		{
+			name: "create", usage: "create NAME --hostname HOST", summary: "Create a website",
+			flags: func(fs *flag.FlagSet) {
+				fs.StringVar(&createFlags.hostname, "hostname", "", "hostname the website is served at")
+				fs.StringVar(&createFlags.image, "image", defaultNginxImage, "nginx image serving the website")
+				fs.StringVar(&createFlags.html, "html", "", "HTML content of the index page")
+				fs.StringVar(&createFlags.htmlFile, "html-file", "", "file with the HTML content of the index page, - for stdin")
+				fs.IntVar(&createFlags.revisionHistoryLimit, "revision-history-limit", -1, "number of revisions to keep, -1 for the server default")
+				fs.BoolVar(&createFlags.dryRun, "dry-run", false, "only validate the website")
+			},
+			run: runCreate,
+		},
+		{
+			name: "apply", usage: "apply -f FILE", summary: "Create or update the websites of a manifest file, as written by export",
+			flags: func(fs *flag.FlagSet) {
+				fs.StringVar(&applyFlags.file, "f", "", "manifest file, - for stdin")
+				fs.BoolVar(&applyFlags.dryRun, "dry-run", false, "only report what would change")
+			},
+			run: runApply,
+		},
+		{
+			name: "export", usage: "export", summary: "Print the websites as manifests, in YAML unless -o json is given",
+			run: runExport,
+		},
+		{
+			name: "edit", usage: "edit NAME", summary: "Edit a website in $EDITOR",
+			run: runEdit, completeNames: true,
+		},
+		{
+			name: "delete", usage: "delete NAME...", summary: "Delete websites",
+			run: runDelete, completeNames: true,
+		},
+		{
+			name: "content push", usage: "content push NAME DIR", summary: "Replace the files of a website with the contents of a directory",
+			run: runContentPush, completeNames: true,
+		},
+		{
+			name: "watch", usage: "watch [NAME]", summary: "Stream changes of websites",
+			run: runWatch, completeNames: true,
+		},
+		{
+			name: "revisions", usage: "revisions NAME", summary: "List the revisions of a website",
+			run: runRevisions, completeNames: true,
+		},
+		{
+			name: "rollback", usage: "rollback NAME REVISION", summary: "Restore a website to a revision",
+			run: runRollback, completeNames: true,
+		},
+		{
+			name: "pause", usage: "pause NAME...", summary: "Pause the reconciliation of websites",
+			run: runPause, completeNames: true,
+		},
+		{
+			name: "resume", usage: "resume NAME...", summary: "Resume the reconciliation of websites",
+			run: runResume, completeNames: true,
+		},
+		{
+			name: "maintenance on", usage: "maintenance on NAME...", summary: "Serve a maintenance page with status 503 instead of the content of websites",
+			flags: func(fs *flag.FlagSet) {
+				fs.StringVar(&maintenanceFlags.htmlFile, "html-file", "", "file with the HTML of the maintenance page, - for stdin")
+				fs.IntVar(&maintenanceFlags.retryAfter, "retry-after", 0, "seconds clients are asked to wait before retrying, 0 to omit the Retry-After header")
+			},
+			run: runMaintenanceOn, completeNames: true,
+		},
+		{
+			name: "maintenance off", usage: "maintenance off NAME...", summary: "Serve the content of websites again",
+			run: runMaintenanceOff, completeNames: true,
+		},
+		{
+			name: "config get-contexts", usage: "config get-contexts", summary: "List the API contexts of the config file",
+			run: runGetContexts,
+		},
+		{
+			name: "config use-context", usage: "config use-context NAME", summary: "Set the current API context",
+			run: runUseContext,
+		},
+		{
+			name: "config set-context", usage: "config set-context NAME --server URL", summary: "Create or update an API context from --server, -n, --token and --token-file",
+			flags: func(fs *flag.FlagSet) {
+				fs.StringVar(&setContextFlags.tokenFile, "token-file", "", "file the bearer token is read from for every request")
+			},
+			run: runSetContext,
+		},
+		{
+			name: "completion", usage: "completion bash|zsh", summary: "Print a shell completion script",
+			run: runCompletion,
+		},
+	}
+}
+
+func expectArgs(args []string, n int, names string) error {
+	if len(args) != n {
+		return fmt.Errorf("expected arguments %s, got %d arguments", names, len(args))
+	}
+	return nil
+}
+
+// apiCall bounds a single API request by the --timeout flag.
+func (e *env) apiCall(ctx context.Context) (context.Context, context.CancelFunc) {
+	if e.globals.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, e.globals.timeout)
+}
+
+func runList(ctx context.Context, e *env, args []string) error {
+	if err := expectArgs(args, 0, ""); err != nil {
+		return err
+	}
+	client, err := e.client()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := e.apiCall(ctx)
+	defer cancel()
+	sites, err := client.ListWebsites(ctx)
+	if err != nil {
+		return err
+	}
+
+	// lists are printed as lists in machine readable formats, even with a single website
+	if e.globals.output == "json" || e.globals.output == "yaml" {
+		_, err := printObject(e.stdout, e.globals.output, sites)
+		return err
+	}
+	return printWebsites(e.stdout, e.globals.output, sites)
+}
+
+func runGet(ctx context.Context, e *env, args []string) error {
+	if err := expectArgs(args, 1, "NAME"); err != nil {
+		return err
+	}
+	client, err := e.client()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := e.apiCall(ctx)
+	defer cancel()
+	site, err := client.GetWebsite(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	return printWebsites(e.stdout, e.globals.output, []*httpapiclient.WebsiteDTO{site})
+}
+
+func runCreate(ctx context.Context, e *env, args []string) error {
+	if err := expectArgs(args, 1, "NAME"); err != nil {
+		return err
+	}
+	if createFlags.hostname == "" {
+		return errors.New("--hostname is required")
+	}
+
+	html := createFlags.html
+	if createFlags.htmlFile != "" {
+		b, err := readFileOrStdin(e, createFlags.htmlFile)
+		if err != nil {
+			return err
+		}
+		html = string(b)
+	}
+
+	dto := httpapiclient.WebsiteCreateDTO{
+		Name: args[0],
+		WebsiteBase: httpapiclient.WebsiteBase{
+			HtmlContent: html,
+			Hostname:    createFlags.hostname,
+			NginxImage:  createFlags.image,
+		},
+	}
+	if createFlags.revisionHistoryLimit >= 0 {
+		limit := int32(createFlags.revisionHistoryLimit)
+		dto.RevisionHistoryLimit = &limit
+	}
+
+	var opts []httpapiclient.RequestOption
+	if createFlags.dryRun {
+		opts = append(opts, httpapiclient.DryRun())
+	}
+
+	client, err := e.client()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := e.apiCall(ctx)
+	defer cancel()
+	site, err := client.CreateWebsite(ctx, dto, opts...)
+	if err != nil {
+		return err
+	}
+
+	if e.globals.output == "table" {
+		if site.DryRun {
+			fmt.Fprintf(e.stdout, "website/%s created (dry run)\n", site.Name)
+			printDiff(e.stdout, site.Diff)
+			return nil
+		}
+		fmt.Fprintf(e.stdout, "website/%s created\n", site.Name)
+		return nil
+	}
+	return printWebsites(e.stdout, e.globals.output, []*httpapiclient.WebsiteDTO{site})
+}
+
+func runApply(ctx context.Context, e *env, args []string) error {
+	if err := expectArgs(args, 0, ""); err != nil {
+		return err
+	}
+	if applyFlags.file == "" {
+		return errors.New("-f is required")
+	}
+
+	b, err := readFileOrStdin(e, applyFlags.file)
+	if err != nil {
+		return err
+	}
+
+	var opts []httpapiclient.RequestOption
+	if applyFlags.dryRun {
+		opts = append(opts, httpapiclient.DryRun())
+	}
+
+	client, err := e.client()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := e.apiCall(ctx)
+	defer cancel()
+	// YAML is a superset of JSON, the API detects JSON documents
+	result, err := client.ImportWebsites(ctx, "application/yaml", bytes.NewReader(b), opts...)
+	if err != nil {
+		return err
+	}
+
+	if err := printImportResult(e.stdout, e.globals.output, result); err != nil {
+		return err
+	}
+	if failed := result.Failed(); len(failed) > 0 {
+		return fmt.Errorf("%d of %d websites failed", len(failed), len(result.Items))
+	}
+	return nil
+}
+
+func runExport(ctx context.Context, e *env, args []string) error {
+	if err := expectArgs(args, 0, ""); err != nil {
+		return err
+	}
+	client, err := e.client()
+	if err != nil {
+		return err
+	}
+
+	format := httpapiclient.BundleYAML
+	if e.globals.output == "json" {
+		format = httpapiclient.BundleJSON
+	}
+
+	ctx, cancel := e.apiCall(ctx)
+	defer cancel()
+	return client.ExportWebsites(ctx, e.stdout, format)
+}
+
+const editHeader = `# Edit the website below, lines starting with '#' are ignored.
+# Saving an unchanged file or an empty file cancels the edit.
+`
+
+func runEdit(ctx context.Context, e *env, args []string) error {
+	if err := expectArgs(args, 1, "NAME"); err != nil {
+		return err
+	}
+	client, err := e.client()
+	if err != nil {
+		return err
+	}
+
+	getCtx, cancel := e.apiCall(ctx)
+	defer cancel()
+	site, err := client.GetWebsite(getCtx, args[0])
+	if err != nil {
+		return err
+	}
+
+	original, err := yaml.Marshal(httpapiclient.WebsiteUpdateDTO{WebsiteBase: site.WebsiteBase, Suspend: &site.Suspend})
+	if err != nil {
+		return err
+	}
+
+	edited, err := editInEditor(e, args[0], append([]byte(editHeader), original...))
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(edited)) == 0 || bytes.Equal(edited, original) {
+		fmt.Fprintln(e.stdout, "Edit cancelled, no changes made.")
+		return nil
+	}
+
+	var dto httpapiclient.WebsiteUpdateDTO
+	if err := yaml.UnmarshalStrict(edited, &dto); err != nil {
+		return fmt.Errorf("invalid website: %w", err)
+	}
+	// the server keeps omitted fields, removing them in the editor resets them
+	if dto.Access == nil && site.Access != nil {
+		dto.Access = &httpapiclient.AccessDTO{}
+	}
+	if dto.Server == nil && site.Server != nil {
+		dto.Server = &httpapiclient.ServerDTO{}
+	}
+	if dto.Suspend == nil {
+		dto.Suspend = new(bool)
+	}
+
+	updateCtx, cancel := e.apiCall(ctx)
+	defer cancel()
+	if _, err := client.UpdateWebsite(updateCtx, args[0], dto); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "website/%s edited\n", args[0])
+	return nil
+}
+
+// editInEditor opens content in $VISUAL or $EDITOR and returns it without comment lines.
+func editInEditor(e *env, name string, content []byte) ([]byte, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	f, err := os.CreateTemp("", "websitectl-"+name+"-*.yaml")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(content)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	// the editor may be given with arguments, e.g. "code --wait"
+	parts := strings.Fields(editor)
+	cmd := exec.Command(parts[0], append(parts[1:], f.Name())...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, e.stdout, e.stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("editor failed: %w", err)
+	}
+
+	b, err := os.ReadFile(f.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for _, line := range strings.SplitAfter(string(b), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	return []byte(strings.Join(lines, "")), nil
+}
+
+func runDelete(ctx context.Context, e *env, args []string) error {
+	if len(args) == 0 {
+		return errors.New("expected arguments NAME...")
+	}
+	client, err := e.client()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, name := range args {
+		callCtx, cancel := e.apiCall(ctx)
+		err := client.DeleteWebsite(callCtx, name)
+		cancel()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("website/%s: %w", name, err))
+			continue
+		}
+		fmt.Fprintf(e.stdout, "website/%s deleted\n", name)
+	}
+	return errors.Join(errs...)
+}
+
+func runPause(ctx context.Context, e *env, args []string) error {
+	return setPaused(ctx, e, args, true)
+}
+
+func runResume(ctx context.Context, e *env, args []string) error {
+	return setPaused(ctx, e, args, false)
+}
+
+func setPaused(ctx context.Context, e *env, args []string, paused bool) error {
+	if len(args) == 0 {
+		return errors.New("expected arguments NAME...")
+	}
+	client, err := e.client()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, name := range args {
+		callCtx, cancel := e.apiCall(ctx)
+		var site *httpapiclient.WebsiteDTO
+		if paused {
+			site, err = client.PauseWebsite(callCtx, name)
+		} else {
+			site, err = client.ResumeWebsite(callCtx, name)
+		}
+		cancel()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("website/%s: %w", name, err))
+			continue
+		}
+
+		switch {
+		case paused:
+			fmt.Fprintf(e.stdout, "website/%s paused\n", name)
+		case site.Paused:
+			fmt.Fprintf(e.stdout, "website/%s resumed, but stays paused while spec.suspend is set\n", name)
+		default:
+			fmt.Fprintf(e.stdout, "website/%s resumed\n", name)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func runMaintenanceOn(ctx context.Context, e *env, args []string) error {
+	if maintenanceFlags.retryAfter < 0 {
+		return errors.New("--retry-after must not be negative")
+	}
+	maintenance := httpapiclient.MaintenanceDTO{Enabled: true, RetryAfterSeconds: int32(maintenanceFlags.retryAfter)}
+	if maintenanceFlags.htmlFile != "" {
+		b, err := readFileOrStdin(e, maintenanceFlags.htmlFile)
+		if err != nil {
+			return err
+		}
+		maintenance.HTML = string(b)
+	}
+	return setMaintenance(ctx, e, args, maintenance)
+}
+
+func runMaintenanceOff(ctx context.Context, e *env, args []string) error {
+	return setMaintenance(ctx, e, args, httpapiclient.MaintenanceDTO{})
+}
+
+func setMaintenance(ctx context.Context, e *env, args []string, maintenance httpapiclient.MaintenanceDTO) error {
+	if len(args) == 0 {
+		return errors.New("expected arguments NAME...")
+	}
+	client, err := e.client()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, name := range args {
+		callCtx, cancel := e.apiCall(ctx)
+		_, err := client.SetWebsiteMaintenance(callCtx, name, maintenance)
+		cancel()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("website/%s: %w", name, err))
+			continue
+		}
+		if maintenance.Enabled {
+			fmt.Fprintf(e.stdout, "website/%s in maintenance\n", name)
+		} else {
+			fmt.Fprintf(e.stdout, "website/%s out of maintenance\n", name)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func runContentPush(ctx context.Context, e *env, args []string) error {
+	if err := expectArgs(args, 2, "NAME DIR"); err != nil {
+		return err
+	}
+	client, err := e.client()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := e.apiCall(ctx)
+	defer cancel()
+	site, err := client.UploadContent(ctx, args[0], args[1])
+	if err != nil {
+		return err
+	}
+
+	if e.globals.output == "table" {
+		fmt.Fprintf(e.stdout, "website/%s content pushed, %d files\n", site.Name, len(site.Files))
+		return nil
+	}
+	return printWebsites(e.stdout, e.globals.output, []*httpapiclient.WebsiteDTO{site})
+}
+
+func runWatch(ctx context.Context, e *env, args []string) error {
+	if len(args) > 1 {
+		return errors.New("expected at most one argument NAME")
+	}
+	client, err := e.client()
+	if err != nil {
+		return err
+	}
+
+	var events <-chan httpapiclient.WebsiteEventDTO
+	if len(args) == 1 {
+		events, err = client.WatchWebsite(ctx, args[0])
+	} else {
+		events, err = client.WatchWebsites(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(e.stdout, 0, 8, 3, ' ', 0)
+	if e.globals.output == "table" {
+		fmt.Fprintln(tw, "EVENT\tNAME\tHOSTNAME\tGENERATION")
+		tw.Flush()
+	}
+
+	for event := range events {
+		if event.Type == "ERROR" {
+			return fmt.Errorf("watch ended: %s", event.Error)
+		}
+
+		switch e.globals.output {
+		case "table":
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\n", event.Type, event.Website.Name, event.Website.Hostname, event.Website.Generation)
+			tw.Flush()
+		case "name":
+			fmt.Fprintln(e.stdout, event.Website.Name)
+		case "yaml":
+			fmt.Fprintln(e.stdout, "---")
+			fallthrough
+		default:
+			if _, err := printObject(e.stdout, e.globals.output, event); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func runRevisions(ctx context.Context, e *env, args []string) error {
+	if err := expectArgs(args, 1, "NAME"); err != nil {
+		return err
+	}
+	client, err := e.client()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := e.apiCall(ctx)
+	defer cancel()
+	revisions, err := client.ListRevisions(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	return printRevisions(e.stdout, e.globals.output, revisions)
+}
+
+func runRollback(ctx context.Context, e *env, args []string) error {
+	if err := expectArgs(args, 2, "NAME REVISION"); err != nil {
+		return err
+	}
+	revision, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid revision '%s'", args[1])
+	}
+	client, err := e.client()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := e.apiCall(ctx)
+	defer cancel()
+	if _, err := client.RollbackWebsite(ctx, args[0], revision); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "website/%s rolled back to revision %d\n", args[0], revision)
+	return nil
+}
+
+func runGetContexts(_ context.Context, e *env, args []string) error {
+	if err := expectArgs(args, 0, ""); err != nil {
+		return err
+	}
+	cfg, err := loadConfig(e.globals.configFile)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(e.stdout, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(tw, "CURRENT\tNAME\tSERVER\tNAMESPACE")
+	for _, c := range cfg.Contexts {
+		current := ""
+		if c.Name == cfg.CurrentContext {
+			current = "*"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", current, c.Name, c.Server, c.Namespace)
+	}
+	return tw.Flush()
+}
+
+func runUseContext(_ context.Context, e *env, args []string) error {
+	if err := expectArgs(args, 1, "NAME"); err != nil {
+		return err
+	}
+	cfg, err := loadConfig(e.globals.configFile)
+	if err != nil {
+		return err
+	}
+	if cfg.context(args[0]) == nil {
+		return fmt.Errorf("context '%s' not found in config file", args[0])
+	}
+
+	cfg.CurrentContext = args[0]
+	if err := cfg.save(e.globals.configFile); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "switched to context %s\n", args[0])
+	return nil
+}
+
+func runSetContext(_ context.Context, e *env, args []string) error {
+	if err := expectArgs(args, 1, "NAME"); err != nil {
+		return err
+	}
+	cfg, err := loadConfig(e.globals.configFile)
+	if err != nil {
+		return err
+	}
+
+	c := cfg.context(args[0])
+	if c == nil {
+		cfg.Contexts = append(cfg.Contexts, apiContext{Name: args[0]})
+		c = &cfg.Contexts[len(cfg.Contexts)-1]
+	}
+	if e.globals.server != "" {
+		c.Server = e.globals.server
+	}
+	if e.globals.namespace != "" {
+		c.Namespace = e.globals.namespace
+	}
+	if e.globals.token != "" {
+		c.Token, c.TokenFile = e.globals.token, ""
+	}
+	if setContextFlags.tokenFile != "" {
+		c.Token, c.TokenFile = "", setContextFlags.tokenFile
+	}
+	if c.Server == "" {
+		return errors.New("--server is required for new contexts")
+	}
+	if cfg.CurrentContext == "" {
+		cfg.CurrentContext = c.Name
+	}
+
+	if err := cfg.save(e.globals.configFile); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "context %s saved\n", args[0])
+	return nil
+}
+
+func readFileOrStdin(e *env, file string) ([]byte, error) {
+	if file == "-" {
+		return io.ReadAll(e.stdin)
+	}
+	return os.ReadFile(file)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// The completion scripts complete commands from the static list below and website names by
+// calling "websitectl list -o name" with the context and namespace flags already typed.
+
+const bashCompletion = `# bash completion for websitectl, load with: source <(websitectl completion bash)
+_websitectl() {
+    local cur="${COMP_WORDS[COMP_CWORD]}"
+    local commands="%[1]s"
+    local name_commands="%[2]s"
+
+    if [[ ${COMP_CWORD} -eq 1 ]]; then
+        COMPREPLY=($(compgen -W "${commands}" -- "${cur}"))
+        return
+    fi
+
+    local cmd="${COMP_WORDS[1]}"
+    local subcommands=""
+    case "${cmd}" in
+%[3]s    esac
+    if [[ -n "${subcommands}" ]]; then
+        if [[ ${COMP_CWORD} -eq 2 ]]; then
+            COMPREPLY=($(compgen -W "${subcommands}" -- "${cur}"))
+            return
+        fi
+        cmd="${cmd}-${COMP_WORDS[2]}"
+    fi
+
+    if [[ "${cur}" == -* ]]; then
+        COMPREPLY=($(compgen -W "-config -context -server -n -token -o -timeout" -- "${cur}"))
+        return
+    fi
+
+    local prev="${COMP_WORDS[COMP_CWORD-1]}"
+    case "${prev}" in
+        -o) COMPREPLY=($(compgen -W "table json yaml name" -- "${cur}")); return ;;
+        -f|-html-file|-config|-token-file) COMPREPLY=($(compgen -f -- "${cur}")); return ;;
+    esac
+
+    if [[ " ${name_commands} " == *" ${cmd} "* ]]; then
+        local args=()
+        local i
+        for ((i = 2; i < COMP_CWORD; i++)); do
+            case "${COMP_WORDS[i]}" in
+                -context|-n|-server|-config) args+=("${COMP_WORDS[i]}" "${COMP_WORDS[i+1]}") ;;
+            esac
+        done
+        COMPREPLY=($(compgen -W "$(websitectl list -o name "${args[@]}" 2>/dev/null)" -- "${cur}"))
+    fi
+}
+complete -o default -F _websitectl websitectl
+`
+
+const zshCompletion = `#compdef websitectl
+# zsh completion for websitectl, load with: source <(websitectl completion zsh)
+autoload -U bashcompinit && bashcompinit
+`
+
+func runCompletion(_ context.Context, e *env, args []string) error {
+	if err := expectArgs(args, 1, "bash|zsh"); err != nil {
+		return err
+	}
+
+	topLevel := map[string]bool{}
+	subcommands := map[string][]string{}
+	var commandNames, nameCommands []string
+	for _, cmd := range commands {
+		first, sub, nested := strings.Cut(cmd.name, " ")
+		if !topLevel[first] {
+			topLevel[first] = true
+			commandNames = append(commandNames, first)
+		}
+		if nested {
+			subcommands[first] = append(subcommands[first], sub)
+		}
+		if cmd.completeNames {
+			nameCommands = append(nameCommands, strings.ReplaceAll(cmd.name, " ", "-"))
+		}
+	}
+
+	var subcommandCases strings.Builder
+	for _, name := range commandNames {
+		if subs, ok := subcommands[name]; ok {
+			fmt.Fprintf(&subcommandCases, "        %s) subcommands=\"%s\" ;;\n", name, strings.Join(subs, " "))
+		}
+	}
+
+	script := fmt.Sprintf(bashCompletion, strings.Join(commandNames, " "), strings.Join(nameCommands, " "), subcommandCases.String())
+
+	switch args[0] {
+	case "bash":
+		fmt.Fprint(e.stdout, script)
+	case "zsh":
+		fmt.Fprint(e.stdout, zshCompletion+script)
+	default:
+		return fmt.Errorf("unsupported shell '%s'", args[0])
+	}
+	return nil
+}
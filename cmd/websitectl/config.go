@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"website-operator/internal"
+
+	"sigs.k8s.io/yaml"
+)
+
+// config is the websitectl config file, which holds named API contexts like a kubeconfig.
+type config struct {
+	CurrentContext string       `json:"currentContext,omitempty"`
+	Contexts       []apiContext `json:"contexts,omitempty"`
+}
+
+// apiContext is an API server and the namespace and credentials used for it.
+type apiContext struct {
+	Name      string `json:"name"`
+	Server    string `json:"server"`
+	Namespace string `json:"namespace,omitempty"`
+	Token     string `json:"token,omitempty"`
+	TokenFile string `json:"tokenFile,omitempty"`
+}
+
+func defaultConfigFile() string {
+	if file := internal.FromEnvWithDefault("WEBSITECTL_CONFIG", ""); file != "" {
+		return file
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "websitectl", "config.yaml")
+}
+
+// loadConfig reads a config file. A missing file is an empty config, so websitectl can be
+// used with flags only.
+func loadConfig(file string) (*config, error) {
+	cfg := &config{}
+	if file == "" {
+		return cfg, nil
+	}
+
+	b, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read config file: %w", err)
+	}
+	if err := yaml.UnmarshalStrict(b, cfg); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", file, err)
+	}
+	return cfg, nil
+}
+
+func (cfg *config) save(file string) error {
+	if file == "" {
+		return errors.New("no config file location, use --config")
+	}
+
+	b, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+		return err
+	}
+	// the file may contain tokens
+	return os.WriteFile(file, b, 0o600)
+}
+
+// selectContext returns the named context, or the current one if name is empty. Without
+// contexts an empty context is returned, to be filled by flags.
+func (cfg *config) selectContext(name string) (apiContext, error) {
+	if name == "" {
+		name = cfg.CurrentContext
+	}
+	if name == "" {
+		return apiContext{}, nil
+	}
+
+	if c := cfg.context(name); c != nil {
+		return *c, nil
+	}
+	return apiContext{}, fmt.Errorf("context '%s' not found in config file", name)
+}
+
+func (cfg *config) context(name string) *apiContext {
+	for i := range cfg.Contexts {
+		if cfg.Contexts[i].Name == name {
+			return &cfg.Contexts[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+// Command websitectl manages websites through the HTTP API.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+	"website-operator/httpapiclient"
+)
+
+// command is a subcommand of websitectl.
+type command struct {
+	name    string
+	usage   string
+	summary string
+	// flags registers the flags of the command in addition to the global flags.
+	flags func(fs *flag.FlagSet)
+	run   func(ctx context.Context, env *env, args []string) error
+	// completeNames completes website names for the positional arguments.
+	completeNames bool
+}
+
+// env is shared by all commands.
+type env struct {
+	globals globalFlags
+	stdin   io.Reader
+	stdout  io.Writer
+	stderr  io.Writer
+}
+
+type globalFlags struct {
+	configFile string
+	context    string
+	server     string
+	namespace  string
+	token      string
+	output     string
+	timeout    time.Duration
+}
+
+func (g *globalFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&g.configFile, "config", defaultConfigFile(), "config file with API contexts")
+	fs.StringVar(&g.context, "context", "", "API context of the config file to use, defaults to the current context")
+	fs.StringVar(&g.server, "server", "", "base URL of the API, overrides the context")
+	fs.StringVar(&g.namespace, "n", "", "namespace of the websites, overrides the context")
+	fs.StringVar(&g.token, "token", "", "bearer token, overrides the context")
+	fs.StringVar(&g.output, "o", "table", "output format: table, json, yaml or name")
+	fs.DurationVar(&g.timeout, "timeout", 30*time.Second, "timeout of API requests")
+}
+
+// client creates an API client for the selected context and flag overrides.
+func (e *env) client() (*httpapiclient.Client, error) {
+	cfg, err := loadConfig(e.globals.configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	apiContext, err := cfg.selectContext(e.globals.context)
+	if err != nil {
+		return nil, err
+	}
+	if e.globals.server != "" {
+		apiContext.Server = e.globals.server
+	}
+	if e.globals.namespace != "" {
+		apiContext.Namespace = e.globals.namespace
+	}
+	if e.globals.token != "" {
+		apiContext.Token = e.globals.token
+		apiContext.TokenFile = ""
+	}
+	if apiContext.Server == "" {
+		return nil, errors.New("no API server configured, use --server or 'websitectl config set-context'")
+	}
+
+	opts := []httpapiclient.ClientOption{
+		httpapiclient.WithUserAgent("websitectl"),
+		httpapiclient.WithNamespace(apiContext.Namespace),
+		httpapiclient.WithRetry(httpapiclient.DefaultRetryPolicy),
+	}
+	switch {
+	case apiContext.TokenFile != "":
+		tokenFile := apiContext.TokenFile
+		// read the file for every request, so rotated tokens are picked up
+		opts = append(opts, httpapiclient.WithTokenSource(func(context.Context) (string, error) {
+			b, err := os.ReadFile(tokenFile)
+			return strings.TrimSpace(string(b)), err
+		}))
+	case apiContext.Token != "":
+		opts = append(opts, httpapiclient.WithBearerToken(apiContext.Token))
+	}
+
+	return httpapiclient.NewDefaultClient(apiContext.Server, opts...)
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	e := &env{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+	if err := run(ctx, e, os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, e *env, args []string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printUsage(e.stdout)
+		return nil
+	}
+
+	name, args := args[0], args[1:]
+	// commands with subcommands, e.g. "content push"
+	if len(args) > 0 && findCommand(name+" "+args[0]) != nil {
+		name, args = name+" "+args[0], args[1:]
+	}
+
+	cmd := findCommand(name)
+	if cmd == nil {
+		printUsage(e.stderr)
+		return fmt.Errorf("unknown command '%s'", name)
+	}
+
+	fs := flag.NewFlagSet("websitectl "+cmd.name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(e.stderr, "Usage: websitectl %s\n\n%s\n\nFlags:\n", cmd.usage, cmd.summary)
+		fs.PrintDefaults()
+	}
+	e.globals.register(fs)
+	if cmd.flags != nil {
+		cmd.flags(fs)
+	}
+
+	positional, err := parseInterspersed(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return cmd.run(ctx, e, positional)
+}
+
+// parseInterspersed parses flags before and after positional arguments, so both
+// "websitectl get -o yaml blog" and "websitectl get blog -o yaml" work.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		// everything after "--" is positional
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+func findCommand(name string) *command {
+	for i := range commands {
+		if commands[i].name == name {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "websitectl manages websites through the website operator HTTP API.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Usage: websitectl <command> [flags] [args]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-38s %s\n", cmd.usage, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'websitectl <command> -h' for the flags of a command.")
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+	"website-operator/httpapiclient"
+
+	"sigs.k8s.io/yaml"
+)
+
+// printObject writes v in a machine readable output format. It returns false for the human
+// readable formats, which are printed by the caller.
+func printObject(w io.Writer, format string, v any) (bool, error) {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return true, enc.Encode(v)
+	case "yaml":
+		b, err := yaml.Marshal(v)
+		if err != nil {
+			return true, err
+		}
+		_, err = w.Write(b)
+		return true, err
+	case "table", "name":
+		return false, nil
+	default:
+		return true, fmt.Errorf("unknown output format '%s'", format)
+	}
+}
+
+func printWebsites(w io.Writer, format string, sites []*httpapiclient.WebsiteDTO) error {
+	var v any = sites
+	if len(sites) == 1 {
+		v = sites[0]
+	}
+	if done, err := printObject(w, format, v); done {
+		return err
+	}
+
+	if format == "name" {
+		for _, site := range sites {
+			fmt.Fprintln(w, site.Name)
+		}
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tHOSTNAME\tIMAGE\tFILES\tGENERATION\tPAUSED\tAGE")
+	for _, site := range sites {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%t\t%s\n",
+			site.Name, site.Hostname, site.NginxImage, len(site.Files), site.Generation, site.Paused, age(site.CreationTimestamp))
+	}
+	return tw.Flush()
+}
+
+func printDiff(w io.Writer, diff []httpapiclient.ChangeDTO) {
+	for _, change := range diff {
+		fmt.Fprintf(w, "%s: %v -> %v\n", change.Field, valueOrNone(change.Old), valueOrNone(change.New))
+	}
+}
+
+func valueOrNone(v any) any {
+	if v == nil {
+		return "<none>"
+	}
+	return v
+}
+
+func printRevisions(w io.Writer, format string, revisions httpapiclient.RevisionListDTO) error {
+	if done, err := printObject(w, format, revisions); done {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(tw, "REVISION\tHOSTNAME\tIMAGE\tFILES\tAGE")
+	for _, rev := range revisions {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%s\n", rev.Revision, rev.Hostname, rev.NginxImage, len(rev.Files), age(rev.CreationTimestamp))
+	}
+	return tw.Flush()
+}
+
+func printImportResult(w io.Writer, format string, result *httpapiclient.ImportResultDTO) error {
+	if done, err := printObject(w, format, result); done {
+		return err
+	}
+
+	suffix := ""
+	if result.DryRun {
+		suffix = " (dry run)"
+	}
+	for _, item := range result.Items {
+		if item.Action == httpapiclient.ImportFailed {
+			fmt.Fprintf(w, "website/%s failed: %s\n", item.Name, item.Error)
+			continue
+		}
+		fmt.Fprintf(w, "website/%s %s%s\n", item.Name, item.Action, suffix)
+	}
+	return nil
+}
+
+// age formats the time since t like kubectl does.
+func age(t time.Time) string {
+	if t.IsZero() {
+		return "<unknown>"
+	}
+
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return strconv.Itoa(int(d.Seconds())) + "s"
+	case d < time.Hour:
+		return strconv.Itoa(int(d.Minutes())) + "m"
+	case d < 48*time.Hour:
+		return strconv.Itoa(int(d.Hours())) + "h"
+	default:
+		return strconv.Itoa(int(d.Hours()/24)) + "d"
+	}
+}
@@ -0,0 +1,34 @@
+package httpapiclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// Bundle formats of ExportWebsites.
+const (
+	BundleYAML = "yaml"
+	BundleJSON = "json"
+)
+
+// ExportWebsites writes the websites as a bundle of manifests to w, either as YAML documents
+// (BundleYAML) or as a JSON List (BundleJSON).
+func (c *Client) ExportWebsites(ctx context.Context, w io.Writer, format string) error {
+	query := url.Values{"format": {format}}
+	return c.doRawRequest(ctx, http.MethodGet, "/api/websites/export?"+query.Encode(), "", nil, w)
+}
+
+// ImportWebsites creates or updates the websites of a bundle written by ExportWebsites. The
+// content type is "application/yaml" or "application/json". With the DryRun option nothing is
+// persisted, but the result still reports what would have changed.
+func (c *Client) ImportWebsites(ctx context.Context, contentType string, bundle io.Reader, opts ...RequestOption) (*ImportResultDTO, error) {
+	endpoint := withRequestOptions("/api/websites/import", opts)
+
+	var result ImportResultDTO
+	if err := c.doRawRequest(ctx, http.MethodPost, endpoint, contentType, bundle, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -9,21 +9,23 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"slices"
 	"time"
 )
 
 type Client struct {
 	baseURL    *url.URL
 	httpClient *http.Client
+	options    clientOptions
 }
 
 // NewDefaultClient creates a new API client with the default http.DefaultClient
-func NewDefaultClient(baseURL string) (*Client, error) {
-	return NewClient(baseURL, http.DefaultClient)
+func NewDefaultClient(baseURL string, opts ...ClientOption) (*Client, error) {
+	return NewClient(baseURL, http.DefaultClient, opts...)
 }
 
 // NewClient creates a new API client.
-func NewClient(baseURL string, httpClient *http.Client) (*Client, error) {
+func NewClient(baseURL string, httpClient *http.Client, opts ...ClientOption) (*Client, error) {
 	u, err := url.Parse(baseURL)
 	if err != nil {
 		return nil, fmt.Errorf("invalid base URL: %w", err)
@@ -31,7 +33,17 @@ func NewClient(baseURL string, httpClient *http.Client) (*Client, error) {
 	if httpClient == nil {
 		httpClient = &http.Client{Timeout: 15 * time.Second}
 	}
-	return &Client{baseURL: u, httpClient: httpClient}, nil
+
+	options := clientOptions{
+		userAgent: defaultUserAgent,
+		headers:   http.Header{},
+		retry:     RetryPolicy{MaxAttempts: 1},
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return &Client{baseURL: u, httpClient: httpClient, options: options}, nil
 }
 
 // --- Public API Methods ---
@@ -44,17 +56,30 @@ func (c *Client) ListWebsites(ctx context.Context) (WebsiteListDTO, error) {
 	return result, nil
 }
 
-func (c *Client) CreateWebsite(ctx context.Context, dto WebsiteCreateDTO) (*WebsiteDTO, error) {
+// GetWebsite returns a website, or an *APIError with status 404 if it doesn't exist.
+func (c *Client) GetWebsite(ctx context.Context, name string) (*WebsiteDTO, error) {
 	var result WebsiteDTO
-	if err := c.doRequest(ctx, http.MethodPost, "/api/websites", dto, &result); err != nil {
+	if err := c.doRequest(ctx, http.MethodGet, path.Join("/api/websites", name), nil, &result); err != nil {
 		return nil, err
 	}
 	return &result, nil
 }
 
-func (c *Client) UpdateWebsite(ctx context.Context, name string, dto WebsiteUpdateDTO) (*WebsiteDTO, error) {
+// CreateWebsite creates a website. With the DryRun option the website is only validated.
+func (c *Client) CreateWebsite(ctx context.Context, dto WebsiteCreateDTO, opts ...RequestOption) (*WebsiteDTO, error) {
 	var result WebsiteDTO
-	endpoint := path.Join("/api/websites", name)
+	endpoint := withRequestOptions("/api/websites", opts)
+	if err := c.doRequest(ctx, http.MethodPost, endpoint, dto, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// UpdateWebsite updates a website. With the DryRun option the returned website contains the
+// changes the update would make, without applying them.
+func (c *Client) UpdateWebsite(ctx context.Context, name string, dto WebsiteUpdateDTO, opts ...RequestOption) (*WebsiteDTO, error) {
+	var result WebsiteDTO
+	endpoint := withRequestOptions(path.Join("/api/websites", name), opts)
 	if err := c.doRequest(ctx, http.MethodPut, endpoint, dto, &result); err != nil {
 		return nil, err
 	}
@@ -66,39 +91,88 @@ func (c *Client) DeleteWebsite(ctx context.Context, name string) error {
 	return c.doRequest(ctx, http.MethodDelete, endpoint, nil, nil)
 }
 
+func (c *Client) ListRevisions(ctx context.Context, name string) (RevisionListDTO, error) {
+	var result RevisionListDTO
+	endpoint := path.Join("/api/websites", name, "revisions")
+	if err := c.doRequest(ctx, http.MethodGet, endpoint, nil, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) RollbackWebsite(ctx context.Context, name string, revision int64) (*WebsiteDTO, error) {
+	var result WebsiteDTO
+	endpoint := path.Join("/api/websites", name, "rollback")
+	if err := c.doRequest(ctx, http.MethodPost, endpoint, RollbackDTO{Revision: revision}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// PauseWebsite stops the controller from reconciling a website until it's resumed.
+func (c *Client) PauseWebsite(ctx context.Context, name string) (*WebsiteDTO, error) {
+	var result WebsiteDTO
+	if err := c.doRequest(ctx, http.MethodPost, path.Join("/api/websites", name, "pause"), nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ResumeWebsite continues the reconciliation of a paused website.
+func (c *Client) ResumeWebsite(ctx context.Context, name string) (*WebsiteDTO, error) {
+	var result WebsiteDTO
+	if err := c.doRequest(ctx, http.MethodPost, path.Join("/api/websites", name, "resume"), nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// SetWebsiteMaintenance swaps a website to a maintenance page or back to its content.
+func (c *Client) SetWebsiteMaintenance(ctx context.Context, name string, maintenance MaintenanceDTO) (*WebsiteDTO, error) {
+	var result WebsiteDTO
+	if err := c.doRequest(ctx, http.MethodPost, path.Join("/api/websites", name, "maintenance"), maintenance, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
 // --- Internal Helpers ---
 func (c *Client) doRequest(ctx context.Context, method, endpoint string, body any, out any) error {
-	u := *c.baseURL
-	u.Path = path.Join(c.baseURL.Path, endpoint)
-
 	var buf io.Reader
+	contentType := ""
 	if body != nil {
 		b, err := json.Marshal(body)
 		if err != nil {
 			return fmt.Errorf("failed to marshal request body: %w", err)
 		}
 		buf = bytes.NewBuffer(b)
+		contentType = "application/json"
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, u.String(), buf)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
+	return c.doRawRequest(ctx, method, endpoint, contentType, buf, out)
+}
 
-	if body != nil {
-		req.Header.Set("Content-Type", "application/json")
+func (c *Client) doRawRequest(ctx context.Context, method, endpoint, contentType string, body io.Reader, out any) error {
+	req, err := c.newRequest(ctx, method, endpoint, contentType, body)
+	if err != nil {
+		return err
 	}
-	req.Header.Set("Accept", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.send(c.httpClient, req)
 	if err != nil {
 		return fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
-		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(b))
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+
+	if w, ok := out.(io.Writer); ok {
+		if _, err := io.Copy(w, resp.Body); err != nil {
+			return fmt.Errorf("failed to read response: %w", err)
+		}
+		return nil
 	}
 
 	if out != nil {
@@ -109,3 +183,101 @@ func (c *Client) doRequest(ctx context.Context, method, endpoint string, body an
 	}
 	return nil
 }
+
+// newRequest creates a request to an endpoint below the base URL, with the headers and
+// credentials configured for the client.
+func (c *Client) newRequest(ctx context.Context, method, endpoint, contentType string, body io.Reader) (*http.Request, error) {
+	ep, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint: %w", err)
+	}
+	u := *c.baseURL
+	u.Path = path.Join(c.baseURL.Path, ep.Path)
+	query := ep.Query()
+	if c.options.namespace != "" && !query.Has("namespace") {
+		query.Set("namespace", c.options.namespace)
+	}
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.options.userAgent)
+	for key, values := range c.options.headers {
+		req.Header[key] = slices.Clone(values)
+	}
+
+	if c.options.tokenSource != nil {
+		token, err := c.options.tokenSource(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return req, nil
+}
+
+// send performs a request with httpClient, retrying it according to the retry policy.
+func (c *Client) send(httpClient *http.Client, req *http.Request) (*http.Response, error) {
+	policy := c.options.retry
+	for attempt := 1; ; attempt++ {
+		for _, hook := range c.options.requestHooks {
+			hook(req)
+		}
+
+		start := time.Now()
+		resp, err := httpClient.Do(req)
+		for _, hook := range c.options.responseHooks {
+			hook(req, resp, err, time.Since(start))
+		}
+
+		if attempt >= policy.MaxAttempts || !policy.shouldRetry(req, resp, err) {
+			return resp, err
+		}
+
+		wait, ok := policy.backoff(attempt, resp)
+		if !ok {
+			return resp, err
+		}
+
+		// requests with a body can only be retried if it can be read again
+		if req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return resp, err
+			}
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return resp, err
+			}
+			req.Body = body
+		}
+
+		if resp != nil {
+			// drain the body to reuse the connection
+			io.Copy(io.Discard, io.LimitReader(resp.Body, maxErrorBodySize))
+			resp.Body.Close()
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// checkResponse returns an *APIError for unsuccessful responses.
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		return newAPIError(resp.StatusCode, b)
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package httpapiclient_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	webv1 "website-operator/api/v1"
+	"website-operator/httpapiclient"
+	"website-operator/httpapiclient/httpapitest"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newSite(name string) httpapiclient.WebsiteCreateDTO {
+	return httpapiclient.WebsiteCreateDTO{
+		Name: name,
+		WebsiteBase: httpapiclient.WebsiteBase{
+			HtmlContent: "<h1>" + name + "</h1>",
+			Hostname:    name + ".local",
+			NginxImage:  "docker.io/nginx:latest",
+		},
+	}
+}
+
+func TestWebsiteLifecycle(t *testing.T) {
+	server := httpapitest.NewServer(t)
+	client := server.Client(t)
+	ctx := context.Background()
+
+	created, err := client.CreateWebsite(ctx, newSite("blog"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if created.Namespace != "default" || created.Generation != 1 {
+		t.Fatalf("unexpected website: %+v", created)
+	}
+
+	dto := httpapiclient.WebsiteUpdateDTO{WebsiteBase: created.WebsiteBase}
+	dto.Hostname = "blog.example.com"
+
+	dryRun, err := client.UpdateWebsite(ctx, "blog", dto, httpapiclient.DryRun())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !dryRun.DryRun || len(dryRun.Diff) != 1 || dryRun.Diff[0].Field != "spec.hostname" {
+		t.Fatalf("unexpected dry-run result: %+v", dryRun)
+	}
+	if got, _ := client.GetWebsite(ctx, "blog"); got.Hostname != "blog.local" {
+		t.Fatalf("dry-run must not persist the update")
+	}
+
+	updated, err := client.UpdateWebsite(ctx, "blog", dto)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated.Hostname != "blog.example.com" || updated.Generation != 2 {
+		t.Fatalf("unexpected website: %+v", updated)
+	}
+
+	sites, err := client.ListWebsites(ctx)
+	if err != nil || len(sites) != 1 {
+		t.Fatalf("expected 1 website, got %d: %v", len(sites), err)
+	}
+
+	if err := client.DeleteWebsite(ctx, "blog"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var apiErr *httpapiclient.APIError
+	if _, err := client.GetWebsite(ctx, "blog"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for deleted website, got %v", err)
+	}
+}
+
+func TestNamespaces(t *testing.T) {
+	server := httpapitest.NewServer(t, &webv1.WebSite{
+		ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "team-a"},
+		Spec:       webv1.WebSiteSpec{Hostname: "shop.local", NginxImage: "docker.io/nginx:latest"},
+	})
+	ctx := context.Background()
+
+	if sites, _ := server.Client(t).ListWebsites(ctx); len(sites) != 0 {
+		t.Fatalf("expected no websites in the default namespace, got %d", len(sites))
+	}
+	sites, err := server.Client(t, httpapiclient.WithNamespace("team-a")).ListWebsites(ctx)
+	if err != nil || len(sites) != 1 || sites[0].Name != "shop" {
+		t.Fatalf("unexpected websites in team-a: %v", err)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	server := httpapitest.NewServer(t)
+	client := server.Client(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	events, err := client.WatchWebsites(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := client.CreateWebsite(ctx, newSite("blog")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := client.DeleteWebsite(ctx, "blog"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, expected := range []string{"ADDED", "DELETED"} {
+		select {
+		case event := <-events:
+			if event.Type != expected || event.Website.Name != "blog" {
+				t.Fatalf("expected %s event for blog, got %+v", expected, event)
+			}
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for %s event", expected)
+		}
+	}
+}
+
+func TestContentAndPreview(t *testing.T) {
+	server := httpapitest.NewServer(t)
+	client := server.Client(t)
+	ctx := context.Background()
+
+	if _, err := client.CreateWebsite(ctx, newSite("blog")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "index.html"), []byte("<h1>uploaded</h1>"), 0o644)
+	os.WriteFile(filepath.Join(dir, ".env"), []byte("SECRET=1"), 0o644)
+
+	site, err := client.UploadContent(ctx, "blog", dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(site.Files) != 1 || site.Files[0] != "index.html" {
+		t.Fatalf("expected only index.html to be uploaded, got %v", site.Files)
+	}
+
+	preview, err := client.PreviewWebsite(ctx, "blog")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err := http.Get(preview.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var body bytes.Buffer
+	body.ReadFrom(resp.Body)
+	if body.String() != "<h1>uploaded</h1>" {
+		t.Fatalf("unexpected preview content %q", body.String())
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	source := httpapitest.NewServer(t)
+	target := httpapitest.NewServer(t)
+	ctx := context.Background()
+
+	for _, name := range []string{"blog", "shop"} {
+		if _, err := source.Client(t).CreateWebsite(ctx, newSite(name)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	var bundle bytes.Buffer
+	if err := source.Client(t).ExportWebsites(ctx, &bundle, httpapiclient.BundleYAML); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(bundle.String(), "resourceVersion") {
+		t.Fatalf("expected server fields to be stripped:\n%s", bundle.String())
+	}
+
+	result, err := target.Client(t).ImportWebsites(ctx, "application/yaml", bytes.NewReader(bundle.Bytes()), httpapiclient.DryRun())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.DryRun || len(result.Items) != 2 || result.Items[0].Action != httpapiclient.ImportCreated {
+		t.Fatalf("unexpected dry-run result: %+v", result)
+	}
+	if sites, _ := target.Client(t).ListWebsites(ctx); len(sites) != 0 {
+		t.Fatalf("dry-run import must not create websites")
+	}
+
+	if _, err := target.Client(t).ImportWebsites(ctx, "application/yaml", bytes.NewReader(bundle.Bytes())); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result, err = target.Client(t).ImportWebsites(ctx, "application/yaml", bytes.NewReader(bundle.Bytes()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, item := range result.Items {
+		if item.Action != httpapiclient.ImportUnchanged {
+			t.Errorf("expected %s to be unchanged on the second import, got %s", item.Name, item.Action)
+		}
+	}
+}
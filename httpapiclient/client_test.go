@@ -0,0 +1,184 @@
+package httpapiclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if attempts.Add(1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Method == http.MethodPost && string(body) == "" {
+			t.Errorf("expected body to be sent again on retries")
+		}
+		w.Write([]byte(`{"name":"blog"}`))
+	}))
+	defer srv.Close()
+
+	var hookCalls int
+	client, _ := NewClient(srv.URL, nil,
+		WithRetry(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}),
+		WithResponseHook(func(*http.Request, *http.Response, error, time.Duration) { hookCalls++ }))
+
+	site, err := client.CreateWebsite(context.Background(), WebsiteCreateDTO{Name: "blog"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if site.Name != "blog" || attempts.Load() != 3 || hookCalls != 3 {
+		t.Fatalf("expected success after 3 attempts, got %d attempts and %d hook calls", attempts.Load(), hookCalls)
+	}
+}
+
+func TestRetryStops(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		status   int
+		header   string
+		attempts int32
+	}{
+		{"NonIdempotentBadGateway", http.MethodPost, http.StatusBadGateway, "", 1},
+		{"IdempotentBadGateway", http.MethodGet, http.StatusBadGateway, "", 3},
+		{"ClientError", http.MethodGet, http.StatusBadRequest, "", 1},
+		{"RetryAfterTooLong", http.MethodGet, http.StatusTooManyRequests, "3600", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts.Add(1)
+				if tt.header != "" {
+					w.Header().Set("Retry-After", tt.header)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(`{"error":"nope"}`))
+			}))
+			defer srv.Close()
+
+			client, _ := NewClient(srv.URL, nil, WithRetry(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}))
+			err := client.doRequest(context.Background(), tt.method, "/api/websites", nil, nil)
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.status || apiErr.Message != "nope" {
+				t.Fatalf("expected APIError with status %d, got %v", tt.status, err)
+			}
+			if attempts.Load() != tt.attempts {
+				t.Fatalf("expected %d attempts, got %d", tt.attempts, attempts.Load())
+			}
+		})
+	}
+}
+
+func TestClientHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer s3cr3t" || r.Header.Get("X-Tenant") != "a" || r.UserAgent() != "websitectl/1.0" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+
+	client, _ := NewClient(srv.URL, nil, WithBearerToken("s3cr3t"), WithHeader("X-Tenant", "a"), WithUserAgent("websitectl/1.0"))
+	if _, err := client.ListWebsites(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	if d, ok := retryAfter("120", now); !ok || d != 2*time.Minute {
+		t.Errorf("unexpected delay for seconds: %v", d)
+	}
+	if d, ok := retryAfter(now.Add(time.Minute).Format(http.TimeFormat), now); !ok || d != time.Minute {
+		t.Errorf("unexpected delay for date: %v", d)
+	}
+	if _, ok := retryAfter("soon", now); ok {
+		t.Errorf("expected invalid value to be ignored")
+	}
+}
+
+func TestWatchStartsOverWhenGone(t *testing.T) {
+	var connections atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		switch connections.Add(1) {
+		case 1:
+			io.WriteString(w, "id: 5\ndata: {\"type\":\"ADDED\",\"website\":{\"name\":\"blog\"}}\n\n")
+			io.WriteString(w, "data: {\"type\":\"ERROR\",\"error\":\"too old resource version\",\"code\":410}\n\n")
+		default:
+			if id := r.Header.Get("Last-Event-ID"); id != "" {
+				t.Errorf("expected the watch to start over, got Last-Event-ID %s", id)
+			}
+			io.WriteString(w, "id: 9\ndata: {\"type\":\"ADDED\",\"website\":{\"name\":\"blog\"}}\n\n")
+			io.WriteString(w, "data: {\"type\":\"ERROR\",\"error\":\"forbidden\",\"code\":403}\n\n")
+		}
+	}))
+	defer srv.Close()
+
+	client, _ := NewClient(srv.URL, srv.Client())
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	events, err := client.WatchWebsites(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var types []string
+	for event := range events {
+		types = append(types, event.Type)
+	}
+	if len(types) != 3 || types[0] != "ADDED" || types[1] != "ADDED" || types[2] != "ERROR" {
+		t.Errorf("expected the website to be added again before the error, got %v", types)
+	}
+}
+
+func TestWatchStopsOnClientError(t *testing.T) {
+	var connections atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if connections.Add(1) > 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"token expired"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "id: 5\ndata: {\"type\":\"ADDED\",\"website\":{\"name\":\"blog\"}}\n\n")
+	}))
+	defer srv.Close()
+
+	client, _ := NewClient(srv.URL, srv.Client())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	events, err := client.WatchWebsites(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var last WebsiteEventDTO
+	for event := range events {
+		last = event
+	}
+	if ctx.Err() != nil {
+		t.Fatal("expected the watch to stop instead of reconnecting")
+	}
+	if last.Type != "ERROR" || last.Code != http.StatusUnauthorized {
+		t.Errorf("expected the client error as last event, got %+v", last)
+	}
+	if n := connections.Load(); n != 2 {
+		t.Errorf("expected 2 connections, got %d", n)
+	}
+}
@@ -0,0 +1,75 @@
+package httpapiclient
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+)
+
+// UploadContent replaces the files of a website with the contents of dir. Hidden files and
+// directories are skipped, all other files must be of a type the API accepts.
+func (c *Client) UploadContent(ctx context.Context, name, dir string) (*WebsiteDTO, error) {
+	var buf bytes.Buffer
+	if err := writeTar(&buf, os.DirFS(dir)); err != nil {
+		return nil, fmt.Errorf("failed to archive '%s': %w", dir, err)
+	}
+
+	return c.UploadContentArchive(ctx, name, "application/x-tar", &buf)
+}
+
+// UploadContentArchive replaces the files of a website with the contents of a zip
+// ("application/zip"), tar ("application/x-tar") or gzipped tar ("application/gzip") archive.
+func (c *Client) UploadContentArchive(ctx context.Context, name, contentType string, archive io.Reader) (*WebsiteDTO, error) {
+	var result WebsiteDTO
+	endpoint := path.Join("/api/websites", name, "content")
+	if err := c.doRawRequest(ctx, http.MethodPut, endpoint, contentType, archive, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func writeTar(w io.Writer, fsys fs.FS) error {
+	tw := tar.NewWriter(w)
+
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != "." && path.Base(p)[0] == '.' {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		b, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+
+		if err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     p,
+			Mode:     0o644,
+			Size:     int64(len(b)),
+		}); err != nil {
+			return err
+		}
+		_, err = tw.Write(b)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return tw.Close()
+}
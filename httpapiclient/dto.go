@@ -13,33 +13,222 @@ type WebsiteListDTO []*WebsiteDTO
 type WebsiteBase struct {
 	HtmlContent string `json:"htmlContent"`
 	Hostname    string `json:"hostname"`
-	NginxImage  string `json:"nginxImage"`
+	NginxImage  string `json:"nginxImage" binding:"required"`
+
+	// RevisionHistoryLimit is the number of revisions kept, an update keeps it if omitted.
+	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
+
+	// Server configures nginx, the default configuration of the image is used if empty. An
+	// update keeps the configuration if it's omitted, an empty one restores the default.
+	Server *ServerDTO `json:"server,omitempty"`
+
+	// Access restricts the access to the website, it's public if empty. An update keeps the
+	// access if it's omitted, an empty one makes the website public.
+	Access *AccessDTO `json:"access,omitempty"`
+}
+
+// AccessDTO restricts the access to a website by basic auth or client networks.
+type AccessDTO struct {
+	BasicAuth *BasicAuthDTO `json:"basicAuth,omitempty"`
+	// AllowedCIDRs are the networks clients are allowed from, e.g. 10.0.0.0/8.
+	AllowedCIDRs []string `json:"allowedCIDRs,omitempty"`
+}
+
+// BasicAuthDTO references the Secret holding the passwords of the users keyed by their name.
+type BasicAuthDTO struct {
+	SecretName string `json:"secretName" binding:"required"`
+	Realm      string `json:"realm,omitempty"`
+}
+
+// ServerDTO configures the nginx server of a website.
+type ServerDTO struct {
+	// SPA serves index.html for paths without a file.
+	SPA  bool `json:"spa,omitempty"`
+	Gzip bool `json:"gzip,omitempty"`
+	// Headers are added to all responses.
+	Headers map[string]string `json:"headers,omitempty"`
+	// Caching sets the Cache-Control header, the first matching rule applies.
+	Caching    []CachingRuleDTO `json:"caching,omitempty"`
+	ErrorPages []ErrorPageDTO   `json:"errorPages,omitempty"`
+	Redirects  []RedirectDTO    `json:"redirects,omitempty"`
+}
+
+// CachingRuleDTO sets the Cache-Control header for the files below Path with one of Extensions.
+type CachingRuleDTO struct {
+	Path          string   `json:"path,omitempty"`
+	Extensions    []string `json:"extensions,omitempty"`
+	MaxAgeSeconds int32    `json:"maxAgeSeconds,omitempty"`
+	Immutable     bool     `json:"immutable,omitempty"`
+	NoStore       bool     `json:"noStore,omitempty"`
+}
+
+// ErrorPageDTO serves the content file Path for the status codes Codes.
+type ErrorPageDTO struct {
+	Codes []int32 `json:"codes" binding:"required"`
+	Path  string  `json:"path" binding:"required"`
+}
+
+// RedirectDTO redirects requests for the exact path From to the path or URL To.
+type RedirectDTO struct {
+	From      string `json:"from" binding:"required"`
+	To        string `json:"to" binding:"required"`
+	Permanent bool   `json:"permanent,omitempty"`
 }
 
 // WebsiteDTO is the full website model returned by the API.
 type WebsiteDTO struct {
 	WebsiteBase
 
+	// Suspend stops the controller from reconciling the website.
+	Suspend bool `json:"suspend,omitempty"`
+
 	Name              string            `json:"name"`
+	Namespace         string            `json:"namespace"`
 	Labels            map[string]string `json:"labels"`
 	Generation        int64             `json:"generation"`
+	ResourceVersion   string            `json:"resourceVersion"`
+	Files             []string          `json:"files,omitempty"`
 	CreationTimestamp time.Time         `json:"creationTimestamp"`
+
+	// Paused is true while the website isn't reconciled, because it's suspended or was paused.
+	Paused bool `json:"paused"`
+	// ImageDigest is the digest of the nginx image the website runs, if known to the controller.
+	ImageDigest string `json:"imageDigest,omitempty"`
+	// Maintenance is set via the maintenance endpoint rather than by updates of the website.
+	Maintenance *MaintenanceDTO `json:"maintenance,omitempty"`
+
+	// DryRun is true if the website is the result of a dry-run request and wasn't persisted.
+	DryRun bool `json:"dryRun,omitempty"`
+	// Diff lists the changes a dry-run request would make to the spec.
+	Diff []ChangeDTO `json:"diff,omitempty"`
+}
+
+// ChangeDTO is a field of a website spec changed by a request. Large values like HTML
+// content are summarized by their size and hash.
+type ChangeDTO struct {
+	Field string `json:"field"`
+	Old   any    `json:"old,omitempty"`
+	New   any    `json:"new,omitempty"`
+}
+
+// WebsiteEventDTO is a change to a website streamed by the watch endpoints.
+type WebsiteEventDTO struct {
+	// Type is one of ADDED, MODIFIED, DELETED or ERROR.
+	Type string `json:"type"`
+	// Website is the state of the website after the change, nil for ERROR events.
+	Website *WebsiteDTO `json:"website,omitempty"`
+	// Error describes why the watch ended, only set for ERROR events.
+	Error string `json:"error,omitempty"`
+	// Code is the HTTP status code of the error, e.g. 410 if the watch can't be resumed.
+	Code int `json:"code,omitempty"`
+}
+
+// RevisionListDTO represents the revisions of a website, newest first.
+type RevisionListDTO []*RevisionDTO
+
+// RevisionDTO is a recorded state of a website's content.
+type RevisionDTO struct {
+	Revision          int64      `json:"revision"`
+	SpecHash          string     `json:"specHash"`
+	CreationTimestamp time.Time  `json:"creationTimestamp"`
+	HtmlContent       string     `json:"htmlContent"`
+	Hostname          string     `json:"hostname"`
+	NginxImage        string     `json:"nginxImage"`
+	Files             []string   `json:"files,omitempty"`
+	Server            *ServerDTO `json:"server,omitempty"`
+}
+
+// MaintenanceDTO swaps a website to a maintenance page answering all requests with status 503,
+// while its content is kept.
+type MaintenanceDTO struct {
+	Enabled bool `json:"enabled"`
+	// HTML of the maintenance page, a default page is served if empty.
+	HTML string `json:"html,omitempty"`
+	// RetryAfterSeconds is sent as Retry-After header unless zero.
+	RetryAfterSeconds int32 `json:"retryAfterSeconds,omitempty"`
+}
+
+func (m *MaintenanceDTO) Validate() error {
+	if m.RetryAfterSeconds < 0 {
+		return fmt.Errorf("retry after seconds must not be negative")
+	}
+	return nil
+}
+
+// RollbackDTO is used to restore a website to a revision.
+type RollbackDTO struct {
+	Revision int64 `json:"revision" binding:"required"`
+}
+
+// PreviewCreateDTO is the content rendered by a preview.
+type PreviewCreateDTO struct {
+	HtmlContent string            `json:"htmlContent"`
+	Files       map[string][]byte `json:"files,omitempty"`
+}
+
+// PreviewDTO describes a preview, which is served below URL until ExpiresAt.
+type PreviewDTO struct {
+	Token     string    `json:"token"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Actions reported for the websites of an import.
+const (
+	ImportCreated   = "created"
+	ImportUpdated   = "updated"
+	ImportUnchanged = "unchanged"
+	ImportFailed    = "failed"
+)
+
+// ImportResultDTO reports the outcome of an import per website.
+type ImportResultDTO struct {
+	// DryRun is true if nothing was persisted.
+	DryRun bool                  `json:"dryRun"`
+	Items  []ImportItemResultDTO `json:"items"`
+}
+
+// ImportItemResultDTO is the outcome of importing a single website.
+type ImportItemResultDTO struct {
+	Name string `json:"name"`
+	// Action is one of created, updated, unchanged or failed.
+	Action string `json:"action"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Failed returns the websites which couldn't be imported.
+func (r *ImportResultDTO) Failed() []ImportItemResultDTO {
+	var failed []ImportItemResultDTO
+	for _, item := range r.Items {
+		if item.Action == ImportFailed {
+			failed = append(failed, item)
+		}
+	}
+	return failed
 }
 
 // WebsiteCreateDTO is used to create a new website.
 type WebsiteCreateDTO struct {
 	WebsiteBase
-	Name string `json:"name"`
+	Name string `json:"name" binding:"required"`
+
+	// Suspend stops the controller from reconciling the website.
+	Suspend bool `json:"suspend,omitempty"`
 }
 
 // WebsiteUpdateDTO is used to update an existing website.
 type WebsiteUpdateDTO struct {
 	WebsiteBase
+
+	// Suspend stops the controller from reconciling the website, it's kept if omitted.
+	Suspend *bool `json:"suspend,omitempty"`
 }
 
+// Validate checks the fields of a website. The nginx image is checked against the image policy
+// of the server when the website is created or updated.
 func (w *WebsiteBase) Validate() error {
-	if !strings.HasPrefix(w.NginxImage, "docker.io/nginx:") {
-		return fmt.Errorf("nginx image '%s' is invalid. must start with 'docker.io/nginx:'", w.NginxImage)
+	if strings.TrimSpace(w.NginxImage) == "" {
+		return fmt.Errorf("nginx image is required")
 	}
 	return nil
 }
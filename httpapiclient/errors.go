@@ -0,0 +1,33 @@
+package httpapiclient
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// maxErrorBodySize limits how much of an error response is read.
+const maxErrorBodySize = 1 << 20
+
+// APIError is returned for responses with an unsuccessful status code.
+type APIError struct {
+	StatusCode int
+	// Message is the error reported by the API, or the raw body for non-JSON responses.
+	Message string
+	Body    []byte
+}
+
+func newAPIError(statusCode int, body []byte) *APIError {
+	e := &APIError{StatusCode: statusCode, Message: string(body), Body: body}
+
+	var apiErr struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(body, &apiErr) == nil && apiErr.Error != "" {
+		e.Message = apiErr.Error
+	}
+	return e
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("unexpected status %d: %s", e.StatusCode, e.Message)
+}
@@ -0,0 +1,53 @@
+// Package httpapitest serves the HTTP API from memory, for end to end tests of API clients
+// without a cluster.
+package httpapitest
+
+import (
+	"net/http/httptest"
+	"testing"
+	webv1 "website-operator/api/v1"
+	"website-operator/clientset/v1/fake"
+	"website-operator/httpapiclient"
+	"website-operator/internal/httpapi"
+	"website-operator/internal/imagepolicy"
+
+	"github.com/gin-gonic/gin"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+// Server is the real API router over an httptest.Server, backed by in-memory clientsets.
+type Server struct {
+	*httptest.Server
+
+	// Websites holds the websites of the server, e.g. to check the results of API calls or
+	// to inject errors.
+	Websites *fake.Clientset
+	// Kubernetes holds the ConfigMaps revisions are read from.
+	Kubernetes *k8sfake.Clientset
+}
+
+// NewServer starts a server containing sites, which is closed when the test ends. Websites are
+// checked against the default image policy.
+func NewServer(tb testing.TB, sites ...*webv1.WebSite) *Server {
+	tb.Helper()
+	gin.SetMode(gin.TestMode)
+
+	s := &Server{
+		Websites:   fake.NewClientset(sites...),
+		Kubernetes: k8sfake.NewClientset(),
+	}
+	s.Server = httptest.NewServer(httpapi.NewRouter(httpapi.NewWebsiteHandler(s.Websites, s.Kubernetes.CoreV1(), imagepolicy.Default())))
+	tb.Cleanup(s.Close)
+	return s
+}
+
+// Client returns an API client for the server.
+func (s *Server) Client(tb testing.TB, opts ...httpapiclient.ClientOption) *httpapiclient.Client {
+	tb.Helper()
+
+	client, err := httpapiclient.NewClient(s.URL, s.Server.Client(), opts...)
+	if err != nil {
+		tb.Fatalf("couldn't create API client: %v", err)
+	}
+	return client
+}
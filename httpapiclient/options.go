@@ -0,0 +1,112 @@
+package httpapiclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const defaultUserAgent = "website-operator-httpapiclient"
+
+// ClientOption configures a Client.
+type ClientOption func(o *clientOptions)
+
+type clientOptions struct {
+	namespace     string
+	userAgent     string
+	headers       http.Header
+	tokenSource   TokenSource
+	retry         RetryPolicy
+	requestHooks  []RequestHook
+	responseHooks []ResponseHook
+}
+
+// TokenSource returns the bearer token for a request. It is called for every request, so it
+// can refresh expiring tokens.
+type TokenSource func(ctx context.Context) (string, error)
+
+// RequestHook is called before every attempt of a request, e.g. to inject tracing headers.
+type RequestHook func(req *http.Request)
+
+// ResponseHook is called after every attempt of a request with its response or error.
+type ResponseHook func(req *http.Request, resp *http.Response, err error, duration time.Duration)
+
+// WithBearerToken authenticates all requests with a static token.
+func WithBearerToken(token string) ClientOption {
+	return WithTokenSource(func(context.Context) (string, error) {
+		return token, nil
+	})
+}
+
+// WithTokenSource authenticates all requests with the bearer tokens of source.
+func WithTokenSource(source TokenSource) ClientOption {
+	return func(o *clientOptions) {
+		o.tokenSource = source
+	}
+}
+
+// WithNamespace selects the namespace of the websites managed by the client. The API uses
+// the "default" namespace if none is given.
+func WithNamespace(namespace string) ClientOption {
+	return func(o *clientOptions) {
+		o.namespace = namespace
+	}
+}
+
+// WithHeader adds a header to all requests.
+func WithHeader(key, value string) ClientOption {
+	return func(o *clientOptions) {
+		o.headers.Add(key, value)
+	}
+}
+
+// WithUserAgent sets the User-Agent header of all requests.
+func WithUserAgent(userAgent string) ClientOption {
+	return func(o *clientOptions) {
+		o.userAgent = userAgent
+	}
+}
+
+// WithRetry retries failed requests according to policy, see DefaultRetryPolicy.
+func WithRetry(policy RetryPolicy) ClientOption {
+	return func(o *clientOptions) {
+		o.retry = policy
+	}
+}
+
+// WithRequestHook adds a hook called before every attempt of a request.
+func WithRequestHook(hook RequestHook) ClientOption {
+	return func(o *clientOptions) {
+		o.requestHooks = append(o.requestHooks, hook)
+	}
+}
+
+// WithResponseHook adds a hook called after every attempt of a request.
+func WithResponseHook(hook ResponseHook) ClientOption {
+	return func(o *clientOptions) {
+		o.responseHooks = append(o.responseHooks, hook)
+	}
+}
+
+// RequestOption modifies a single API call.
+type RequestOption func(query url.Values)
+
+// DryRun validates a change on the server, including admission webhooks, without persisting it.
+func DryRun() RequestOption {
+	return func(query url.Values) {
+		query.Set("dryRun", "true")
+	}
+}
+
+func withRequestOptions(endpoint string, opts []RequestOption) string {
+	if len(opts) == 0 {
+		return endpoint
+	}
+
+	query := url.Values{}
+	for _, opt := range opts {
+		opt(query)
+	}
+	return endpoint + "?" + query.Encode()
+}
@@ -0,0 +1,60 @@
+package httpapiclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+)
+
+// CreatePreview renders content under a temporary URL without publishing it.
+func (c *Client) CreatePreview(ctx context.Context, dto PreviewCreateDTO) (*PreviewDTO, error) {
+	var result PreviewDTO
+	if err := c.doRequest(ctx, http.MethodPost, "/api/websites/preview", dto, &result); err != nil {
+		return nil, err
+	}
+	return c.absolutePreview(&result), nil
+}
+
+// CreatePreviewFromDir renders the contents of dir under a temporary URL, skipping hidden
+// files like UploadContent.
+func (c *Client) CreatePreviewFromDir(ctx context.Context, dir string) (*PreviewDTO, error) {
+	var buf bytes.Buffer
+	if err := writeTar(&buf, os.DirFS(dir)); err != nil {
+		return nil, fmt.Errorf("failed to archive '%s': %w", dir, err)
+	}
+
+	return c.CreatePreviewFromArchive(ctx, "application/x-tar", &buf)
+}
+
+// CreatePreviewFromArchive renders the contents of an archive under a temporary URL. The
+// supported content types are the same as for UploadContentArchive.
+func (c *Client) CreatePreviewFromArchive(ctx context.Context, contentType string, archive io.Reader) (*PreviewDTO, error) {
+	var result PreviewDTO
+	if err := c.doRawRequest(ctx, http.MethodPost, "/api/websites/preview", contentType, archive, &result); err != nil {
+		return nil, err
+	}
+	return c.absolutePreview(&result), nil
+}
+
+// PreviewWebsite renders the current content of a website under a temporary URL.
+func (c *Client) PreviewWebsite(ctx context.Context, name string) (*PreviewDTO, error) {
+	var result PreviewDTO
+	endpoint := path.Join("/api/websites", name, "preview")
+	if err := c.doRequest(ctx, http.MethodGet, endpoint, nil, &result); err != nil {
+		return nil, err
+	}
+	return c.absolutePreview(&result), nil
+}
+
+// absolutePreview resolves the preview URL, which the API returns relative to its root.
+func (c *Client) absolutePreview(p *PreviewDTO) *PreviewDTO {
+	u := *c.baseURL
+	u.Path = path.Join(c.baseURL.Path, p.URL) + "/"
+	u.RawQuery = ""
+	p.URL = u.String()
+	return p
+}
@@ -0,0 +1,91 @@
+package httpapiclient
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures how failed requests are retried.
+//
+// Requests with idempotent methods are retried on network errors and 502, 503 and 504
+// responses. Requests of all methods are retried on 429 and 503 responses, which the server
+// sends without processing the request. Delays grow exponentially with full jitter, unless
+// the server sends a Retry-After header.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts including the first one, 1 disables retries.
+	MaxAttempts int
+	// InitialBackoff is the upper bound of the delay before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts. Responses asking to retry later than that
+	// with Retry-After are returned instead of retried.
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy makes up to 4 attempts within a few seconds.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    4,
+	InitialBackoff: 250 * time.Millisecond,
+	MaxBackoff:     5 * time.Second,
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func (p RetryPolicy) shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if err != nil {
+		// cancelled requests must not be retried
+		if req.Context().Err() != nil {
+			return false
+		}
+		// the request may have reached the server, so only retry if repeating it is safe
+		return isIdempotent(req.Method)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return isIdempotent(req.Method)
+	}
+	return false
+}
+
+// backoff returns the delay before the next attempt, or false if the server asked to wait
+// longer than MaxBackoff.
+func (p RetryPolicy) backoff(attempt int, resp *http.Response) (time.Duration, bool) {
+	if resp != nil {
+		if wait, ok := retryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			return wait, wait <= p.MaxBackoff
+		}
+	}
+
+	backoff := p.InitialBackoff << (attempt - 1)
+	if backoff <= 0 || backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+	if backoff <= 0 {
+		return 0, true
+	}
+	return rand.N(backoff + 1), true
+}
+
+// retryAfter parses a Retry-After header given in seconds or as HTTP date.
+func retryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
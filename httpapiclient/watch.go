@@ -0,0 +1,187 @@
+package httpapiclient
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// Dropped streams are reconnected after watchReconnectDelay, doubled up to watchMaxReconnectDelay
+// while the server can't be reached.
+const (
+	watchReconnectDelay    = 2 * time.Second
+	watchMaxReconnectDelay = time.Minute
+)
+
+// WatchWebsites streams changes of all websites. The returned channel is closed when ctx is
+// cancelled or after an ERROR event, e.g. when the server rejects a reconnect with a client
+// error like 401 or 403. Dropped connections are resumed transparently from the last received
+// event. If that event is too old to resume from, the watch starts over from the current state
+// with an ADDED event for every website.
+func (c *Client) WatchWebsites(ctx context.Context) (<-chan WebsiteEventDTO, error) {
+	return c.watch(ctx, "/api/websites/watch")
+}
+
+// WatchWebsite streams changes of a single website, see WatchWebsites.
+func (c *Client) WatchWebsite(ctx context.Context, name string) (<-chan WebsiteEventDTO, error) {
+	return c.watch(ctx, path.Join("/api/websites", name, "watch"))
+}
+
+func (c *Client) watch(ctx context.Context, endpoint string) (<-chan WebsiteEventDTO, error) {
+	// open the first connection synchronously to report errors like 404 or 401 directly
+	resp, err := c.openStream(ctx, endpoint, "")
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan WebsiteEventDTO)
+	go func() {
+		defer close(events)
+
+		lastEventID := ""
+		for {
+			var failure *WebsiteEventDTO
+			lastEventID, failure = readEventStream(ctx, resp, lastEventID, events)
+			if ctx.Err() != nil {
+				return
+			}
+
+			delay := watchReconnectDelay
+			if failure != nil {
+				if failure.Code != http.StatusGone || lastEventID == "" {
+					sendEvent(ctx, events, *failure)
+					return
+				}
+				// the resumed resourceVersion is too old, start over right away
+				lastEventID, delay = "", 0
+			}
+
+			resp, lastEventID, err = c.reconnect(ctx, endpoint, lastEventID, delay)
+			if err != nil {
+				var apiErr *APIError
+				if errors.As(err, &apiErr) {
+					sendEvent(ctx, events, WebsiteEventDTO{Type: "ERROR", Error: err.Error(), Code: apiErr.StatusCode})
+				}
+				return
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// reconnect opens a stream resuming from lastEventID after delay, and returns it with the event
+// ID it resumes from. Network and server errors are retried with growing delays. Client errors
+// are returned, except 410 Gone, which starts the stream over from the current state.
+func (c *Client) reconnect(ctx context.Context, endpoint, lastEventID string, delay time.Duration) (*http.Response, string, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, "", ctx.Err()
+		case <-time.After(delay):
+		}
+
+		resp, err := c.openStream(ctx, endpoint, lastEventID)
+		var apiErr *APIError
+		switch {
+		case err == nil:
+			return resp, lastEventID, nil
+		case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusGone && lastEventID != "":
+			lastEventID, delay = "", 0
+		case errors.As(err, &apiErr) && apiErr.StatusCode < 500 && apiErr.StatusCode != http.StatusTooManyRequests:
+			return nil, "", err
+		default:
+			delay = min(max(2*delay, watchReconnectDelay), watchMaxReconnectDelay)
+		}
+	}
+}
+
+// sendEvent sends event unless ctx is cancelled.
+func sendEvent(ctx context.Context, events chan<- WebsiteEventDTO, event WebsiteEventDTO) {
+	select {
+	case events <- event:
+	case <-ctx.Done():
+	}
+}
+
+func (c *Client) openStream(ctx context.Context, endpoint, lastEventID string) (*http.Response, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, endpoint, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	// streams are long-lived, so the client wide timeout must not apply
+	streamClient := *c.httpClient
+	streamClient.Timeout = 0
+
+	resp, err := c.send(&streamClient, req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	if err := checkResponse(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp, nil
+}
+
+// readEventStream forwards the events of an SSE response until it ends. It returns the ID of
+// the last event and the ERROR event ending the stream, which isn't forwarded.
+func readEventStream(ctx context.Context, resp *http.Response, lastEventID string, events chan<- WebsiteEventDTO) (string, *WebsiteEventDTO) {
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	var id, data string
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case line == "":
+			// blank line dispatches the event
+			if data == "" {
+				continue
+			}
+
+			var event WebsiteEventDTO
+			if err := json.Unmarshal([]byte(data), &event); err != nil {
+				event = WebsiteEventDTO{Type: "ERROR", Error: fmt.Sprintf("failed to decode event: %s", err)}
+			}
+			data = ""
+			if id != "" {
+				lastEventID = id
+			}
+
+			if event.Type == "ERROR" {
+				return lastEventID, &event
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return lastEventID, nil
+			}
+		case strings.HasPrefix(line, ":"):
+			// comment, e.g. heartbeat
+		case strings.HasPrefix(line, "id:"):
+			id = strings.TrimSpace(strings.TrimPrefix(line, "id:"))
+		case strings.HasPrefix(line, "data:"):
+			if data != "" {
+				data += "\n"
+			}
+			data += strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " ")
+		}
+	}
+
+	return lastEventID, nil
+}
@@ -0,0 +1,145 @@
+// Package access validates the access restrictions of websites and renders the users allowed to
+// log in into an htpasswd file. Restrictions are enforced either by the ingress controller or by
+// the nginx of a website, so the file uses a hash scheme both understand.
+package access
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"maps"
+	"net/netip"
+	"regexp"
+	"slices"
+	"strings"
+	webv1 "website-operator/api/v1"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// UserFileKey is the key of the htpasswd file in the Secret generated for a website, the one
+// ingress-nginx expects for auth-file secrets.
+const UserFileKey = "auth"
+
+const (
+	sshaPrefix = "{SSHA}"
+	saltSize   = 8
+)
+
+// realmPattern excludes quotes and escapes, the realm ends up in the nginx configuration and in
+// an annotation of the ingress.
+var realmPattern = regexp.MustCompile(`^[A-Za-z0-9 ._,:/@()-]*$`)
+
+// Validate checks the access configuration at path, which is valid if nil.
+func Validate(config *webv1.AccessConfig, path *field.Path) field.ErrorList {
+	if config == nil {
+		return nil
+	}
+
+	var errs field.ErrorList
+	if config.BasicAuth != nil {
+		basicAuthPath := path.Child("basicAuth")
+		for _, msg := range validation.IsDNS1123Subdomain(config.BasicAuth.SecretName) {
+			errs = append(errs, field.Invalid(basicAuthPath.Child("secretName"), config.BasicAuth.SecretName, msg))
+		}
+		if !realmPattern.MatchString(config.BasicAuth.Realm) {
+			errs = append(errs, field.Invalid(basicAuthPath.Child("realm"), config.BasicAuth.Realm,
+				"must consist of letters, digits, spaces and . _ , : / @ ( ) -"))
+		}
+	}
+
+	for i, cidr := range config.AllowedCIDRs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			errs = append(errs, field.Invalid(path.Child("allowedCIDRs").Index(i), cidr, "must be a CIDR, e.g. 10.0.0.0/8"))
+		} else if prefix.Masked() != prefix {
+			errs = append(errs, field.Invalid(path.Child("allowedCIDRs").Index(i), cidr,
+				fmt.Sprintf("must be a network address, e.g. %s", prefix.Masked())))
+		}
+	}
+	return errs
+}
+
+// Realm returns the realm of the basic auth of a website.
+func Realm(config *webv1.AccessConfig, hostname string) string {
+	if config == nil || config.BasicAuth == nil || config.BasicAuth.Realm == "" {
+		return hostname
+	}
+	return config.BasicAuth.Realm
+}
+
+// Htpasswd renders the passwords of users, keyed by user name, into an htpasswd file. The
+// entries of current are kept as long as the passwords match, so the file only changes along
+// with the passwords rather than with every salt.
+//
+// Passwords are hashed with salted SHA-1, the strongest scheme nginx supports independent of
+// the crypt implementation of its platform.
+func Htpasswd(users map[string][]byte, current []byte) ([]byte, error) {
+	if len(users) == 0 {
+		return nil, errors.New("secret holds no users")
+	}
+
+	entries := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(current))
+	for scanner.Scan() {
+		if user, hash, ok := strings.Cut(scanner.Text(), ":"); ok {
+			entries[user] = hash
+		}
+	}
+
+	var b bytes.Buffer
+	for _, user := range slices.Sorted(maps.Keys(users)) {
+		password := users[user]
+		if strings.ContainsAny(user, ":\n") {
+			return nil, fmt.Errorf("user name '%s' must not contain colons", user)
+		}
+		if len(password) == 0 {
+			return nil, fmt.Errorf("password of user '%s' is empty", user)
+		}
+
+		hash := entries[user]
+		if !verify(hash, password) {
+			var err error
+			if hash, err = hashPassword(password); err != nil {
+				return nil, err
+			}
+		}
+		fmt.Fprintf(&b, "%s:%s\n", user, hash)
+	}
+	return b.Bytes(), nil
+}
+
+func hashPassword(password []byte) (string, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("couldn't generate salt: %w", err)
+	}
+	return sshaPrefix + base64.StdEncoding.EncodeToString(append(ssha(password, salt), salt...)), nil
+}
+
+// verify reports whether hash is the salted SHA-1 hash of password.
+func verify(hash string, password []byte) bool {
+	encoded, ok := strings.CutPrefix(hash, sshaPrefix)
+	if !ok {
+		return false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(decoded) <= sha1.Size {
+		return false
+	}
+	digest, salt := decoded[:sha1.Size], decoded[sha1.Size:]
+	return subtle.ConstantTimeCompare(digest, ssha(password, salt)) == 1
+}
+
+func ssha(password, salt []byte) []byte {
+	h := sha1.New()
+	h.Write(password)
+	h.Write(salt)
+	return h.Sum(nil)
+}
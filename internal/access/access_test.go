@@ -0,0 +1,89 @@
+package access
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	webv1 "website-operator/api/v1"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestHtpasswd(t *testing.T) {
+	users := map[string][]byte{"bob": []byte("secret"), "alice": []byte("wonderland")}
+	file, err := Htpasswd(users, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(file)), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "alice:{SSHA}") || !strings.HasPrefix(lines[1], "bob:{SSHA}") {
+		t.Fatalf("unexpected htpasswd file:\n%s", file)
+	}
+	_, hash, _ := strings.Cut(lines[1], ":")
+	if !verify(hash, []byte("secret")) || verify(hash, []byte("wrong")) {
+		t.Errorf("hash %s doesn't verify the password of bob", hash)
+	}
+
+	// unchanged passwords keep their hashes
+	again, err := Htpasswd(users, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again, file) {
+		t.Errorf("expected unchanged file, got\n%s", again)
+	}
+
+	users["bob"] = []byte("changed")
+	changed, err := Htpasswd(users, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(changed, []byte(lines[0]+"\n")) || bytes.Contains(changed, []byte(lines[1])) {
+		t.Errorf("expected only the entry of bob to change, got\n%s", changed)
+	}
+}
+
+func TestHtpasswdInvalid(t *testing.T) {
+	for name, users := range map[string]map[string][]byte{
+		"no users":       {},
+		"empty password": {"bob": nil},
+	} {
+		if _, err := Htpasswd(users, nil); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		config webv1.AccessConfig
+		// field is the path of the expected error, empty if valid
+		field string
+	}{
+		{name: "valid", config: webv1.AccessConfig{
+			BasicAuth:    &webv1.BasicAuth{SecretName: "users", Realm: "Intranet (staff only)"},
+			AllowedCIDRs: []string{"10.0.0.0/8", "2001:db8::/32"},
+		}},
+		{name: "secret name", config: webv1.AccessConfig{BasicAuth: &webv1.BasicAuth{SecretName: "Users"}}, field: "access.basicAuth.secretName"},
+		{name: "realm", config: webv1.AccessConfig{BasicAuth: &webv1.BasicAuth{SecretName: "users", Realm: `a"; deny all; "`}}, field: "access.basicAuth.realm"},
+		{name: "cidr", config: webv1.AccessConfig{AllowedCIDRs: []string{"10.0.0.0"}}, field: "access.allowedCIDRs[0]"},
+		{name: "host bits", config: webv1.AccessConfig{AllowedCIDRs: []string{"10.0.0.1/8"}}, field: "access.allowedCIDRs[0]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := Validate(&tt.config, field.NewPath("access"))
+			if tt.field == "" {
+				if len(errs) > 0 {
+					t.Errorf("unexpected errors: %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || errs[0].Field != tt.field {
+				t.Errorf("expected an error for %s, got %v", tt.field, errs)
+			}
+		})
+	}
+}
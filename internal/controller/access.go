@@ -0,0 +1,246 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	webv1 "website-operator/api/v1"
+	"website-operator/internal/access"
+	"website-operator/internal/nginxconf"
+
+	appsv1 "k8s.io/api/apps/v1"
+	netv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// ExposureMode is how websites are exposed, which decides who enforces their access restrictions.
+type ExposureMode string
+
+const (
+	// ExposureIngressNginx exposes websites via ingress-nginx, which enforces access restrictions
+	// configured by annotations of the ingress.
+	ExposureIngressNginx ExposureMode = "ingress-nginx"
+	// ExposureIngress exposes websites via another ingress controller, the nginx of each website
+	// enforces its access restrictions.
+	ExposureIngress ExposureMode = "ingress"
+)
+
+// Exposure configures how websites are exposed, the zero value exposes them via ingress-nginx.
+type Exposure struct {
+	Mode ExposureMode
+	// TrustedProxies are the networks of the ingress controller, whose X-Forwarded-For header the
+	// nginx of a website trusts for the client address in ExposureIngress mode.
+	TrustedProxies []string
+}
+
+// nginxEnforcesAccess reports whether the nginx of a website enforces its access restrictions.
+func (e Exposure) nginxEnforcesAccess() bool {
+	return e.Mode == ExposureIngress
+}
+
+// accessSecretIndex indexes the websites in the cache by the Secret holding their users.
+const accessSecretIndex = "spec.access.basicAuth.secretName"
+
+// ingress-nginx annotations enforcing the access restrictions of a website
+const (
+	annotationAuthType       = "nginx.ingress.kubernetes.io/auth-type"
+	annotationAuthSecret     = "nginx.ingress.kubernetes.io/auth-secret"
+	annotationAuthSecretType = "nginx.ingress.kubernetes.io/auth-secret-type"
+	annotationAuthRealm      = "nginx.ingress.kubernetes.io/auth-realm"
+	annotationSourceRange    = "nginx.ingress.kubernetes.io/whitelist-source-range"
+)
+
+var accessAnnotations = []string{annotationAuthType, annotationAuthSecret, annotationAuthSecretType, annotationAuthRealm, annotationSourceRange}
+
+// basicAuthSecretError is a Secret referenced for basic auth which is missing or holds no valid users.
+type basicAuthSecretError struct {
+	name string
+	err  error
+}
+
+func (e *basicAuthSecretError) Error() string {
+	return fmt.Sprintf("basic auth secret '%s' is invalid: %s", e.name, e.err)
+}
+
+func (e *basicAuthSecretError) Unwrap() error {
+	return e.err
+}
+
+func indexAccessSecret(obj client.Object) []string {
+	if config := obj.(*webv1.WebSite).Spec.Access; config != nil && config.BasicAuth != nil {
+		return []string{config.BasicAuth.SecretName}
+	}
+	return nil
+}
+
+func basicAuth(spec webv1.WebSiteSpec) bool {
+	return spec.Access != nil && spec.Access.BasicAuth != nil
+}
+
+// mountsHtpasswd reports whether the deployment of a website mounts its htpasswd Secret.
+func (r *WebsiteController) mountsHtpasswd(spec webv1.WebSiteSpec) bool {
+	return basicAuth(spec) && r.exposure.nginxEnforcesAccess()
+}
+
+// nginxAccess returns the access restrictions the nginx of website enforces, or nil if there are
+// none or they're enforced by the ingress controller.
+func (r *WebsiteController) nginxAccess(website *webv1.WebSite) *nginxconf.Access {
+	if website.Spec.Access == nil || !r.exposure.nginxEnforcesAccess() {
+		return nil
+	}
+
+	nginxAccess := &nginxconf.Access{AllowedCIDRs: website.Spec.Access.AllowedCIDRs}
+	if len(nginxAccess.AllowedCIDRs) > 0 {
+		nginxAccess.TrustedProxies = r.exposure.TrustedProxies
+	}
+	if basicAuth(website.Spec) {
+		nginxAccess.UserFile = htpasswdDir + "/" + access.UserFileKey
+		nginxAccess.Realm = access.Realm(website.Spec.Access, website.Spec.Hostname)
+	}
+	return nginxAccess
+}
+
+// ingressAccessAnnotations returns the annotations of the ingress of a website enforcing its
+// access restrictions by ingress-nginx.
+func ingressAccessAnnotations(name string, spec webv1.WebSiteSpec) map[string]string {
+	if spec.Access == nil {
+		return nil
+	}
+
+	annotations := map[string]string{}
+	if spec.Access.BasicAuth != nil {
+		annotations[annotationAuthType] = "basic"
+		annotations[annotationAuthSecret] = HtpasswdSecretObjectName(name)
+		annotations[annotationAuthSecretType] = "auth-file"
+		annotations[annotationAuthRealm] = access.Realm(spec.Access, spec.Hostname)
+	}
+	if len(spec.Access.AllowedCIDRs) > 0 {
+		annotations[annotationSourceRange] = strings.Join(spec.Access.AllowedCIDRs, ",")
+	}
+	return annotations
+}
+
+// ensureAccess validates the access restrictions of website and renders the users of its basic
+// auth Secret into its htpasswd Secret, which is read by the ingress controller or mounted for nginx.
+func (r *WebsiteController) ensureAccess(ctx context.Context, req ctrl.Request, website *webv1.WebSite) error {
+	if errs := access.Validate(website.Spec.Access, field.NewPath("spec", "access")); len(errs) > 0 {
+		return invalidSpec(website, errs)
+	}
+	if !basicAuth(website.Spec) {
+		return nil
+	}
+
+	secretClient := r.kubeClient.CoreV1().Secrets(req.Namespace)
+	usersName := website.Spec.Access.BasicAuth.SecretName
+	users, err := secretClient.Get(ctx, usersName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		// the website is reconciled again once the secret is created
+		return reconcile.TerminalError(&basicAuthSecretError{name: usersName, err: err})
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't get basic auth secret: %w", err)
+	}
+
+	siteName := r.siteName(req)
+	secretName := HtpasswdSecretObjectName(siteName)
+	secret, err := secretClient.Get(ctx, secretName, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("couldn't get htpasswd secret: %w", err)
+	}
+	exists := err == nil
+
+	var current []byte
+	if exists {
+		current = secret.Data[access.UserFileKey]
+	}
+	htpasswd, err := access.Htpasswd(users.Data, current)
+	if err != nil {
+		return reconcile.TerminalError(&basicAuthSecretError{name: usersName, err: err})
+	}
+
+	if !exists {
+		if _, err := secretClient.Create(ctx, CreateHtpasswdSecretObject(siteName, htpasswd), metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("couldn't create htpasswd secret: %w", err)
+		}
+		r.childChanged(website, kindSecret, operationCreate, secretName)
+		log.FromContext(ctx).Info("new htpasswd secret created for website", "secretName", secretName)
+		return nil
+	}
+
+	if len(secret.Data) != 1 || !bytes.Equal(current, htpasswd) {
+		secret.Data = map[string][]byte{access.UserFileKey: htpasswd}
+		if _, err := secretClient.Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("couldn't update htpasswd secret: %w", err)
+		}
+		r.childChanged(website, kindSecret, operationUpdate, secretName)
+		log.FromContext(ctx).Info("htpasswd updated via secret")
+	}
+	return nil
+}
+
+// removeHtpasswd deletes the htpasswd Secret of a website without basic auth. It's called once
+// the ingress doesn't refer to it anymore. Pods of the previous ReplicaSet may mount it until
+// they're replaced, so it's only deleted once the deployment is rolled out.
+func (r *WebsiteController) removeHtpasswd(ctx context.Context, req ctrl.Request, website *webv1.WebSite, deployment *appsv1.Deployment) error {
+	if basicAuth(website.Spec) || !deploymentRolledOut(deployment) {
+		return nil
+	}
+
+	secretName := HtpasswdSecretObjectName(r.siteName(req))
+	err := r.kubeClient.CoreV1().Secrets(req.Namespace).Delete(ctx, secretName, metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't delete htpasswd secret: %w", err)
+	}
+	r.childChanged(website, kindSecret, operationDelete, secretName)
+	return nil
+}
+
+// sitesReferencingSecret maps a changed Secret to the websites whose users it holds, so their
+// htpasswd Secrets follow password changes.
+func (r *WebsiteController) sitesReferencingSecret(ctx context.Context, obj client.Object) []reconcile.Request {
+	var sites webv1.WebSiteList
+	err := r.Client.List(ctx, &sites, client.InNamespace(obj.GetNamespace()), client.MatchingFields{accessSecretIndex: obj.GetName()})
+	if err != nil {
+		log.FromContext(ctx).Error(err, "couldn't list websites by basic auth secret", "secret", obj.GetName())
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(sites.Items))
+	for _, site := range sites.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&site)})
+	}
+	return slices.Clip(requests)
+}
+
+// ensureIngressAnnotations sets the access annotations of an ingress to the desired ones and
+// removes those no longer desired. Other annotations are left alone.
+func ensureIngressAnnotations(ingress *netv1.Ingress, desired map[string]string) bool {
+	changed := false
+	for _, key := range accessAnnotations {
+		value, ok := desired[key]
+		if current, exists := ingress.Annotations[key]; exists == ok && current == value {
+			continue
+		}
+		changed = true
+		if !ok {
+			delete(ingress.Annotations, key)
+			continue
+		}
+		if ingress.Annotations == nil {
+			ingress.Annotations = map[string]string{}
+		}
+		ingress.Annotations[key] = value
+	}
+	return changed
+}
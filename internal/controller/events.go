@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	webv1 "website-operator/api/v1"
+	"website-operator/internal/imagepolicy"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// controllerName is the source of the events recorded by the controller.
+const controllerName = "website-controller"
+
+// Reasons of the events recorded for failures, also used for the Degraded and Available conditions.
+const (
+	reasonReconcileFailed  = "ReconcileFailed"
+	reasonInvalidSpec      = "InvalidSpec"
+	reasonQuotaExceeded    = "QuotaExceeded"
+	reasonIngressConflict  = "IngressConflict"
+	reasonInvalidImage     = "InvalidImage"
+	reasonHostnameConflict = "HostnameConflict"
+	reasonImagePolicy      = "ImagePolicyViolation"
+	reasonNginxConfig      = "InvalidNginxConfig"
+	reasonBasicAuthSecret  = "InvalidBasicAuthSecret"
+	reasonPodCreation      = "PodCreationFailed"
+)
+
+// imagePullFailures are the waiting reasons of containers whose image can't be pulled.
+var imagePullFailures = []string{"ErrImagePull", "ImagePullBackOff", "InvalidImageName"}
+
+// childChanged records an operation on an object created for website, both in the metrics and
+// as an event of the website.
+func (r *WebsiteController) childChanged(website *webv1.WebSite, kind, operation, name string) {
+	r.metrics.observeChildOperation(kind, operation)
+
+	var verb string
+	switch operation {
+	case operationCreate:
+		verb = "Created"
+	case operationUpdate:
+		verb = "Updated"
+	case operationDelete:
+		verb = "Deleted"
+	}
+	r.recorder.Eventf(website, corev1.EventTypeNormal, kind+verb, "%s %s %s", verb, strings.ToLower(kind), name)
+}
+
+// failureReason classifies the error of a failed reconciliation phase.
+func failureReason(phase string, err error) string {
+	var violation *imagepolicy.Violation
+	var secretErr *basicAuthSecretError
+	switch {
+	case errors.As(err, &violation):
+		return reasonImagePolicy
+	case errors.As(err, &secretErr):
+		return reasonBasicAuthSecret
+	case apierrors.IsForbidden(err) && strings.Contains(err.Error(), "exceeded quota"):
+		return reasonQuotaExceeded
+	case phase == phaseIngress && (apierrors.IsAlreadyExists(err) || strings.Contains(err.Error(), "is already defined in ingress")):
+		// ingress-nginx rejects a host and path already served by another ingress
+		return reasonIngressConflict
+	case apierrors.IsInvalid(err):
+		return reasonInvalidSpec
+	default:
+		return reasonReconcileFailed
+	}
+}
+
+// specError marks err, caused by the spec of a website, as terminal. Retrying doesn't help, the
+// website is reconciled again once its spec changes.
+func specError(err error) error {
+	return reconcile.TerminalError(err)
+}
+
+// invalidSpec returns the terminal error of a website whose spec fails validation with errs.
+func invalidSpec(website *webv1.WebSite, errs field.ErrorList) error {
+	return specError(apierrors.NewInvalid(schema.GroupKind{Group: webv1.GroupName, Kind: "WebSite"}, website.Name, errs))
+}
+
+// podFailure returns why the pods of the deployment can't be created or one of them can't start,
+// and a message. Pods aren't created if the quota of the namespace is exceeded, reported as
+// reasonQuotaExceeded, or an admission webhook rejects them. A pod can't start if its image can't
+// be pulled, reported as reasonInvalidImage, or nginx rejects its configuration. The reason is
+// empty if there's no such failure.
+func (r *WebsiteController) podFailure(ctx context.Context, deployment *appsv1.Deployment) (string, string, error) {
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type != appsv1.DeploymentReplicaFailure || condition.Status != corev1.ConditionTrue || condition.Reason != "FailedCreate" {
+			continue
+		}
+		if strings.Contains(condition.Message, "exceeded quota") {
+			return reasonQuotaExceeded, fmt.Sprintf("pods can't be created: %s", condition.Message), nil
+		}
+		return reasonPodCreation, fmt.Sprintf("pods can't be created: %s", condition.Message), nil
+	}
+
+	selector := labels.SelectorFromSet(deployment.Spec.Template.Labels)
+	pods, err := r.kubeClient.CoreV1().Pods(deployment.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return "", "", fmt.Errorf("couldn't list pods: %w", err)
+	}
+
+	for _, pod := range pods.Items {
+		for _, container := range slices.Concat(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses) {
+			if waiting := container.State.Waiting; waiting != nil && slices.Contains(imagePullFailures, waiting.Reason) {
+				return reasonInvalidImage, fmt.Sprintf("image %s can't be pulled: %s: %s", container.Image, waiting.Reason, waiting.Message), nil
+			}
+		}
+		for _, container := range pod.Status.InitContainerStatuses {
+			if container.Name != nginxConfigCheckName {
+				continue
+			}
+			// a failed check is restarted, so it's waiting with the failure as last state
+			terminated := container.State.Terminated
+			if container.State.Waiting != nil {
+				terminated = container.LastTerminationState.Terminated
+			}
+			if terminated != nil && terminated.ExitCode != 0 {
+				return reasonNginxConfig, fmt.Sprintf("nginx rejects the configuration: %s", strings.TrimSpace(terminated.Message)), nil
+			}
+		}
+	}
+	return "", "", nil
+}
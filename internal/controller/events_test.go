@@ -0,0 +1,168 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	webv1 "website-operator/api/v1"
+	"website-operator/internal/imagepolicy"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestFailureReason(t *testing.T) {
+	deployments := schema.GroupResource{Group: "apps", Resource: "deployments"}
+	ingresses := schema.GroupResource{Group: "networking.k8s.io", Resource: "ingresses"}
+
+	tests := []struct {
+		name  string
+		phase string
+		err   error
+		want  string
+	}{
+		{
+			name:  "quota exceeded",
+			phase: phaseDeployment,
+			err: apierrors.NewForbidden(deployments, "website-a-deploy",
+				errors.New("exceeded quota: compute, requested: pods=1, used: pods=10, limited: pods=10")),
+			want: reasonQuotaExceeded,
+		},
+		{
+			name:  "forbidden",
+			phase: phaseDeployment,
+			err:   apierrors.NewForbidden(deployments, "website-a-deploy", errors.New("no RBAC policy matched")),
+			want:  reasonReconcileFailed,
+		},
+		{
+			name:  "host defined by another ingress",
+			phase: phaseIngress,
+			err: apierrors.NewBadRequest(`admission webhook "validate.nginx.ingress.kubernetes.io" denied the request: ` +
+				`host "a.example.com" and path "/" is already defined in ingress default/other`),
+			want: reasonIngressConflict,
+		},
+		{
+			name:  "ingress exists",
+			phase: phaseIngress,
+			err:   apierrors.NewAlreadyExists(ingresses, "website-a-ingress"),
+			want:  reasonIngressConflict,
+		},
+		{
+			name:  "invalid deployment",
+			phase: phaseDeployment,
+			err: apierrors.NewInvalid(schema.GroupKind{Group: "apps", Kind: "Deployment"}, "website-a-deploy", field.ErrorList{
+				field.Required(field.NewPath("spec", "template", "spec", "containers").Index(0).Child("image"), ""),
+			}),
+			want: reasonInvalidSpec,
+		},
+		{
+			name:  "wrapped error",
+			phase: phaseDeployment,
+			err:   errors.Join(errors.New("couldn't create deployment"), apierrors.NewInvalid(schema.GroupKind{Kind: "Deployment"}, "a", nil)),
+			want:  reasonInvalidSpec,
+		},
+		{
+			name:  "image policy violation",
+			phase: phaseImage,
+			err:   reconcile.TerminalError(&imagepolicy.Violation{Image: "evil/nginx", Reason: "not allowed"}),
+			want:  reasonImagePolicy,
+		},
+		{
+			name:  "missing basic auth secret",
+			phase: phaseAccess,
+			err: reconcile.TerminalError(&basicAuthSecretError{name: "users",
+				err: apierrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, "users")}),
+			want: reasonBasicAuthSecret,
+		},
+		{
+			name:  "other error",
+			phase: phaseRevision,
+			err:   errors.New("connection refused"),
+			want:  reasonReconcileFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := failureReason(tt.phase, tt.err); got != tt.want {
+				t.Errorf("failureReason() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPodFailure(t *testing.T) {
+	deployment := CreateDeploymentObject("website-blog", webv1.WebSiteSpec{NginxImage: "docker.io/nginx:1.28"}, "server {}", false)
+	deployment.Namespace = "default"
+
+	pod := func(name string, initStatus corev1.ContainerStatus) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: deployment.Spec.Template.Labels},
+			Status:     corev1.PodStatus{InitContainerStatuses: []corev1.ContainerStatus{initStatus}},
+		}
+	}
+	passed := corev1.ContainerStatus{
+		Name:                 nginxConfigCheckName,
+		State:                corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}},
+		LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Message: "flaky"}},
+	}
+	rejected := corev1.ContainerStatus{
+		Name:  nginxConfigCheckName,
+		State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+		LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1,
+			Message: "nginx: [emerg] unknown directive \"gzipp\"\nnginx: configuration file /etc/nginx/nginx.conf test failed\n"}},
+	}
+	pullFailed := corev1.ContainerStatus{
+		Name:  nginxConfigCheckName,
+		Image: "docker.io/nginx:missing",
+		State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ErrImagePull", Message: "not found"}},
+	}
+
+	replicaFailure := func(message string) []appsv1.DeploymentCondition {
+		return []appsv1.DeploymentCondition{{
+			Type: appsv1.DeploymentReplicaFailure, Status: corev1.ConditionTrue, Reason: "FailedCreate", Message: message,
+		}}
+	}
+
+	tests := []struct {
+		name        string
+		pod         *corev1.Pod
+		conditions  []appsv1.DeploymentCondition
+		wantReason  string
+		wantMessage string
+	}{
+		{name: "running", pod: pod("running", passed)},
+		{name: "quota exceeded", pod: pod("running", passed),
+			conditions:  replicaFailure(`pods "website-blog-1" is forbidden: exceeded quota: compute, requested: pods=1, used: pods=2, limited: pods=2`),
+			wantReason:  reasonQuotaExceeded,
+			wantMessage: `pods can't be created: pods "website-blog-1" is forbidden: exceeded quota: compute, requested: pods=1, used: pods=2, limited: pods=2`},
+		{name: "pod rejected", pod: pod("running", passed),
+			conditions:  replicaFailure(`admission webhook "policy.example.com" denied the request`),
+			wantReason:  reasonPodCreation,
+			wantMessage: `pods can't be created: admission webhook "policy.example.com" denied the request`},
+		{name: "config rejected", pod: pod("rejected", rejected), wantReason: reasonNginxConfig,
+			wantMessage: "nginx rejects the configuration: nginx: [emerg] unknown directive \"gzipp\"\nnginx: configuration file /etc/nginx/nginx.conf test failed"},
+		{name: "image not pulled", pod: pod("pull", pullFailed), wantReason: reasonInvalidImage,
+			wantMessage: "image docker.io/nginx:missing can't be pulled: ErrImagePull: not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deployment := deployment.DeepCopy()
+			deployment.Status.Conditions = tt.conditions
+			r := &WebsiteController{kubeClient: k8sfake.NewClientset(tt.pod)}
+			reason, message, err := r.podFailure(context.Background(), deployment)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reason != tt.wantReason || message != tt.wantMessage {
+				t.Errorf("podFailure() = %q, %q, want %q, %q", reason, message, tt.wantReason, tt.wantMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	webv1 "website-operator/api/v1"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// hostnameIndex indexes the websites by their hostname. It matches the selectable field of the
+// CRD, so the same selector lists the claims on a hostname from the API server.
+const hostnameIndex = "spec.hostname"
+
+// hostnameRecheckInterval is how often a website losing a hostname conflict checks the claim
+// again, since the winning website may be outside the scope of the controller and isn't watched.
+const hostnameRecheckInterval = time.Minute
+
+func indexHostname(obj client.Object) []string {
+	if hostname := obj.(*webv1.WebSite).Spec.Hostname; hostname != "" {
+		return []string{hostname}
+	}
+	return nil
+}
+
+// claimsBefore orders websites claiming the same hostname, the oldest claim wins. Websites
+// created in the same second are ordered by namespace and name.
+func claimsBefore(a, b *webv1.WebSite) int {
+	if c := a.CreationTimestamp.Compare(b.CreationTimestamp.Time); c != 0 {
+		return c
+	}
+	if c := strings.Compare(a.Namespace, b.Namespace); c != 0 {
+		return c
+	}
+	return strings.Compare(a.Name, b.Name)
+}
+
+// hostnameConflict returns the website holding the claim on the hostname of website, if it's
+// not website itself.
+func (r *WebsiteController) hostnameConflict(ctx context.Context, website *webv1.WebSite) (*webv1.WebSite, error) {
+	if website.Spec.Hostname == "" {
+		return nil, nil
+	}
+
+	sites, err := r.hostnameClaims(ctx, website.Spec.Hostname)
+	if err != nil {
+		return nil, err
+	}
+
+	var winner *webv1.WebSite
+	for i := range sites.Items {
+		if winner == nil || claimsBefore(&sites.Items[i], winner) < 0 {
+			winner = &sites.Items[i]
+		}
+	}
+	if winner == nil || (winner.Namespace == website.Namespace && winner.Name == website.Name) {
+		return nil, nil
+	}
+	return winner, nil
+}
+
+// hostnameClaims lists the websites claiming hostname in all namespaces and shards from the API
+// server. If the controller may only list the websites in its namespaces, the cache is used, and
+// conflicts are only detected within the scope of the controller.
+func (r *WebsiteController) hostnameClaims(ctx context.Context, hostname string) (*webv1.WebSiteList, error) {
+	var sites webv1.WebSiteList
+	err := r.apiReader.List(ctx, &sites, client.MatchingFields{hostnameIndex: hostname})
+	if errors.IsForbidden(err) {
+		log.FromContext(ctx).V(1).Info("not allowed to list websites in all namespaces, checking hostname claims in scope only")
+		err = r.Client.List(ctx, &sites, client.MatchingFields{hostnameIndex: hostname})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("couldn't list websites by hostname: %w", err)
+	}
+	return &sites, nil
+}
+
+// withholdIngress deletes the ingress of a website whose hostname is claimed by another one.
+func (r *WebsiteController) withholdIngress(ctx context.Context, req ctrl.Request, website *webv1.WebSite) error {
+	ingressObjectName := IngressObjectName(r.siteName(req))
+	err := r.kubeClient.NetworkingV1().Ingresses(req.Namespace).Delete(ctx, ingressObjectName, metav1.DeleteOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't delete ingress of conflicting hostname: %w", err)
+	}
+	r.childChanged(website, kindIngress, operationDelete, ingressObjectName)
+
+	log.FromContext(ctx).Info("ingress withheld because of a hostname conflict", "hostname", website.Spec.Hostname)
+	return nil
+}
+
+// sitesClaimingHostname maps a changed website to the other websites claiming its hostname, so
+// a conflict is resolved once the winning website is deleted or changes its hostname.
+func (r *WebsiteController) sitesClaimingHostname(ctx context.Context, obj client.Object) []reconcile.Request {
+	hostnames := indexHostname(obj)
+	if len(hostnames) == 0 {
+		return nil
+	}
+
+	var sites webv1.WebSiteList
+	if err := r.Client.List(ctx, &sites, client.MatchingFields{hostnameIndex: hostnames[0]}); err != nil {
+		log.FromContext(ctx).Error(err, "couldn't list websites by hostname", "hostname", hostnames[0])
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(sites.Items))
+	for _, site := range sites.Items {
+		if site.Namespace != obj.GetNamespace() || site.Name != obj.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&site)})
+		}
+	}
+	return slices.Clip(requests)
+}
@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+	webv1 "website-operator/api/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestHostnameConflict(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := webv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	claim := func(namespace, name, hostname string, age time.Duration) *webv1.WebSite {
+		s := site(namespace, name)
+		s.CreationTimestamp = metav1.NewTime(created.Add(-age))
+		s.Spec.Hostname = hostname
+		return s
+	}
+
+	oldest := claim("team-b", "shop", "shop.example.com", time.Hour)
+	sameAgeFirst := claim("team-a", "shop", "shop.example.com", time.Minute)
+	sameAgeSecond := claim("team-c", "shop", "shop.example.com", time.Minute)
+	other := claim("team-a", "blog", "blog.example.com", time.Minute)
+
+	// the oldest website is in another shard, so it's only known to the API server
+	r := &WebsiteController{
+		Client: fake.NewClientBuilder().WithScheme(scheme).
+			WithObjects(sameAgeFirst, sameAgeSecond, other).
+			WithIndex(&webv1.WebSite{}, hostnameIndex, indexHostname).
+			Build(),
+		apiReader: fake.NewClientBuilder().WithScheme(scheme).
+			WithObjects(oldest, sameAgeFirst, sameAgeSecond, other).
+			WithIndex(&webv1.WebSite{}, hostnameIndex, indexHostname).
+			Build(),
+	}
+
+	tests := []struct {
+		website *webv1.WebSite
+		want    *webv1.WebSite
+	}{
+		{website: oldest, want: nil},
+		{website: sameAgeFirst, want: oldest},
+		{website: sameAgeSecond, want: oldest},
+		{website: other, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.website.Namespace+"/"+tt.website.Name, func(t *testing.T) {
+			got, err := r.hostnameConflict(context.Background(), tt.website)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (got == nil) != (tt.want == nil) || got != nil && (got.Namespace != tt.want.Namespace || got.Name != tt.want.Name) {
+				t.Errorf("hostnameConflict() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	requests := r.sitesClaimingHostname(context.Background(), oldest)
+	if len(requests) != 2 {
+		t.Errorf("expected the other 2 websites claiming the hostname to be reconciled, got %v", requests)
+	}
+}
+
+func TestClaimsBefore(t *testing.T) {
+	a, b := site("team-a", "shop"), site("team-b", "shop")
+	a.CreationTimestamp = metav1.Now()
+	b.CreationTimestamp = a.CreationTimestamp
+	if claimsBefore(a, b) >= 0 || claimsBefore(b, a) <= 0 {
+		t.Error("expected websites created at the same time to be ordered by namespace")
+	}
+	b.CreationTimestamp = metav1.NewTime(a.CreationTimestamp.Add(-time.Hour))
+	if claimsBefore(b, a) >= 0 {
+		t.Error("expected the older website to claim first")
+	}
+}
@@ -0,0 +1,33 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	webv1 "website-operator/api/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// resolveImage checks the nginx image of website against the image policy, and returns the image
+// to deploy with its digest, if known. If tags are resolved, the image is pinned to the digest
+// resolved once per generation, so a moved tag isn't rolled out until the spec changes.
+func (r *WebsiteController) resolveImage(ctx context.Context, website *webv1.WebSite) (string, string, error) {
+	ref, err := r.images.Check(website.Spec.NginxImage)
+	if err != nil {
+		return "", "", specError(err)
+	}
+	if ref.Digest != "" || r.resolver == nil {
+		return website.Spec.NginxImage, ref.Digest, nil
+	}
+
+	digest := website.Status.ImageDigest
+	if digest == "" || website.Status.ObservedGeneration != website.Generation {
+		if digest, err = r.resolver.Resolve(ctx, ref); err != nil {
+			return "", "", fmt.Errorf("couldn't resolve digest of image %s: %w", website.Spec.NginxImage, err)
+		}
+		log.FromContext(ctx).Info("resolved image digest", "image", website.Spec.NginxImage, "digest", digest)
+	}
+
+	ref.Digest = digest
+	return ref.String(), digest, nil
+}
@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	webv1 "website-operator/api/v1"
+	"website-operator/internal/imagepolicy"
+)
+
+func TestResolveImage(t *testing.T) {
+	const (
+		digest    = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+		newDigest = "sha256:fedcba9876543210fedcba9876543210fedcba9876543210fedcba9876543210"
+	)
+
+	var requests int
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/v2/web/nginx/manifests/1.28" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Docker-Content-Digest", newDigest)
+	}))
+	defer registry.Close()
+	host := strings.TrimPrefix(registry.URL, "http://")
+
+	r := &WebsiteController{
+		images:   &imagepolicy.Policy{Allowed: []string{host}},
+		resolver: imagepolicy.NewResolver(registry.Client(), []string{host}),
+	}
+	website := func(image string, generation, observedGeneration int64) *webv1.WebSite {
+		s := site("default", "blog")
+		s.Generation = generation
+		s.Spec.NginxImage = image
+		s.Status.ObservedGeneration = observedGeneration
+		s.Status.ImageDigest = digest
+		return s
+	}
+
+	tests := []struct {
+		name       string
+		website    *webv1.WebSite
+		wantImage  string
+		wantDigest string
+		requests   int
+	}{
+		{
+			name:       "spec changed",
+			website:    website(host+"/web/nginx:1.28", 2, 1),
+			wantImage:  host + "/web/nginx:1.28@" + newDigest,
+			wantDigest: newDigest,
+			requests:   1,
+		},
+		{
+			name:       "resolved for generation",
+			website:    website(host+"/web/nginx:1.28", 2, 2),
+			wantImage:  host + "/web/nginx:1.28@" + digest,
+			wantDigest: digest,
+		},
+		{
+			name:       "pinned by spec",
+			website:    website(host+"/web/nginx@"+newDigest, 2, 1),
+			wantImage:  host + "/web/nginx@" + newDigest,
+			wantDigest: newDigest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests = 0
+			image, digest, err := r.resolveImage(context.Background(), tt.website)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if image != tt.wantImage || digest != tt.wantDigest || requests != tt.requests {
+				t.Errorf("resolveImage() = %s, %s after %d requests, want %s, %s after %d requests",
+					image, digest, requests, tt.wantImage, tt.wantDigest, tt.requests)
+			}
+		})
+	}
+
+	_, _, err := r.resolveImage(context.Background(), website("docker.io/nginx:1.28", 2, 1))
+	var violation *imagepolicy.Violation
+	if !errors.As(err, &violation) {
+		t.Errorf("expected image policy violation, got %v", err)
+	}
+
+	if _, _, err := r.resolveImage(context.Background(), website(host+"/web/nginx:missing", 2, 1)); err == nil {
+		t.Error("expected missing tag to fail")
+	}
+}
@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	webv1 "website-operator/api/v1"
+	"website-operator/internal/nginxconf"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// defaultMaintenancePage is served for websites in maintenance without their own page.
+const defaultMaintenancePage = `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Down for maintenance</title></head>
+<body>
+<h1>Down for maintenance</h1>
+<p>This site is undergoing maintenance and will be back shortly.</p>
+</body>
+</html>
+`
+
+func maintenanceEnabled(spec webv1.WebSiteSpec) bool {
+	return spec.Maintenance != nil && spec.Maintenance.Enabled
+}
+
+// nginxMaintenance returns the maintenance page nginx serves for a website, or nil if it isn't
+// in maintenance.
+func nginxMaintenance(spec webv1.WebSiteSpec) *nginxconf.Maintenance {
+	if !maintenanceEnabled(spec) {
+		return nil
+	}
+	return &nginxconf.Maintenance{Root: maintenanceDir, File: maintenanceFile, RetryAfterSeconds: spec.Maintenance.RetryAfterSeconds}
+}
+
+func maintenancePage(spec webv1.WebSiteSpec) string {
+	if spec.Maintenance.HTML == "" {
+		return defaultMaintenancePage
+	}
+	return spec.Maintenance.HTML
+}
+
+// ensureMaintenance stores the maintenance page of a website in maintenance in its own ConfigMap,
+// so the content ConfigMap stays as it is.
+func (r *WebsiteController) ensureMaintenance(ctx context.Context, req ctrl.Request, website *webv1.WebSite) error {
+	if !maintenanceEnabled(website.Spec) {
+		return nil
+	}
+
+	siteName := r.siteName(req)
+	cmClient := r.kubeClient.CoreV1().ConfigMaps(req.Namespace)
+	cmName := MaintenanceConfigMapObjectName(siteName)
+	page := maintenancePage(website.Spec)
+
+	confMap, err := cmClient.Get(ctx, cmName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		if _, err := cmClient.Create(ctx, CreateMaintenanceConfigMapObject(siteName, page), metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("couldn't create maintenance configmap: %w", err)
+		}
+		r.childChanged(website, kindConfigMap, operationCreate, cmName)
+		log.FromContext(ctx).Info("new maintenance configmap created for website", "configMapName", cmName)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't get maintenance configmap: %w", err)
+	}
+
+	if len(confMap.Data) != 1 || confMap.Data[maintenanceFile] != page || len(confMap.BinaryData) > 0 {
+		confMap.Data = map[string]string{maintenanceFile: page}
+		confMap.BinaryData = nil
+		if _, err := cmClient.Update(ctx, confMap, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("couldn't update maintenance configmap: %w", err)
+		}
+		r.childChanged(website, kindConfigMap, operationUpdate, cmName)
+		log.FromContext(ctx).Info("maintenance page updated via configmap")
+	}
+	return nil
+}
+
+// removeMaintenance deletes the maintenance ConfigMap of a website which isn't in maintenance.
+// Pods of the previous ReplicaSet mount it until they're replaced, so it's only deleted once the
+// deployment is rolled out.
+func (r *WebsiteController) removeMaintenance(ctx context.Context, req ctrl.Request, website *webv1.WebSite, deployment *appsv1.Deployment) error {
+	if maintenanceEnabled(website.Spec) || !deploymentRolledOut(deployment) {
+		return nil
+	}
+
+	cmName := MaintenanceConfigMapObjectName(r.siteName(req))
+	err := r.kubeClient.CoreV1().ConfigMaps(req.Namespace).Delete(ctx, cmName, metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't delete maintenance configmap: %w", err)
+	}
+	r.childChanged(website, kindConfigMap, operationDelete, cmName)
+	return nil
+}
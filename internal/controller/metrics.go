@@ -0,0 +1,143 @@
+package controller
+
+import (
+	"context"
+	"time"
+	webv1 "website-operator/api/v1"
+
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Reconciliation phases, used to label errors.
+const (
+	phaseImage       = "resolveImage"
+	phaseAccess      = "ensureAccess"
+	phaseMaintenance = "ensureMaintenance"
+	phaseNginxConfig = "ensureNginxConfig"
+	phaseDeployment  = "ensureDeployment"
+	phaseConfigMap   = "ensureConfigMap"
+	phaseService     = "ensureService"
+	phaseIngress     = "ensureIngress"
+	phaseRevision    = "ensureRevision"
+	phaseStatus      = "updateStatus"
+	phaseFinalize    = "finalizeWebsite"
+)
+
+// Kinds of the objects created for a website.
+const (
+	kindDeployment = "Deployment"
+	kindConfigMap  = "ConfigMap"
+	kindSecret     = "Secret"
+	kindService    = "Service"
+	kindIngress    = "Ingress"
+	kindRevision   = "Revision"
+)
+
+// Operations on the objects created for a website.
+const (
+	operationCreate = "create"
+	operationUpdate = "update"
+	operationDelete = "delete"
+)
+
+const collectTimeout = 5 * time.Second
+
+// Metrics collects the Prometheus metrics of the website controller. The number of sites by
+// condition is read from reader whenever the metrics are collected.
+type Metrics struct {
+	reader client.Reader
+
+	sites           *prometheus.Desc
+	childOperations *prometheus.CounterVec
+	timeToAvailable *prometheus.HistogramVec
+	reconcileErrors *prometheus.CounterVec
+}
+
+func NewMetrics(reader client.Reader) *Metrics {
+	return &Metrics{
+		reader: reader,
+		sites: prometheus.NewDesc(
+			"website_controller_sites",
+			"Number of websites by namespace, condition and condition status.",
+			[]string{"namespace", "condition", "status"}, nil),
+		childOperations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "website_controller_child_operations_total",
+			Help: "Number of objects created, updated and deleted for websites by kind and operation.",
+		}, []string{"kind", "operation"}),
+		timeToAvailable: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "website_controller_spec_to_available_seconds",
+			Help:    "Time from observing a spec change of a website until it is rolled out and available.",
+			Buckets: []float64{1, 2, 5, 10, 20, 30, 60, 120, 300, 600},
+		}, []string{"namespace"}),
+		reconcileErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "website_controller_reconcile_errors_total",
+			Help: "Number of failed reconciliations by phase.",
+		}, []string{"phase"}),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- m.sites
+	m.childOperations.Describe(ch)
+	m.timeToAvailable.Describe(ch)
+	m.reconcileErrors.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.collectSites(ch)
+	m.childOperations.Collect(ch)
+	m.timeToAvailable.Collect(ch)
+	m.reconcileErrors.Collect(ch)
+}
+
+type siteCount struct {
+	namespace, condition string
+	status               metav1.ConditionStatus
+}
+
+func (m *Metrics) collectSites(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	var sites webv1.WebSiteList
+	if err := m.reader.List(ctx, &sites); err != nil {
+		ch <- prometheus.NewInvalidMetric(m.sites, err)
+		return
+	}
+
+	counts := map[siteCount]int{}
+	for _, site := range sites.Items {
+		for _, conditionType := range []string{webv1.ConditionAvailable, webv1.ConditionProgressing, webv1.ConditionDegraded, webv1.ConditionPaused, webv1.ConditionHostnameConflict} {
+			status := metav1.ConditionUnknown
+			for _, condition := range site.Status.Conditions {
+				if condition.Type == conditionType {
+					status = condition.Status
+				}
+			}
+			counts[siteCount{site.Namespace, conditionType, status}]++
+		}
+	}
+
+	for key, count := range counts {
+		ch <- prometheus.MustNewConstMetric(m.sites, prometheus.GaugeValue, float64(count),
+			key.namespace, key.condition, string(key.status))
+	}
+}
+
+func (m *Metrics) observeChildOperation(kind, operation string) {
+	m.childOperations.WithLabelValues(kind, operation).Inc()
+}
+
+func (m *Metrics) observeError(phase string, err error) {
+	if err != nil {
+		m.reconcileErrors.WithLabelValues(phase).Inc()
+	}
+}
+
+func (m *Metrics) observeAvailable(namespace string, d time.Duration) {
+	m.timeToAvailable.WithLabelValues(namespace).Observe(d.Seconds())
+}
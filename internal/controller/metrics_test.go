@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	webv1 "website-operator/api/v1"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func site(namespace, name string, conditions ...metav1.Condition) *webv1.WebSite {
+	return &webv1.WebSite{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Status:     webv1.WebSiteStatus{Conditions: conditions},
+	}
+}
+
+func TestMetricsCollectSites(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := webv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	available := metav1.Condition{Type: webv1.ConditionAvailable, Status: metav1.ConditionTrue}
+	unavailable := metav1.Condition{Type: webv1.ConditionAvailable, Status: metav1.ConditionFalse}
+	degraded := metav1.Condition{Type: webv1.ConditionDegraded, Status: metav1.ConditionTrue}
+
+	reader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		site("default", "a", available),
+		site("default", "b", available),
+		site("default", "c", unavailable, degraded),
+		site("team", "d"),
+	).Build()
+
+	m := NewMetrics(reader)
+	m.observeChildOperation(kindDeployment, operationCreate)
+	m.observeError(phaseIngress, errors.New("failed"))
+	m.observeError(phaseService, nil)
+
+	expected := `
+# HELP website_controller_sites Number of websites by namespace, condition and condition status.
+# TYPE website_controller_sites gauge
+website_controller_sites{condition="Available",namespace="default",status="False"} 1
+website_controller_sites{condition="Available",namespace="default",status="True"} 2
+website_controller_sites{condition="Available",namespace="team",status="Unknown"} 1
+website_controller_sites{condition="Degraded",namespace="default",status="True"} 1
+website_controller_sites{condition="Degraded",namespace="default",status="Unknown"} 2
+website_controller_sites{condition="Degraded",namespace="team",status="Unknown"} 1
+website_controller_sites{condition="HostnameConflict",namespace="default",status="Unknown"} 3
+website_controller_sites{condition="HostnameConflict",namespace="team",status="Unknown"} 1
+website_controller_sites{condition="Paused",namespace="default",status="Unknown"} 3
+website_controller_sites{condition="Paused",namespace="team",status="Unknown"} 1
+website_controller_sites{condition="Progressing",namespace="default",status="Unknown"} 3
+website_controller_sites{condition="Progressing",namespace="team",status="Unknown"} 1
+# HELP website_controller_child_operations_total Number of objects created, updated and deleted for websites by kind and operation.
+# TYPE website_controller_child_operations_total counter
+website_controller_child_operations_total{kind="Deployment",operation="create"} 1
+# HELP website_controller_reconcile_errors_total Number of failed reconciliations by phase.
+# TYPE website_controller_reconcile_errors_total counter
+website_controller_reconcile_errors_total{phase="ensureIngress"} 1
+`
+	err := testutil.CollectAndCompare(m, strings.NewReader(expected),
+		"website_controller_sites", "website_controller_child_operations_total", "website_controller_reconcile_errors_total")
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	webv1 "website-operator/api/v1"
+	"website-operator/internal/nginxconf"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// rendersNginxConfig reports whether website needs its own nginx configuration, for its server
+// configuration, for access restrictions enforced by nginx or for maintenance.
+func (r *WebsiteController) rendersNginxConfig(website *webv1.WebSite) bool {
+	return website.Spec.Server != nil || r.nginxAccess(website) != nil || maintenanceEnabled(website.Spec)
+}
+
+// ensureNginxConfig renders the server configuration of website into an immutable nginx ConfigMap
+// named after the configuration and returns the rendered configuration, or an empty string if
+// the website has none.
+func (r *WebsiteController) ensureNginxConfig(ctx context.Context, req ctrl.Request, website *webv1.WebSite) (string, error) {
+	if !r.rendersNginxConfig(website) {
+		return "", nil
+	}
+
+	if errs := nginxconf.Validate(website.Spec.Server, field.NewPath("spec", "server")); len(errs) > 0 {
+		return "", invalidSpec(website, errs)
+	}
+	nginxConfig, err := nginxconf.Render(website.Spec.Server, nginxconf.Options{
+		Access:      r.nginxAccess(website),
+		Maintenance: nginxMaintenance(website.Spec),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	siteName := r.siteName(req)
+	cmClient := r.kubeClient.CoreV1().ConfigMaps(req.Namespace)
+	cmName := NginxConfigMapObjectName(siteName, nginxConfig)
+
+	_, err = cmClient.Get(ctx, cmName, metav1.GetOptions{})
+	if err == nil {
+		return nginxConfig, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return "", fmt.Errorf("couldn't get nginx configmap: %w", err)
+	}
+
+	if _, err := cmClient.Create(ctx, CreateNginxConfigMapObject(siteName, nginxConfig), metav1.CreateOptions{}); err != nil {
+		return "", fmt.Errorf("couldn't create nginx configmap: %w", err)
+	}
+	r.childChanged(website, kindConfigMap, operationCreate, cmName)
+	log.FromContext(ctx).Info("new nginx configmap created for website", "configMapName", cmName)
+	return nginxConfig, nil
+}
+
+// removeNginxConfigs deletes the nginx ConfigMaps of a website but the one of nginxConfig. Pods of
+// the previous ReplicaSet mount theirs until they're replaced, so they're only deleted once the
+// deployment is rolled out.
+func (r *WebsiteController) removeNginxConfigs(ctx context.Context, req ctrl.Request, website *webv1.WebSite, deployment *appsv1.Deployment, nginxConfig string) error {
+	if !deploymentRolledOut(deployment) {
+		return nil
+	}
+
+	siteName := r.siteName(req)
+	var current string
+	if nginxConfig != "" {
+		current = NginxConfigMapObjectName(siteName, nginxConfig)
+	}
+
+	cmClient := r.kubeClient.CoreV1().ConfigMaps(req.Namespace)
+	configs, err := cmClient.List(ctx, metav1.ListOptions{LabelSelector: nginxConfigSelector(siteName)})
+	if err != nil {
+		return fmt.Errorf("couldn't list nginx configmaps: %w", err)
+	}
+	for _, confMap := range configs.Items {
+		if confMap.Name == current {
+			continue
+		}
+		err := cmClient.Delete(ctx, confMap.Name, metav1.DeleteOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("couldn't delete nginx configmap: %w", err)
+		}
+		r.childChanged(website, kindConfigMap, operationDelete, confMap.Name)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"context"
+	"slices"
+	"testing"
+	webv1 "website-operator/api/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestRemoveNginxConfigs(t *testing.T) {
+	previous := CreateNginxConfigMapObject("website-blog", "server { listen 80; }")
+	current := CreateNginxConfigMapObject("website-blog", "server { listen 8080; }")
+	other := CreateNginxConfigMapObject("website-blog-archive", "server { listen 80; }")
+	for _, cm := range []metav1.Object{previous, current, other} {
+		cm.SetNamespace("default")
+	}
+
+	spec := webv1.WebSiteSpec{NginxImage: "docker.io/nginx:1.28"}
+	deployment := CreateDeploymentObject("website-blog", spec, "server { listen 8080; }", false)
+	deployment.Generation = 2
+
+	tests := []struct {
+		name   string
+		status func()
+		want   []string
+	}{
+		{
+			name:   "rolling out",
+			status: func() { deployment.Status.ObservedGeneration = 1 },
+			want:   []string{current.Name, previous.Name, other.Name},
+		},
+		{
+			name: "rolled out",
+			status: func() {
+				deployment.Status.ObservedGeneration = 2
+				deployment.Status.Replicas, deployment.Status.UpdatedReplicas, deployment.Status.AvailableReplicas = 1, 1, 1
+			},
+			want: []string{current.Name, other.Name},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.status()
+			kubeClient := k8sfake.NewClientset(previous, current, other)
+			r := &WebsiteController{kubeClient: kubeClient, metrics: NewMetrics(nil), recorder: record.NewFakeRecorder(10)}
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "blog"}}
+
+			if err := r.removeNginxConfigs(context.Background(), req, site("default", "blog"), deployment, "server { listen 8080; }"); err != nil {
+				t.Fatal(err)
+			}
+
+			configs, err := kubeClient.CoreV1().ConfigMaps("default").List(context.Background(), metav1.ListOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, cm := range configs.Items {
+				names = append(names, cm.Name)
+			}
+			slices.Sort(names)
+			slices.Sort(tt.want)
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("expected configmaps %v, got %v", tt.want, names)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	webv1 "website-operator/api/v1"
+	"website-operator/internal/shard"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const shardLabelInterval = time.Minute
+
+// ShardLabeler assigns websites without a shard label to their bucket, so a sharded controller
+// picks them up. Websites created by the HTTP API are labeled on creation already, so this only
+// catches websites created otherwise, e.g. with kubectl.
+type ShardLabeler struct {
+	// reader bypasses the cache, which only holds the websites of a shard
+	reader     client.Reader
+	writer     client.Writer
+	namespaces []string
+}
+
+// NewShardLabeler creates a labeler for the websites in namespaces, or all namespaces if empty.
+func NewShardLabeler(mgr manager.Manager, namespaces []string) *ShardLabeler {
+	return &ShardLabeler{
+		reader:     mgr.GetAPIReader(),
+		writer:     mgr.GetClient(),
+		namespaces: namespaces,
+	}
+}
+
+// Start implements manager.Runnable and labels websites periodically until ctx is done.
+func (l *ShardLabeler) Start(ctx context.Context) error {
+	ticker := time.NewTicker(shardLabelInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := l.labelWebsites(ctx); err != nil {
+			log.FromContext(ctx).Error(err, "couldn't label websites with their shard")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable.
+func (l *ShardLabeler) NeedLeaderElection() bool {
+	return true
+}
+
+func (l *ShardLabeler) labelWebsites(ctx context.Context) error {
+	unlabeled, err := labels.NewRequirement(shard.Label, selection.DoesNotExist, nil)
+	if err != nil {
+		return err
+	}
+	selector := client.MatchingLabelsSelector{Selector: labels.NewSelector().Add(*unlabeled)}
+
+	namespaces := l.namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{""}
+	}
+
+	var errs []error
+	for _, namespace := range namespaces {
+		var sites webv1.WebSiteList
+		if err := l.reader.List(ctx, &sites, client.InNamespace(namespace), selector); err != nil {
+			errs = append(errs, fmt.Errorf("couldn't list websites: %w", err))
+			continue
+		}
+
+		for i := range sites.Items {
+			site := &sites.Items[i]
+			patch := client.MergeFrom(site.DeepCopy())
+			site.Labels = shard.SetLabel(site.Labels, site.Namespace, site.Name)
+			if err := l.writer.Patch(ctx, site, patch); err != nil {
+				errs = append(errs, fmt.Errorf("couldn't label website %s/%s: %w", site.Namespace, site.Name, err))
+				continue
+			}
+			log.FromContext(ctx).Info("labeled website with its shard", "website", site.Name, "namespace", site.Namespace, "bucket", site.Labels[shard.Label])
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -3,58 +3,163 @@ package controller
 import (
 	"context"
 	"fmt"
+	"slices"
 	webv1 "website-operator/api/v1"
+	"website-operator/internal/imagepolicy"
+	"website-operator/internal/revision"
 
 	v1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	netv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
 
 type WebsiteController struct {
 	client.Client
+	// apiReader bypasses the cache, which only holds the websites in scope
+	apiReader  client.Reader
 	scheme     *runtime.Scheme
 	kubeClient kubernetes.Interface
+	metrics    *Metrics
+	recorder   record.EventRecorder
+	images     *imagepolicy.Policy
+	// resolver pins the images of websites to digests, unless nil
+	resolver *imagepolicy.Resolver
+	exposure Exposure
 }
 
-func NewWebsiteController(mgr manager.Manager, kubeClient kubernetes.Interface) *WebsiteController {
+func NewWebsiteController(mgr manager.Manager, kubeClient kubernetes.Interface, metrics *Metrics, images *imagepolicy.Policy, resolver *imagepolicy.Resolver, exposure Exposure) *WebsiteController {
 	return &WebsiteController{
 		Client:     mgr.GetClient(),
+		apiReader:  mgr.GetAPIReader(),
 		scheme:     mgr.GetScheme(),
 		kubeClient: kubeClient,
+		metrics:    metrics,
+		recorder:   mgr.GetEventRecorderFor(controllerName),
+		images:     images,
+		resolver:   resolver,
+		exposure:   exposure,
 	}
 }
 
+// SetupWithManager registers the controller with mgr. Changes of the status only, which is
+// written by the controller itself, don't trigger a reconciliation. Websites are indexed by their
+// hostname, and a change of one website reconciles all others claiming the same hostname. Secrets
+// are watched by their metadata only, a change reconciles the websites whose users it holds.
+func (r *WebsiteController) SetupWithManager(mgr manager.Manager) error {
+	err := mgr.GetFieldIndexer().IndexField(context.Background(), &webv1.WebSite{}, hostnameIndex, indexHostname)
+	if err != nil {
+		return fmt.Errorf("couldn't index websites by hostname: %w", err)
+	}
+	err = mgr.GetFieldIndexer().IndexField(context.Background(), &webv1.WebSite{}, accessSecretIndex, indexAccessSecret)
+	if err != nil {
+		return fmt.Errorf("couldn't index websites by basic auth secret: %w", err)
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&webv1.WebSite{}, builder.WithPredicates(predicate.Or(
+			predicate.GenerationChangedPredicate{},
+			predicate.AnnotationChangedPredicate{},
+			predicate.LabelChangedPredicate{},
+		))).
+		Watches(&webv1.WebSite{}, handler.EnqueueRequestsFromMapFunc(r.sitesClaimingHostname),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.sitesReferencingSecret), builder.OnlyMetadata).
+		Complete(r)
+}
+
 func (r *WebsiteController) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	website, err := r.getWebsite(ctx, req)
-	if r.needsFinalizeWebsite(err) {
-		return r.finalizeWebsite(ctx, req)
+	if errors.IsNotFound(err) {
+		return r.reconcileMissing(ctx, req)
 	}
-
-	if err = r.ensureDeployment(ctx, req, website); err != nil {
+	if err != nil {
 		return ctrl.Result{}, err
 	}
 
+	if website.Paused() {
+		return r.reconcilePaused(ctx, website)
+	}
+	if meta.IsStatusConditionTrue(website.Status.Conditions, webv1.ConditionPaused) {
+		// the ensure steps below revert any changes made to the objects while paused
+		log.FromContext(ctx).Info("resuming reconciliation of website")
+		r.recorder.Event(website, corev1.EventTypeNormal, "Resumed", "Reconciliation resumed, changes made to the objects of the website are reverted")
+	}
+
+	image, digest, err := r.resolveImage(ctx, website)
+	if err != nil {
+		return r.reconcileFailed(ctx, website, phaseImage, err)
+	}
+
+	if err = r.ensureAccess(ctx, req, website); err != nil {
+		return r.reconcileFailed(ctx, website, phaseAccess, err)
+	}
+
+	if err = r.ensureMaintenance(ctx, req, website); err != nil {
+		return r.reconcileFailed(ctx, website, phaseMaintenance, err)
+	}
+
+	nginxConfig, err := r.ensureNginxConfig(ctx, req, website)
+	if err != nil {
+		return r.reconcileFailed(ctx, website, phaseNginxConfig, err)
+	}
+
+	deployment, err := r.ensureDeployment(ctx, req, website, image, nginxConfig)
+	if err != nil {
+		return r.reconcileFailed(ctx, website, phaseDeployment, err)
+	}
+
+	if err = r.removeNginxConfigs(ctx, req, website, deployment, nginxConfig); err != nil {
+		return r.reconcileFailed(ctx, website, phaseNginxConfig, err)
+	}
+
+	if err = r.removeMaintenance(ctx, req, website, deployment); err != nil {
+		return r.reconcileFailed(ctx, website, phaseMaintenance, err)
+	}
+
 	if err = r.ensureConfigMap(ctx, req, website); err != nil {
-		return ctrl.Result{}, err
+		return r.reconcileFailed(ctx, website, phaseConfigMap, err)
 	}
 
-	if err = r.ensureService(ctx, req); err != nil {
-		return ctrl.Result{}, err
+	if err = r.ensureService(ctx, req, website); err != nil {
+		return r.reconcileFailed(ctx, website, phaseService, err)
 	}
 
-	if err = r.ensureIngress(ctx, req, website); err != nil {
-		return ctrl.Result{}, err
+	conflict, err := r.hostnameConflict(ctx, website)
+	if err != nil {
+		return r.reconcileFailed(ctx, website, phaseIngress, err)
+	}
+	if conflict != nil {
+		err = r.withholdIngress(ctx, req, website)
+	} else {
+		err = r.ensureIngress(ctx, req, website)
+	}
+	if err != nil {
+		return r.reconcileFailed(ctx, website, phaseIngress, err)
 	}
 
-	return ctrl.Result{}, nil
+	if err = r.removeHtpasswd(ctx, req, website, deployment); err != nil {
+		return r.reconcileFailed(ctx, website, phaseAccess, err)
+	}
+
+	if err = r.ensureRevision(ctx, req, website); err != nil {
+		return r.reconcileFailed(ctx, website, phaseRevision, err)
+	}
+
+	return r.updateStatus(ctx, website, deployment, conflict, digest)
 }
 
 func (r *WebsiteController) siteName(req ctrl.Request) string {
@@ -67,8 +172,22 @@ func (r *WebsiteController) getWebsite(ctx context.Context, req ctrl.Request) (*
 	return &website, err
 }
 
-func (r *WebsiteController) needsFinalizeWebsite(err error) bool {
-	return err != nil && errors.IsNotFound(err)
+// reconcileMissing finalizes a website missing from the cache once it's confirmed to be deleted.
+// A website whose labels changed so it's out of scope is missing from the cache as well, its
+// objects are left to the controller now in charge of it.
+func (r *WebsiteController) reconcileMissing(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	err := r.apiReader.Get(ctx, req.NamespacedName, &webv1.WebSite{})
+	if err == nil {
+		log.FromContext(ctx).Info("website left the scope of the controller, keeping its objects")
+		return ctrl.Result{}, nil
+	}
+	if !errors.IsNotFound(err) {
+		return ctrl.Result{}, fmt.Errorf("couldn't confirm deletion of website: %w", err)
+	}
+
+	result, err := r.finalizeWebsite(ctx, req)
+	r.metrics.observeError(phaseFinalize, err)
+	return result, err
 }
 
 func (r *WebsiteController) finalizeWebsite(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -78,68 +197,141 @@ func (r *WebsiteController) finalizeWebsite(ctx context.Context, req ctrl.Reques
 	deploymentsClient := r.kubeClient.AppsV1().Deployments(req.Namespace)
 	cmClient := r.kubeClient.CoreV1().ConfigMaps(req.Namespace)
 	svcClient := r.kubeClient.CoreV1().Services(req.Namespace)
+	secretClient := r.kubeClient.CoreV1().Secrets(req.Namespace)
 	ingressClient := r.kubeClient.NetworkingV1().Ingresses(req.Namespace)
 
+	// websites suspended or paused since their creation have no objects
 	err := deploymentsClient.Delete(ctx, DeploymentObjectName(siteName), metav1.DeleteOptions{})
-	if err != nil {
+	if client.IgnoreNotFound(err) != nil {
 		return ctrl.Result{}, fmt.Errorf("couldn't finalized deployment: %s", err)
 	}
-	log.Info("finalized deployment for website")
+	if err == nil {
+		r.metrics.observeChildOperation(kindDeployment, operationDelete)
+		log.Info("finalized deployment for website")
+	}
 	err = cmClient.Delete(ctx, ConfigMapObjectName(siteName), metav1.DeleteOptions{})
-	if err != nil {
+	if client.IgnoreNotFound(err) != nil {
 		return ctrl.Result{}, fmt.Errorf("couldn't finalized configmap: %s", err)
 	}
-	log.Info("finalized configmap for website")
+	if err == nil {
+		r.metrics.observeChildOperation(kindConfigMap, operationDelete)
+		log.Info("finalized configmap for website")
+	}
 
-	err = svcClient.Delete(ctx, ServiceObjectName(siteName), metav1.DeleteOptions{})
+	// only websites with a server configuration have nginx configmaps
+	err = cmClient.DeleteCollection(ctx, metav1.DeleteOptions{}, metav1.ListOptions{LabelSelector: nginxConfigSelector(siteName)})
 	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("couldn't finalize nginx configmaps: %s", err)
+	}
+	log.Info("finalized nginx configmaps for website")
+
+	err = svcClient.Delete(ctx, ServiceObjectName(siteName), metav1.DeleteOptions{})
+	if client.IgnoreNotFound(err) != nil {
 		return ctrl.Result{}, fmt.Errorf("couldn't finalized service: %s", err)
 	}
-	log.Info("finalized service for website")
+	if err == nil {
+		r.metrics.observeChildOperation(kindService, operationDelete)
+		log.Info("finalized service for website")
+	}
 
+	// the ingress of a website with a hostname conflict is withheld
 	err = ingressClient.Delete(ctx, IngressObjectName(siteName), metav1.DeleteOptions{})
-	if err != nil {
+	if client.IgnoreNotFound(err) != nil {
 		return ctrl.Result{}, fmt.Errorf("couldn't finalized ingress: %s", err)
 	}
-	log.Info("finalized ingress for website")
+	if err == nil {
+		r.metrics.observeChildOperation(kindIngress, operationDelete)
+		log.Info("finalized ingress for website")
+	}
+
+	// only websites in maintenance have a maintenance configmap
+	err = cmClient.Delete(ctx, MaintenanceConfigMapObjectName(siteName), metav1.DeleteOptions{})
+	if client.IgnoreNotFound(err) != nil {
+		return ctrl.Result{}, fmt.Errorf("couldn't finalize maintenance configmap: %s", err)
+	}
+	if err == nil {
+		r.metrics.observeChildOperation(kindConfigMap, operationDelete)
+		log.Info("finalized maintenance configmap for website")
+	}
 
-	return ctrl.Result{}, err
+	// only websites with basic auth have an htpasswd secret
+	err = secretClient.Delete(ctx, HtpasswdSecretObjectName(siteName), metav1.DeleteOptions{})
+	if client.IgnoreNotFound(err) != nil {
+		return ctrl.Result{}, fmt.Errorf("couldn't finalize htpasswd secret: %s", err)
+	}
+	if err == nil {
+		r.metrics.observeChildOperation(kindSecret, operationDelete)
+		log.Info("finalized htpasswd secret for website")
+	}
+
+	err = cmClient.DeleteCollection(ctx, metav1.DeleteOptions{}, metav1.ListOptions{LabelSelector: revision.Selector(req.Name)})
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("couldn't finalize revisions: %s", err)
+	}
+	log.Info("finalized revisions for website")
+
+	return ctrl.Result{}, nil
 }
 
-func (r *WebsiteController) ensureDeployment(ctx context.Context, req ctrl.Request, website *webv1.WebSite) error {
+// ensureDeployment returns the deployment of the website, created or updated to match its spec.
+// It runs image, which may be pinned to a digest, with nginxConfig unless empty.
+func (r *WebsiteController) ensureDeployment(ctx context.Context, req ctrl.Request, website *webv1.WebSite, image, nginxConfig string) (*v1.Deployment, error) {
 	siteName := r.siteName(req)
+	spec := website.Spec
+	spec.NginxImage = image
 	log := log.FromContext(ctx)
 	deploymentsClient := r.kubeClient.AppsV1().Deployments(req.Namespace)
 
 	deploymentName := DeploymentObjectName(siteName)
 	deployment, err := deploymentsClient.Get(ctx, deploymentName, metav1.GetOptions{})
 	if err != nil && errors.IsNotFound(err) {
-		deploymentObj := CreateDeploymentObject(siteName, website.Spec)
-		_, err := deploymentsClient.Create(ctx, deploymentObj, metav1.CreateOptions{})
+		deploymentObj := CreateDeploymentObject(siteName, spec, nginxConfig, r.mountsHtpasswd(spec))
+		deployment, err := deploymentsClient.Create(ctx, deploymentObj, metav1.CreateOptions{})
 		if err != nil {
-			return fmt.Errorf("couldn't create deployment: %s", err)
+			return nil, fmt.Errorf("couldn't create deployment: %w", err)
 		}
+		r.childChanged(website, kindDeployment, operationCreate, deploymentName)
 
 		log.Info("new deployment created for website", "deploymentName", deploymentName)
-		return nil
+		return deployment, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get deployment: %w", err)
 	}
 
-	// look up nginx image change
-	if r.ensureDeploymentSpec(deployment, website) {
+	if r.ensureDeploymentSpec(deployment, siteName, spec, nginxConfig) {
 		deployment, err = deploymentsClient.Update(ctx, deployment, metav1.UpdateOptions{})
 		if err != nil {
-			return fmt.Errorf("couldn't update deployment: %s", err)
+			return nil, fmt.Errorf("couldn't update deployment: %w", err)
 		}
+		r.childChanged(website, kindDeployment, operationUpdate, deploymentName)
 		log.Info("updated deployment")
 	}
 
-	return nil
+	return deployment, nil
 }
 
-func (r *WebsiteController) ensureDeploymentSpec(deployment *v1.Deployment, website *webv1.WebSite) bool {
-	needsUpdate := deployment.Spec.Template.Spec.Containers[0].Image != website.Spec.NginxImage
+// ensureDeploymentSpec reverts changes of the fields set by the controller, e.g. the nginx image.
+// Fields left empty are defaulted by the API server and may differ.
+func (r *WebsiteController) ensureDeploymentSpec(deployment *v1.Deployment, siteName string, spec webv1.WebSiteSpec, nginxConfig string) bool {
+	desired := CreateDeploymentObject(siteName, spec, nginxConfig, r.mountsHtpasswd(spec))
+
+	needsUpdate := !equality.Semantic.DeepDerivative(desired.Spec.Replicas, deployment.Spec.Replicas) ||
+		!equality.Semantic.DeepDerivative(desired.Spec.Template, deployment.Spec.Template)
+
+	// look up added or removed content files, which are mapped to their paths by the volume items.
+	// No items are ignored by the comparison above.
+	items := contentVolumeItems(spec)
+	for _, volume := range deployment.Spec.Template.Spec.Volumes {
+		if volume.Name == contentVolumeName && volume.ConfigMap != nil && !equality.Semantic.DeepEqual(volume.ConfigMap.Items, items) {
+			needsUpdate = true
+		}
+	}
 
-	deployment.Spec.Template.Spec.Containers[0].Image = website.Spec.NginxImage
+	if needsUpdate {
+		deployment.Spec.Replicas = desired.Spec.Replicas
+		deployment.Spec.Template = desired.Spec.Template
+	}
 	return needsUpdate
 }
 
@@ -157,7 +349,10 @@ func (r *WebsiteController) ensureConfigMap(ctx context.Context, req ctrl.Reques
 		cmObj := CreateConfigMapObject(siteName, website.Spec)
 		_, err = cmClient.Create(ctx, cmObj, metav1.CreateOptions{})
 		if err != nil && !errors.IsAlreadyExists(err) {
-			return fmt.Errorf("couldn't create configmap: %s", err)
+			return fmt.Errorf("couldn't create configmap: %w", err)
+		}
+		if err == nil {
+			r.childChanged(website, kindConfigMap, operationCreate, cmName)
 		}
 		log.Info("new configmap created for website", "configMapName", cmName)
 		return nil
@@ -166,43 +361,81 @@ func (r *WebsiteController) ensureConfigMap(ctx context.Context, req ctrl.Reques
 	if r.ensureConfigMapSpec(confMap, website) {
 		_, err = cmClient.Update(ctx, confMap, metav1.UpdateOptions{})
 		if err != nil {
-			return fmt.Errorf("couldn't update ConfigMap: %s", err)
+			return fmt.Errorf("couldn't update ConfigMap: %w", err)
 		}
+		r.childChanged(website, kindConfigMap, operationUpdate, cmName)
 		log.Info("website contents updated via configmap")
 	}
 	return nil
 }
 
 func (r *WebsiteController) ensureConfigMapSpec(confMap *corev1.ConfigMap, website *webv1.WebSite) bool {
-	needsUpdate := confMap.Data["index.html"] != website.Spec.HtmlContent
-	confMap.Data["index.html"] = website.Spec.HtmlContent
+	data := configMapData(website.Spec)
+	binaryData := configMapBinaryData(website.Spec)
+
+	needsUpdate := !equality.Semantic.DeepEqual(confMap.Data, data) || !equality.Semantic.DeepEqual(confMap.BinaryData, binaryData)
+	confMap.Data = data
+	confMap.BinaryData = binaryData
 	return needsUpdate
 }
 
-func (r *WebsiteController) ensureService(ctx context.Context, req ctrl.Request) error {
+func (r *WebsiteController) ensureService(ctx context.Context, req ctrl.Request, website *webv1.WebSite) error {
 	siteName := r.siteName(req)
 	log := log.FromContext(ctx)
 
 	svcClient := r.kubeClient.CoreV1().Services(req.Namespace)
 
 	serviceObjectName := ServiceObjectName(siteName)
-	_, err := svcClient.Get(ctx, serviceObjectName, metav1.GetOptions{})
+	service, err := svcClient.Get(ctx, serviceObjectName, metav1.GetOptions{})
 	if err != nil && errors.IsNotFound(err) {
 		svcObject := CreateServiceObject(siteName)
 		svcObject, err = svcClient.Create(ctx, svcObject, metav1.CreateOptions{})
 		if err != nil {
-			return fmt.Errorf("couldn't create service: %s", err)
+			return fmt.Errorf("couldn't create service: %w", err)
 		}
+		r.childChanged(website, kindService, operationCreate, serviceObjectName)
 
 		log.Info("new service created for website", "serviceObjectName", serviceObjectName)
 		return nil
 	}
 
-	// service does not need update because website spec does not influence service
+	if err != nil {
+		return fmt.Errorf("couldn't get service: %w", err)
+	}
+
+	if r.ensureServiceSpec(service, siteName) {
+		_, err = svcClient.Update(ctx, service, metav1.UpdateOptions{})
+		if err != nil {
+			return fmt.Errorf("couldn't update service: %w", err)
+		}
+		r.childChanged(website, kindService, operationUpdate, serviceObjectName)
 
+		log.Info("service spec updated")
+	}
 	return nil
 }
 
+// ensureServiceSpec reverts changes of the selector, type and ports of a service. The website spec
+// doesn't influence the service, so it only changes if edited by hand.
+func (r *WebsiteController) ensureServiceSpec(service *corev1.Service, siteName string) bool {
+	desired := CreateServiceObject(siteName)
+
+	// node ports are allocated by the API server
+	portsMatch := slices.EqualFunc(service.Spec.Ports, desired.Spec.Ports, func(a, b corev1.ServicePort) bool {
+		return a.Name == b.Name && a.Protocol == b.Protocol && a.Port == b.Port && a.TargetPort == b.TargetPort
+	})
+	needsUpdate := !portsMatch ||
+		service.Spec.Type != desired.Spec.Type ||
+		!equality.Semantic.DeepEqual(service.Spec.Selector, desired.Spec.Selector)
+
+	if !portsMatch {
+		service.Spec.Ports = desired.Spec.Ports
+	}
+	service.Spec.Type = desired.Spec.Type
+	service.Spec.Selector = desired.Spec.Selector
+	return needsUpdate
+}
+
 func (r *WebsiteController) ensureIngress(ctx context.Context, req ctrl.Request, website *webv1.WebSite) error {
 	siteName := r.siteName(req)
 	log := log.FromContext(ctx)
@@ -212,35 +445,92 @@ func (r *WebsiteController) ensureIngress(ctx context.Context, req ctrl.Request,
 	ingressObjectName := IngressObjectName(siteName)
 	ingress, err := ingressClient.Get(ctx, ingressObjectName, metav1.GetOptions{})
 	if err != nil && errors.IsNotFound(err) {
-		ingressObject := CreateIngressObj(siteName, website.Spec)
+		ingressObject := CreateIngressObj(siteName, website.Spec, !r.exposure.nginxEnforcesAccess())
 		ingressObject, err = ingressClient.Create(ctx, ingressObject, metav1.CreateOptions{})
 		if err != nil {
-			return fmt.Errorf("couldn't create ingress: %s", err)
+			return fmt.Errorf("couldn't create ingress: %w", err)
 		}
+		r.childChanged(website, kindIngress, operationCreate, ingressObjectName)
 
 		log.Info("new ingress created for website, exposed now via hostname", "hostname", website.Spec.Hostname, "ingressObjectName", ingressObjectName)
 
 		return nil
 	}
 
-	if r.ensureIngressSpec(ingress, website) {
+	if err != nil {
+		return fmt.Errorf("couldn't get ingress: %w", err)
+	}
+
+	if r.ensureIngressSpec(ingress, siteName, website) {
 		_, err = ingressClient.Update(ctx, ingress, metav1.UpdateOptions{})
 		if err != nil {
-			return fmt.Errorf("couldn't update ingress spec: %s", err)
+			return fmt.Errorf("couldn't update ingress spec: %w", err)
 		}
+		r.childChanged(website, kindIngress, operationUpdate, ingressObjectName)
 
 		log.Info("ingress spec updated")
 	}
 	return nil
 }
 
-func (r *WebsiteController) ensureIngressSpec(ingress *netv1.Ingress, website *webv1.WebSite) bool {
-	needsUpdate := ingress.Spec.Rules == nil || (ingress.Spec.Rules[0].Host != website.Spec.Hostname)
+// ensureIngressSpec reverts changes of the fields set by the controller, e.g. the hostname, and of
+// the annotations enforcing access restrictions.
+func (r *WebsiteController) ensureIngressSpec(ingress *netv1.Ingress, siteName string, website *webv1.WebSite) bool {
+	desired := CreateIngressObj(siteName, website.Spec, !r.exposure.nginxEnforcesAccess())
 
-	if ingress.Spec.Rules == nil || len(ingress.Spec.Rules) == 0 {
-		ingress.Spec.Rules = make([]netv1.IngressRule, 1)
+	needsUpdate := ensureIngressAnnotations(ingress, desired.Annotations)
+	if !equality.Semantic.DeepDerivative(desired.Spec, ingress.Spec) {
+		ingress.Spec = desired.Spec
+		needsUpdate = true
 	}
-
-	ingress.Spec.Rules[0].Host = website.Spec.Hostname
 	return needsUpdate
 }
+
+// ensureRevision records the current spec as a new revision if it differs from the latest one
+// and prunes revisions exceeding the history limit.
+func (r *WebsiteController) ensureRevision(ctx context.Context, req ctrl.Request, website *webv1.WebSite) error {
+	log := log.FromContext(ctx)
+
+	cmClient := r.kubeClient.CoreV1().ConfigMaps(req.Namespace)
+
+	revisions, err := cmClient.List(ctx, metav1.ListOptions{LabelSelector: revision.Selector(req.Name)})
+	if err != nil {
+		return fmt.Errorf("couldn't list revisions: %w", err)
+	}
+	revision.SortNewestFirst(revisions.Items)
+
+	limit := revision.HistoryLimit(website.Spec)
+
+	var latest int64
+	if len(revisions.Items) > 0 {
+		latest = revision.Number(&revisions.Items[0])
+	}
+
+	if limit > 0 && (latest == 0 || revisions.Items[0].Annotations[revision.AnnotationSpecHash] != revision.Hash(website.Spec)) {
+		cmObj, err := revision.NewConfigMap(website, latest+1)
+		if err != nil {
+			return fmt.Errorf("couldn't build revision: %w", err)
+		}
+		_, err = cmClient.Create(ctx, cmObj, metav1.CreateOptions{})
+		if err != nil && !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("couldn't create revision: %w", err)
+		}
+		if err == nil {
+			r.childChanged(website, kindRevision, operationCreate, cmObj.Name)
+		}
+		log.Info("new revision recorded for website", "revision", latest+1)
+
+		revisions.Items = append([]corev1.ConfigMap{*cmObj}, revisions.Items...)
+	}
+
+	for i := limit; i < len(revisions.Items); i++ {
+		err = cmClient.Delete(ctx, revisions.Items[i].Name, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("couldn't prune revision: %w", err)
+		}
+		r.childChanged(website, kindRevision, operationDelete, revisions.Items[i].Name)
+		log.Info("pruned revision of website", "revision", revision.Number(&revisions.Items[i]))
+	}
+
+	return nil
+}
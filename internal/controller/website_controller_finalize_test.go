@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	webv1 "website-operator/api/v1"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReconcileMissingWebsite(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := webv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	// the website was relabeled into another shard, so it's gone from the cache only
+	relabeled := site("default", "blog")
+	relabeled.Labels = map[string]string{"tier": "internal"}
+
+	tests := []struct {
+		name         string
+		apiObjects   []client.Object
+		wantFinalize bool
+	}{
+		{name: "out of scope", apiObjects: []client.Object{relabeled}},
+		{name: "deleted", wantFinalize: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := webv1.WebSiteSpec{HtmlContent: "blog", Hostname: "blog.example.com", NginxImage: "docker.io/nginx:1.28"}
+			deployment := CreateDeploymentObject("website-blog", spec, "", false)
+			configMap := CreateConfigMapObject("website-blog", spec)
+			service := CreateServiceObject("website-blog")
+			for _, obj := range []metav1.Object{deployment, configMap, service} {
+				obj.SetNamespace("default")
+			}
+			kubeClient := k8sfake.NewClientset(deployment, configMap, service)
+
+			r := &WebsiteController{
+				Client:     fake.NewClientBuilder().WithScheme(scheme).Build(),
+				apiReader:  fake.NewClientBuilder().WithScheme(scheme).WithObjects(tt.apiObjects...).Build(),
+				kubeClient: kubeClient,
+				metrics:    NewMetrics(nil),
+			}
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "blog"}}
+			if _, err := r.Reconcile(context.Background(), req); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := kubeClient.AppsV1().Deployments("default").Get(context.Background(), deployment.Name, metav1.GetOptions{})
+			if finalized := apierrors.IsNotFound(err); finalized != tt.wantFinalize {
+				t.Errorf("expected deployment to be finalized: %t, got error %v", tt.wantFinalize, err)
+			}
+			_, err = kubeClient.CoreV1().Services("default").Get(context.Background(), service.Name, metav1.GetOptions{})
+			if finalized := apierrors.IsNotFound(err); finalized != tt.wantFinalize {
+				t.Errorf("expected service to be finalized: %t, got error %v", tt.wantFinalize, err)
+			}
+		})
+	}
+}
+
+func TestDeleteWebsiteSuspendedFromCreation(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := webv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	suspended := site("default", "blog")
+	suspended.Spec = webv1.WebSiteSpec{HtmlContent: "blog", NginxImage: "docker.io/nginx:1.28", Suspend: true}
+	websites := fake.NewClientBuilder().WithScheme(scheme).WithObjects(suspended).WithStatusSubresource(suspended).Build()
+	kubeClient := k8sfake.NewClientset()
+
+	r := &WebsiteController{
+		Client:     websites,
+		apiReader:  websites,
+		kubeClient: kubeClient,
+		metrics:    NewMetrics(nil),
+		recorder:   record.NewFakeRecorder(10),
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "blog"}}
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if deployments, _ := kubeClient.AppsV1().Deployments("default").List(context.Background(), metav1.ListOptions{}); len(deployments.Items) != 0 {
+		t.Fatalf("expected a suspended website to have no deployment, got %d", len(deployments.Items))
+	}
+
+	if err := websites.Delete(context.Background(), suspended); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Errorf("expected a website without objects to be finalized, got %v", err)
+	}
+}
@@ -1,13 +1,21 @@
 package controller
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"slices"
+	"strings"
 	webv1 "website-operator/api/v1"
 	"website-operator/internal"
+	"website-operator/internal/access"
+	"website-operator/internal/nginxconf"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	netv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
@@ -18,8 +26,29 @@ const (
 	ingressClassName = "nginx"
 
 	websiteReplica = 1
+
+	contentVolumeName = "contents"
+	indexFile         = "index.html"
+
+	nginxConfigVolumeName = "nginx-config"
+	nginxConfigDir        = "/etc/nginx/conf.d"
+	// nginxConfigCheckName is the init container checking the nginx configuration, so pods
+	// with an invalid configuration don't replace the running ones
+	nginxConfigCheckName = "nginx-config-check"
+	// nginxConfigLabel marks the nginx ConfigMaps of a website, so those of previous
+	// configurations are found once they're no longer mounted
+	nginxConfigLabel = "anexia.com/nginx-config"
+
+	htpasswdVolumeName = "htpasswd"
+	htpasswdDir        = "/etc/nginx/auth"
+
+	maintenanceVolumeName = "maintenance"
+	maintenanceDir        = "/usr/share/nginx/maintenance"
+	maintenanceFile       = "maintenance.html"
 )
 
+var configMapKeyRegexp = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+
 func IngressObjectName(siteName string) string {
 	return siteName + "-ingress"
 }
@@ -36,10 +65,73 @@ func ConfigMapObjectName(siteName string) string {
 	return siteName + "-cm"
 }
 
-func CreateIngressObj(name string, spec webv1.WebSiteSpec) *netv1.Ingress {
+// NginxConfigMapObjectName returns the name of the ConfigMap holding nginxConfig. The name changes
+// along with the configuration, which rolls out the pods since nginx doesn't reload it, and pods
+// mounting a previous configuration keep the one checked before they started.
+func NginxConfigMapObjectName(siteName, nginxConfig string) string {
+	sum := sha256.Sum256([]byte(nginxConfig))
+	return siteName + "-nginx-" + hex.EncodeToString(sum[:5])
+}
+
+// nginxConfigSelector selects all nginx ConfigMaps of a website.
+func nginxConfigSelector(siteName string) string {
+	return labels.SelectorFromSet(labels.Set{nginxConfigLabel: internal.LabelValue(siteName)}).String()
+}
+
+func HtpasswdSecretObjectName(siteName string) string {
+	return siteName + "-htpasswd"
+}
+
+func MaintenanceConfigMapObjectName(siteName string) string {
+	return siteName + "-maintenance"
+}
+
+// ContentFileKey returns the ConfigMap key a content file is stored under. ConfigMap keys
+// can't contain slashes, so nested paths are stored under a hash of the path and mapped
+// back to their location by the items of the content volume.
+func ContentFileKey(path string) string {
+	if !strings.Contains(path, "/") && configMapKeyRegexp.MatchString(path) {
+		return path
+	}
+	sum := sha256.Sum256([]byte(path))
+	return "file-" + hex.EncodeToString(sum[:8])
+}
+
+// contentVolumeItems maps the ConfigMap keys to the paths of the content files. No items
+// are returned for a site without files, so every key is mounted under its own name.
+func contentVolumeItems(spec webv1.WebSiteSpec) []corev1.KeyToPath {
+	if len(spec.Files) == 0 {
+		return nil
+	}
+
+	paths := make([]string, 0, len(spec.Files)+1)
+	for path := range spec.Files {
+		paths = append(paths, path)
+	}
+	if _, ok := spec.Files[indexFile]; !ok {
+		paths = append(paths, indexFile)
+	}
+	slices.Sort(paths)
+
+	items := make([]corev1.KeyToPath, 0, len(paths))
+	for _, path := range paths {
+		items = append(items, corev1.KeyToPath{Key: ContentFileKey(path), Path: path})
+	}
+	return items
+}
+
+// CreateIngressObj creates the ingress of a website, with the annotations enforcing its access
+// restrictions by ingress-nginx if enforceAccess.
+func CreateIngressObj(name string, spec webv1.WebSiteSpec, enforceAccess bool) *netv1.Ingress {
+	var annotations map[string]string
+	if enforceAccess {
+		annotations = ingressAccessAnnotations(name, spec)
+	}
+
 	return &netv1.Ingress{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: IngressObjectName(name),
+			Name:        IngressObjectName(name),
+			Annotations: annotations,
 		},
 		Spec: netv1.IngressSpec{
 			IngressClassName: internal.Ptr(ingressClassName),
@@ -95,8 +187,11 @@ func CreateServiceObject(name string) *corev1.Service {
 	}
 }
 
-func CreateDeploymentObject(name string, spec webv1.WebSiteSpec) *appsv1.Deployment {
-	return &appsv1.Deployment{
+// CreateDeploymentObject returns the deployment of a website. Unless nginxConfig is empty, it's
+// mounted as the nginx configuration and checked before the pods start. The htpasswd Secret is
+// mounted if htpasswd, for nginx enforcing basic auth.
+func CreateDeploymentObject(name string, spec webv1.WebSiteSpec, nginxConfig string, htpasswd bool) *appsv1.Deployment {
+	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: DeploymentObjectName(name),
 		},
@@ -128,7 +223,7 @@ func CreateDeploymentObject(name string, spec webv1.WebSiteSpec) *appsv1.Deploym
 							},
 							VolumeMounts: []corev1.VolumeMount{
 								{
-									Name:      "contents",
+									Name:      contentVolumeName,
 									MountPath: "/usr/share/nginx/html",
 								},
 							},
@@ -136,12 +231,13 @@ func CreateDeploymentObject(name string, spec webv1.WebSiteSpec) *appsv1.Deploym
 					},
 					Volumes: []corev1.Volume{
 						{
-							Name: "contents",
+							Name: contentVolumeName,
 							VolumeSource: corev1.VolumeSource{
 								ConfigMap: &corev1.ConfigMapVolumeSource{
 									LocalObjectReference: corev1.LocalObjectReference{
 										Name: ConfigMapObjectName(name),
 									},
+									Items: contentVolumeItems(spec),
 								},
 							},
 						},
@@ -150,6 +246,98 @@ func CreateDeploymentObject(name string, spec webv1.WebSiteSpec) *appsv1.Deploym
 			},
 		},
 	}
+
+	if nginxConfig != "" {
+		mountNginxConfig(deployment, name, spec.NginxImage, nginxConfig)
+	}
+	if htpasswd {
+		mountHtpasswd(deployment, name)
+	}
+	if maintenanceEnabled(spec) {
+		mountMaintenancePage(deployment, name)
+	}
+	return deployment
+}
+
+func mountNginxConfig(deployment *appsv1.Deployment, name, image, nginxConfig string) {
+	template := &deployment.Spec.Template
+	template.Spec.Volumes = append(template.Spec.Volumes, corev1.Volume{
+		Name: nginxConfigVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: NginxConfigMapObjectName(name, nginxConfig)},
+			},
+		},
+	})
+	mount := corev1.VolumeMount{Name: nginxConfigVolumeName, MountPath: nginxConfigDir, ReadOnly: true}
+	template.Spec.Containers[0].VolumeMounts = append(template.Spec.Containers[0].VolumeMounts, mount)
+
+	template.Spec.InitContainers = []corev1.Container{
+		{
+			Name:         nginxConfigCheckName,
+			Image:        image,
+			Command:      []string{"nginx", "-t"},
+			VolumeMounts: []corev1.VolumeMount{mount},
+			// the output of nginx -t explains why the configuration is rejected
+			TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+		},
+	}
+}
+
+// mountHtpasswd mounts the htpasswd Secret for nginx. nginx reads it for every request, so
+// password changes don't need a rollout.
+func mountHtpasswd(deployment *appsv1.Deployment, name string) {
+	template := &deployment.Spec.Template
+	template.Spec.Volumes = append(template.Spec.Volumes, corev1.Volume{
+		Name: htpasswdVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{SecretName: HtpasswdSecretObjectName(name)},
+		},
+	})
+	template.Spec.Containers[0].VolumeMounts = append(template.Spec.Containers[0].VolumeMounts,
+		corev1.VolumeMount{Name: htpasswdVolumeName, MountPath: htpasswdDir, ReadOnly: true})
+}
+
+// mountMaintenancePage mounts the maintenance page next to the content, which stays mounted.
+func mountMaintenancePage(deployment *appsv1.Deployment, name string) {
+	template := &deployment.Spec.Template
+	template.Spec.Volumes = append(template.Spec.Volumes, corev1.Volume{
+		Name: maintenanceVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: MaintenanceConfigMapObjectName(name)},
+			},
+		},
+	})
+	template.Spec.Containers[0].VolumeMounts = append(template.Spec.Containers[0].VolumeMounts,
+		corev1.VolumeMount{Name: maintenanceVolumeName, MountPath: maintenanceDir, ReadOnly: true})
+}
+
+func CreateMaintenanceConfigMapObject(name, page string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: MaintenanceConfigMapObjectName(name),
+		},
+		Data: map[string]string{maintenanceFile: page},
+	}
+}
+
+func CreateHtpasswdSecretObject(name string, htpasswd []byte) *corev1.Secret {
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: HtpasswdSecretObjectName(name),
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{access.UserFileKey: htpasswd},
+	}
 }
 
 func CreateConfigMapObject(name string, spec webv1.WebSiteSpec) *corev1.ConfigMap {
@@ -161,8 +349,43 @@ func CreateConfigMapObject(name string, spec webv1.WebSiteSpec) *corev1.ConfigMa
 		ObjectMeta: metav1.ObjectMeta{
 			Name: ConfigMapObjectName(name),
 		},
-		Data: map[string]string{
-			"index.html": spec.HtmlContent,
+		Data:       configMapData(spec),
+		BinaryData: configMapBinaryData(spec),
+	}
+}
+
+func CreateNginxConfigMapObject(name, nginxConfig string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   NginxConfigMapObjectName(name, nginxConfig),
+			Labels: map[string]string{nginxConfigLabel: internal.LabelValue(name)},
 		},
+		Immutable: internal.Ptr(true),
+		Data:      map[string]string{nginxconf.File: nginxConfig},
+	}
+}
+
+func configMapData(spec webv1.WebSiteSpec) map[string]string {
+	if _, ok := spec.Files[indexFile]; ok {
+		return map[string]string{}
+	}
+	return map[string]string{
+		indexFile: spec.HtmlContent,
+	}
+}
+
+func configMapBinaryData(spec webv1.WebSiteSpec) map[string][]byte {
+	if len(spec.Files) == 0 {
+		return nil
+	}
+
+	data := make(map[string][]byte, len(spec.Files))
+	for path, content := range spec.Files {
+		data[ContentFileKey(path)] = content
 	}
+	return data
 }
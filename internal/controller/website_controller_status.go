@@ -0,0 +1,190 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+	webv1 "website-operator/api/v1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// statusPollInterval is how often the deployment of a website is checked while it's rolled
+// out or unavailable, since the controller doesn't watch deployments.
+const statusPollInterval = 5 * time.Second
+
+// updateStatus reports the state of the deployment in the status of website, whether its
+// hostname is claimed by conflict, and the digest of its image. Once a spec change is rolled out
+// and available, the time it took is observed.
+func (r *WebsiteController) updateStatus(ctx context.Context, website *webv1.WebSite, deployment *appsv1.Deployment, conflict *webv1.WebSite, digest string) (ctrl.Result, error) {
+	status := website.Status.DeepCopy()
+
+	var progressingSince time.Time
+	if progressing := meta.FindStatusCondition(status.Conditions, webv1.ConditionProgressing); progressing != nil && progressing.Status == metav1.ConditionTrue {
+		progressingSince = progressing.LastTransitionTime.Time
+	}
+
+	specChanged := status.ObservedGeneration != website.Generation
+	rolledOut := deploymentRolledOut(deployment)
+	available := deploymentAvailable(deployment)
+	progressing := specChanged || !rolledOut
+
+	// pods failing to start don't replace the available ones, which keep the website available
+	var failureReason, failure string
+	if !available || progressing {
+		var err error
+		if failureReason, failure, err = r.podFailure(ctx, deployment); err != nil {
+			log.FromContext(ctx).Error(err, "couldn't check pods of website for failures")
+		}
+		if failureReason != "" {
+			r.recorder.Event(website, corev1.EventTypeWarning, failureReason, failure)
+		}
+	}
+
+	status.ObservedGeneration = website.Generation
+	status.ImageDigest = digest
+	switch {
+	case available:
+		setCondition(status, website.Generation, webv1.ConditionAvailable, true, "ReplicasAvailable",
+			"all replicas of the website are available")
+	case failureReason != "":
+		setCondition(status, website.Generation, webv1.ConditionAvailable, false, failureReason, failure)
+	default:
+		setCondition(status, website.Generation, webv1.ConditionAvailable, false, "ReplicasUnavailable",
+			fmt.Sprintf("%d of %d replicas are available", deployment.Status.AvailableReplicas, deploymentReplicas(deployment)))
+	}
+	switch {
+	case progressing && failureReason != "":
+		setCondition(status, website.Generation, webv1.ConditionProgressing, true, failureReason, failure)
+	case progressing:
+		setCondition(status, website.Generation, webv1.ConditionProgressing, true, "RollingOut",
+			"the spec of the website is being rolled out")
+	default:
+		setCondition(status, website.Generation, webv1.ConditionProgressing, false, "RolledOut",
+			"the spec of the website is rolled out")
+	}
+	setCondition(status, website.Generation, webv1.ConditionDegraded, false, "ReconcileSucceeded", "")
+	setCondition(status, website.Generation, webv1.ConditionPaused, false, "Reconciling", "")
+
+	if conflict != nil {
+		message := fmt.Sprintf("hostname %s is claimed by the older website %s/%s, the website has no ingress",
+			website.Spec.Hostname, conflict.Namespace, conflict.Name)
+		if !meta.IsStatusConditionTrue(status.Conditions, webv1.ConditionHostnameConflict) {
+			r.recorder.Event(website, corev1.EventTypeWarning, reasonHostnameConflict, message)
+		}
+		setCondition(status, website.Generation, webv1.ConditionHostnameConflict, true, "HostnameClaimed", message)
+	} else {
+		setCondition(status, website.Generation, webv1.ConditionHostnameConflict, false, "HostnameAvailable", "")
+	}
+
+	if err := r.writeStatus(ctx, website, status); err != nil {
+		r.metrics.observeError(phaseStatus, err)
+		return ctrl.Result{}, fmt.Errorf("couldn't update status: %s", err)
+	}
+
+	if !progressingSince.IsZero() && !progressing && available {
+		r.metrics.observeAvailable(website.Namespace, time.Since(progressingSince))
+		r.recorder.Eventf(website, corev1.EventTypeNormal, "RolledOut", "Generation %d is rolled out and available after %s",
+			website.Generation, time.Since(progressingSince).Round(time.Second))
+	}
+
+	if progressing || !available {
+		return ctrl.Result{RequeueAfter: statusPollInterval}, nil
+	}
+	if conflict != nil {
+		return ctrl.Result{RequeueAfter: hostnameRecheckInterval}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// reconcileFailed reports a failed phase in the status of website and returns err, so the
+// reconciliation is retried.
+func (r *WebsiteController) reconcileFailed(ctx context.Context, website *webv1.WebSite, phase string, err error) (ctrl.Result, error) {
+	r.metrics.observeError(phase, err)
+
+	reason := failureReason(phase, err)
+	message := fmt.Sprintf("%s failed: %s", phase, err)
+	r.recorder.Event(website, corev1.EventTypeWarning, reason, message)
+
+	status := website.Status.DeepCopy()
+	setCondition(status, website.Generation, webv1.ConditionDegraded, true, reason, message)
+	setCondition(status, website.Generation, webv1.ConditionPaused, false, "Reconciling", "")
+
+	if statusErr := r.writeStatus(ctx, website, status); statusErr != nil {
+		r.metrics.observeError(phaseStatus, statusErr)
+		log.FromContext(ctx).Error(statusErr, "couldn't report failed reconciliation in status")
+	}
+	return ctrl.Result{}, err
+}
+
+// reconcilePaused reports in the status of website that its reconciliation is paused. The
+// objects of the website are left as they are and no reconciliation is scheduled.
+func (r *WebsiteController) reconcilePaused(ctx context.Context, website *webv1.WebSite) (ctrl.Result, error) {
+	reason, message := "Suspended", "the website is suspended by its spec"
+	if !website.Spec.Suspend {
+		reason = "AnnotationPaused"
+		message = fmt.Sprintf("the reconciliation is paused by the annotation %s", webv1.AnnotationReconcile)
+	}
+
+	if !meta.IsStatusConditionTrue(website.Status.Conditions, webv1.ConditionPaused) {
+		log.FromContext(ctx).Info("reconciliation of website is paused")
+		r.recorder.Event(website, corev1.EventTypeNormal, "Paused", "Reconciliation paused, "+message)
+	}
+
+	status := website.Status.DeepCopy()
+	setCondition(status, website.Generation, webv1.ConditionPaused, true, reason, message)
+	if err := r.writeStatus(ctx, website, status); err != nil {
+		r.metrics.observeError(phaseStatus, err)
+		return ctrl.Result{}, fmt.Errorf("couldn't update status: %w", err)
+	}
+	return ctrl.Result{}, nil
+}
+
+// writeStatus updates the status subresource of website, unless status is unchanged.
+func (r *WebsiteController) writeStatus(ctx context.Context, website *webv1.WebSite, status *webv1.WebSiteStatus) error {
+	if equality.Semantic.DeepEqual(website.Status, *status) {
+		return nil
+	}
+	website.Status = *status
+	return r.Client.Status().Update(ctx, website)
+}
+
+func setCondition(status *webv1.WebSiteStatus, generation int64, conditionType string, value bool, reason, message string) {
+	conditionStatus := metav1.ConditionFalse
+	if value {
+		conditionStatus = metav1.ConditionTrue
+	}
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             conditionStatus,
+		ObservedGeneration: generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+func deploymentReplicas(deployment *appsv1.Deployment) int32 {
+	if deployment.Spec.Replicas == nil {
+		return 1
+	}
+	return *deployment.Spec.Replicas
+}
+
+// deploymentRolledOut reports whether all replicas of the deployment run its current template.
+func deploymentRolledOut(deployment *appsv1.Deployment) bool {
+	replicas := deploymentReplicas(deployment)
+	return deployment.Status.ObservedGeneration >= deployment.Generation &&
+		deployment.Status.UpdatedReplicas == replicas &&
+		deployment.Status.Replicas == replicas &&
+		deployment.Status.AvailableReplicas == replicas
+}
+
+func deploymentAvailable(deployment *appsv1.Deployment) bool {
+	return deployment.Status.AvailableReplicas >= deploymentReplicas(deployment)
+}
@@ -7,13 +7,17 @@ import (
 	"time"
 	webv1 "website-operator/api/v1"
 	"website-operator/internal"
+	"website-operator/internal/imagepolicy"
+	"website-operator/internal/revision"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	. "github.com/onsi/gomega/gstruct"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -32,6 +36,7 @@ var (
 	scheme    = runtime.NewScheme()
 	ctx       context.Context
 	cancel    context.CancelFunc
+	metrics   *Metrics
 )
 
 func TestWebsiteControllerSuite(t *testing.T) {
@@ -77,11 +82,9 @@ var _ = BeforeSuite(func() {
 	Expect(err).ToNot(HaveOccurred())
 
 	// register controller
-	reconciler := NewWebsiteController(k8sManager, clientset)
-	err = ctrl.NewControllerManagedBy(k8sManager).
-		For(&webv1.WebSite{}).
-		Complete(reconciler)
-	Expect(err).ToNot(HaveOccurred())
+	metrics = NewMetrics(k8sManager.GetCache())
+	reconciler := NewWebsiteController(k8sManager, clientset, metrics, imagepolicy.Default(), nil, Exposure{})
+	Expect(reconciler.SetupWithManager(k8sManager)).To(Succeed())
 
 	go func() {
 		defer GinkgoRecover()
@@ -183,4 +186,393 @@ var _ = Describe("WebsiteController", func() {
 		}, 10*time.Second, 500*time.Millisecond).Should(BeTrue())
 		// TODO assert ingress object
 	})
+
+	It("should record a revision for every content change", func() {
+		By("creating a website CR")
+		website := &webv1.WebSite{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "revision-site",
+				Namespace: "default",
+			},
+			Spec: webv1.WebSiteSpec{
+				HtmlContent:          "first",
+				Hostname:             "revision.anexia.com",
+				NginxImage:           "docker.io/nginx:1.28",
+				RevisionHistoryLimit: internal.Ptr(int32(2)),
+			},
+		}
+		Expect(k8sClient.Create(ctx, website)).To(Succeed())
+
+		revisions := func() []string {
+			list := &corev1.ConfigMapList{}
+			Expect(k8sClient.List(ctx, list, client.InNamespace("default"),
+				client.MatchingLabels{revision.LabelWebsite: "revision-site"})).To(Succeed())
+			names := []string{}
+			for _, cm := range list.Items {
+				names = append(names, cm.Name)
+			}
+			return names
+		}
+		Eventually(revisions, 10*time.Second, 500*time.Millisecond).Should(ConsistOf("website-revision-site-rev-1"))
+
+		By("changing the content twice")
+		for _, change := range []struct{ content, revision string }{{"second", "website-revision-site-rev-2"}, {"third", "website-revision-site-rev-3"}} {
+			Eventually(func() error {
+				if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(website), website); err != nil {
+					return err
+				}
+				website.Spec.HtmlContent = change.content
+				return k8sClient.Update(ctx, website)
+			}, 10*time.Second, 500*time.Millisecond).Should(Succeed())
+			Eventually(revisions, 10*time.Second, 500*time.Millisecond).Should(ContainElement(change.revision))
+		}
+
+		By("pruning revisions beyond the history limit")
+		Eventually(revisions, 10*time.Second, 500*time.Millisecond).Should(ConsistOf("website-revision-site-rev-2", "website-revision-site-rev-3"))
+	})
+
+	It("should report the rollout of a website in its status", func() {
+		By("creating a website CR")
+		website := &webv1.WebSite{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "status-site",
+				Namespace: "default",
+			},
+			Spec: webv1.WebSiteSpec{
+				HtmlContent: "status",
+				Hostname:    "status.anexia.com",
+				NginxImage:  "docker.io/nginx:1.28",
+			},
+		}
+		Expect(k8sClient.Create(ctx, website)).To(Succeed())
+
+		By("waiting for the status to be reported")
+		// envtest runs no deployment controller, so the deployment is never rolled out
+		Eventually(func(g Gomega) {
+			g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(website), website)).To(Succeed())
+			g.Expect(website.Status.ObservedGeneration).To(Equal(website.Generation))
+			g.Expect(website.Status.Conditions).To(ContainElements(
+				MatchFields(IgnoreExtras, Fields{"Type": Equal(webv1.ConditionAvailable), "Status": Equal(metav1.ConditionFalse)}),
+				MatchFields(IgnoreExtras, Fields{"Type": Equal(webv1.ConditionProgressing), "Status": Equal(metav1.ConditionTrue)}),
+				MatchFields(IgnoreExtras, Fields{"Type": Equal(webv1.ConditionDegraded), "Status": Equal(metav1.ConditionFalse)}),
+			))
+		}, 10*time.Second, 500*time.Millisecond).Should(Succeed())
+
+		By("counting the created objects")
+		Expect(testutil.ToFloat64(metrics.childOperations.WithLabelValues(kindDeployment, operationCreate))).To(BeNumerically(">=", 1))
+		Expect(testutil.ToFloat64(metrics.childOperations.WithLabelValues(kindIngress, operationCreate))).To(BeNumerically(">=", 1))
+	})
+
+	It("should leave a paused website alone and revert changes once resumed", func() {
+		By("creating a website CR")
+		website := &webv1.WebSite{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "paused-site",
+				Namespace: "default",
+			},
+			Spec: webv1.WebSiteSpec{
+				HtmlContent: "paused",
+				Hostname:    "paused.anexia.com",
+				NginxImage:  "docker.io/nginx:1.28",
+			},
+		}
+		Expect(k8sClient.Create(ctx, website)).To(Succeed())
+
+		deploy := &appsv1.Deployment{}
+		deployKey := types.NamespacedName{Name: "website-paused-site-deploy", Namespace: "default"}
+		Eventually(func() error { return k8sClient.Get(ctx, deployKey, deploy) }, 10*time.Second, 500*time.Millisecond).Should(Succeed())
+
+		By("pausing the website")
+		Eventually(func() error {
+			if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(website), website); err != nil {
+				return err
+			}
+			website.Annotations = map[string]string{webv1.AnnotationReconcile: webv1.ReconcilePaused}
+			return k8sClient.Update(ctx, website)
+		}, 10*time.Second, 500*time.Millisecond).Should(Succeed())
+		Eventually(func(g Gomega) {
+			g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(website), website)).To(Succeed())
+			g.Expect(website.Status.Conditions).To(ContainElement(
+				MatchFields(IgnoreExtras, Fields{"Type": Equal(webv1.ConditionPaused), "Status": Equal(metav1.ConditionTrue)})))
+		}, 10*time.Second, 500*time.Millisecond).Should(Succeed())
+
+		By("editing the deployment by hand")
+		Eventually(func() error {
+			if err := k8sClient.Get(ctx, deployKey, deploy); err != nil {
+				return err
+			}
+			deploy.Spec.Template.Spec.Containers[0].Image = "docker.io/nginx:hotfix"
+			return k8sClient.Update(ctx, deploy)
+		}, 10*time.Second, 500*time.Millisecond).Should(Succeed())
+		Consistently(func(g Gomega) {
+			g.Expect(k8sClient.Get(ctx, deployKey, deploy)).To(Succeed())
+			g.Expect(deploy.Spec.Template.Spec.Containers[0].Image).To(Equal("docker.io/nginx:hotfix"))
+		}, 2*time.Second, 500*time.Millisecond).Should(Succeed())
+
+		By("resuming the website")
+		Eventually(func() error {
+			if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(website), website); err != nil {
+				return err
+			}
+			delete(website.Annotations, webv1.AnnotationReconcile)
+			return k8sClient.Update(ctx, website)
+		}, 10*time.Second, 500*time.Millisecond).Should(Succeed())
+		Eventually(func(g Gomega) {
+			g.Expect(k8sClient.Get(ctx, deployKey, deploy)).To(Succeed())
+			g.Expect(deploy.Spec.Template.Spec.Containers[0].Image).To(Equal("docker.io/nginx:1.28"))
+		}, 10*time.Second, 500*time.Millisecond).Should(Succeed())
+	})
+
+	It("should withhold the ingress of a website whose hostname is already claimed", func() {
+		By("creating two website CRs with the same hostname")
+		owner := &webv1.WebSite{
+			ObjectMeta: metav1.ObjectMeta{Name: "owner-site", Namespace: "default"},
+			Spec: webv1.WebSiteSpec{
+				HtmlContent: "owner",
+				Hostname:    "claimed.anexia.com",
+				NginxImage:  "docker.io/nginx:1.28",
+			},
+		}
+		Expect(k8sClient.Create(ctx, owner)).To(Succeed())
+
+		ownerIngressKey := types.NamespacedName{Name: "website-owner-site-ingress", Namespace: "default"}
+		Eventually(func() error {
+			return k8sClient.Get(ctx, ownerIngressKey, &networkingv1.Ingress{})
+		}, 10*time.Second, 500*time.Millisecond).Should(Succeed())
+
+		claimant := &webv1.WebSite{
+			ObjectMeta: metav1.ObjectMeta{Name: "claimant-site", Namespace: "default"},
+			Spec: webv1.WebSiteSpec{
+				HtmlContent: "claimant",
+				Hostname:    "claimed.anexia.com",
+				NginxImage:  "docker.io/nginx:1.28",
+			},
+		}
+		Expect(k8sClient.Create(ctx, claimant)).To(Succeed())
+
+		By("reporting the conflict in the status of the newer website")
+		Eventually(func(g Gomega) {
+			g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(claimant), claimant)).To(Succeed())
+			g.Expect(claimant.Status.Conditions).To(ContainElement(
+				MatchFields(IgnoreExtras, Fields{"Type": Equal(webv1.ConditionHostnameConflict), "Status": Equal(metav1.ConditionTrue)})))
+		}, 10*time.Second, 500*time.Millisecond).Should(Succeed())
+
+		claimantIngressKey := types.NamespacedName{Name: "website-claimant-site-ingress", Namespace: "default"}
+		Expect(apierrors.IsNotFound(k8sClient.Get(ctx, claimantIngressKey, &networkingv1.Ingress{}))).To(BeTrue())
+
+		By("creating the ingress once the older website is deleted")
+		Expect(k8sClient.Delete(ctx, owner)).To(Succeed())
+		Eventually(func() error {
+			return k8sClient.Get(ctx, claimantIngressKey, &networkingv1.Ingress{})
+		}, 10*time.Second, 500*time.Millisecond).Should(Succeed())
+	})
+
+	It("should mount the nginx configuration rendered from the server spec", func() {
+		By("creating a website CR with a server spec")
+		website := &webv1.WebSite{
+			ObjectMeta: metav1.ObjectMeta{Name: "spa-site", Namespace: "default"},
+			Spec: webv1.WebSiteSpec{
+				HtmlContent: "spa",
+				Hostname:    "spa.anexia.com",
+				NginxImage:  "docker.io/nginx:1.28",
+				Server:      &webv1.ServerConfig{SPA: true},
+			},
+		}
+		Expect(k8sClient.Create(ctx, website)).To(Succeed())
+
+		By("rendering the configuration into an immutable configmap")
+		var configs []corev1.ConfigMap
+		Eventually(func(g Gomega) {
+			configs = nginxConfigMaps(g, "website-spa-site")
+			g.Expect(configs).To(HaveLen(1))
+		}, 10*time.Second, 500*time.Millisecond).Should(Succeed())
+		Expect(configs[0].Immutable).To(PointTo(BeTrue()))
+		Expect(configs[0].Data).To(HaveKeyWithValue("default.conf", ContainSubstring("try_files $uri $uri/ /index.html;")))
+
+		By("checking the configuration before the website container starts")
+		deploy := &appsv1.Deployment{}
+		Eventually(func() error {
+			return k8sClient.Get(ctx, types.NamespacedName{Name: "website-spa-site-deploy", Namespace: "default"}, deploy)
+		}, 10*time.Second, 500*time.Millisecond).Should(Succeed())
+		Expect(deploy.Spec.Template.Spec.InitContainers).To(ConsistOf(
+			MatchFields(IgnoreExtras, Fields{"Name": Equal("nginx-config-check"), "Image": Equal("docker.io/nginx:1.28")})))
+		Expect(deploy.Spec.Template.Spec.Containers[0].VolumeMounts).To(ContainElement(
+			MatchFields(IgnoreExtras, Fields{"MountPath": Equal("/etc/nginx/conf.d"), "ReadOnly": BeTrue()})))
+
+		By("keeping the configmap until the deployment without it is rolled out")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(website), website)).To(Succeed())
+		website.Spec.Server = nil
+		Expect(k8sClient.Update(ctx, website)).To(Succeed())
+		Eventually(func(g Gomega) {
+			g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(deploy), deploy)).To(Succeed())
+			g.Expect(deploy.Spec.Template.Spec.InitContainers).To(BeEmpty())
+		}, 10*time.Second, 500*time.Millisecond).Should(Succeed())
+		Consistently(func(g Gomega) {
+			g.Expect(nginxConfigMaps(g, "website-spa-site")).To(HaveLen(1))
+		}, 2*time.Second, 500*time.Millisecond).Should(Succeed())
+
+		rollOut(deploy)
+		Eventually(func(g Gomega) {
+			g.Expect(nginxConfigMaps(g, "website-spa-site")).To(BeEmpty())
+		}, 20*time.Second, 500*time.Millisecond).Should(Succeed())
+	})
+
+	It("should serve a maintenance page while maintenance is enabled", func() {
+		By("creating a website CR in maintenance")
+		website := &webv1.WebSite{
+			ObjectMeta: metav1.ObjectMeta{Name: "maint-site", Namespace: "default"},
+			Spec: webv1.WebSiteSpec{
+				HtmlContent: "content",
+				Hostname:    "maint.anexia.com",
+				NginxImage:  "docker.io/nginx:1.28",
+				Maintenance: &webv1.Maintenance{Enabled: true, HTML: "back soon", RetryAfterSeconds: 300},
+			},
+		}
+		Expect(k8sClient.Create(ctx, website)).To(Succeed())
+
+		By("storing the maintenance page apart from the content")
+		page := &corev1.ConfigMap{}
+		Eventually(func() error {
+			return k8sClient.Get(ctx, types.NamespacedName{Name: "website-maint-site-maintenance", Namespace: "default"}, page)
+		}, 10*time.Second, 500*time.Millisecond).Should(Succeed())
+		Expect(page.Data).To(HaveKeyWithValue("maintenance.html", "back soon"))
+		content := &corev1.ConfigMap{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "website-maint-site-cm", Namespace: "default"}, content)).To(Succeed())
+		Expect(content.Data).To(HaveKeyWithValue("index.html", "content"))
+
+		By("answering all requests with status 503")
+		Eventually(func(g Gomega) {
+			configs := nginxConfigMaps(g, "website-maint-site")
+			g.Expect(configs).To(HaveLen(1))
+			g.Expect(configs[0].Data).To(HaveKeyWithValue("default.conf", And(ContainSubstring("try_files /.unavailable =503;"), ContainSubstring("Retry-After 300"))))
+		}, 10*time.Second, 500*time.Millisecond).Should(Succeed())
+
+		By("removing the maintenance page once the deployment without it is rolled out")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(website), website)).To(Succeed())
+		website.Spec.Maintenance.Enabled = false
+		Expect(k8sClient.Update(ctx, website)).To(Succeed())
+		deploy := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "website-maint-site-deploy", Namespace: "default"}}
+		Eventually(func(g Gomega) {
+			g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(deploy), deploy)).To(Succeed())
+			g.Expect(deploy.Spec.Template.Spec.Volumes).NotTo(ContainElement(HaveField("Name", "maintenance")))
+		}, 10*time.Second, 500*time.Millisecond).Should(Succeed())
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "website-maint-site-maintenance", Namespace: "default"}, &corev1.ConfigMap{})).To(Succeed())
+
+		rollOut(deploy)
+		Eventually(func() bool {
+			err := k8sClient.Get(ctx, types.NamespacedName{Name: "website-maint-site-maintenance", Namespace: "default"}, &corev1.ConfigMap{})
+			return apierrors.IsNotFound(err)
+		}, 20*time.Second, 500*time.Millisecond).Should(BeTrue())
+	})
+
+	It("should restrict the access to a website by ingress annotations", func() {
+		By("creating a website CR with basic auth and an allowlist")
+		users := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "private-users", Namespace: "default"},
+			Data:       map[string][]byte{"alice": []byte("wonderland")},
+		}
+		Expect(k8sClient.Create(ctx, users)).To(Succeed())
+		website := &webv1.WebSite{
+			ObjectMeta: metav1.ObjectMeta{Name: "private-site", Namespace: "default"},
+			Spec: webv1.WebSiteSpec{
+				HtmlContent: "private",
+				Hostname:    "private.anexia.com",
+				NginxImage:  "docker.io/nginx:1.28",
+				Access: &webv1.AccessConfig{
+					BasicAuth:    &webv1.BasicAuth{SecretName: "private-users"},
+					AllowedCIDRs: []string{"10.0.0.0/8"},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, website)).To(Succeed())
+
+		By("rendering the users into an htpasswd secret")
+		htpasswdKey := types.NamespacedName{Name: "website-private-site-htpasswd", Namespace: "default"}
+		htpasswd := &corev1.Secret{}
+		Eventually(func() error {
+			return k8sClient.Get(ctx, htpasswdKey, htpasswd)
+		}, 10*time.Second, 500*time.Millisecond).Should(Succeed())
+		Expect(string(htpasswd.Data["auth"])).To(HavePrefix("alice:{SSHA}"))
+
+		By("annotating the ingress for ingress-nginx")
+		ingress := &networkingv1.Ingress{}
+		Eventually(func() error {
+			return k8sClient.Get(ctx, types.NamespacedName{Name: "website-private-site-ingress", Namespace: "default"}, ingress)
+		}, 10*time.Second, 500*time.Millisecond).Should(Succeed())
+		Expect(ingress.Annotations).To(And(
+			HaveKeyWithValue("nginx.ingress.kubernetes.io/auth-type", "basic"),
+			HaveKeyWithValue("nginx.ingress.kubernetes.io/auth-secret", "website-private-site-htpasswd"),
+			HaveKeyWithValue("nginx.ingress.kubernetes.io/auth-realm", "private.anexia.com"),
+			HaveKeyWithValue("nginx.ingress.kubernetes.io/whitelist-source-range", "10.0.0.0/8")))
+
+		By("following password changes of the users secret")
+		users.Data["bob"] = []byte("builder")
+		Expect(k8sClient.Update(ctx, users)).To(Succeed())
+		Eventually(func(g Gomega) {
+			g.Expect(k8sClient.Get(ctx, htpasswdKey, htpasswd)).To(Succeed())
+			g.Expect(string(htpasswd.Data["auth"])).To(ContainSubstring("bob:{SSHA}"))
+		}, 10*time.Second, 500*time.Millisecond).Should(Succeed())
+
+		By("lifting the restrictions once the access spec is removed")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(website), website)).To(Succeed())
+		website.Spec.Access = nil
+		Expect(k8sClient.Update(ctx, website)).To(Succeed())
+		rollOut(&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "website-private-site-deploy", Namespace: "default"}})
+		Eventually(func(g Gomega) {
+			g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(ingress), ingress)).To(Succeed())
+			g.Expect(ingress.Annotations).NotTo(HaveKey("nginx.ingress.kubernetes.io/auth-type"))
+			g.Expect(apierrors.IsNotFound(k8sClient.Get(ctx, htpasswdKey, &corev1.Secret{}))).To(BeTrue())
+		}, 20*time.Second, 500*time.Millisecond).Should(Succeed())
+	})
+
+	It("should record events for the objects created for a website", func() {
+		By("creating a website CR")
+		website := &webv1.WebSite{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "event-site",
+				Namespace: "default",
+			},
+			Spec: webv1.WebSiteSpec{
+				HtmlContent: "events",
+				Hostname:    "events.anexia.com",
+				NginxImage:  "docker.io/nginx:1.28",
+			},
+		}
+		Expect(k8sClient.Create(ctx, website)).To(Succeed())
+
+		By("waiting for the events of the website")
+		Eventually(func(g Gomega) {
+			events := &corev1.EventList{}
+			g.Expect(k8sClient.List(ctx, events, client.InNamespace("default"))).To(Succeed())
+			reasons := []string{}
+			for _, event := range events.Items {
+				if event.InvolvedObject.Name == "event-site" && event.Type == corev1.EventTypeNormal {
+					reasons = append(reasons, event.Reason)
+				}
+			}
+			g.Expect(reasons).To(ContainElements("DeploymentCreated", "ConfigMapCreated", "ServiceCreated", "IngressCreated", "RevisionCreated"))
+		}, 10*time.Second, 500*time.Millisecond).Should(Succeed())
+	})
 })
+
+// nginxConfigMaps lists the nginx ConfigMaps of the website with siteName.
+func nginxConfigMaps(g Gomega, siteName string) []corev1.ConfigMap {
+	configs := &corev1.ConfigMapList{}
+	g.Expect(k8sClient.List(ctx, configs, client.InNamespace("default"),
+		client.MatchingLabels{nginxConfigLabel: siteName})).To(Succeed())
+	return configs.Items
+}
+
+// rollOut reports the current template of deploy as rolled out, envtest runs no deployment
+// controller.
+func rollOut(deploy *appsv1.Deployment) {
+	Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(deploy), deploy)).To(Succeed())
+	deploy.Status = appsv1.DeploymentStatus{
+		ObservedGeneration: deploy.Generation,
+		Replicas:           1,
+		UpdatedReplicas:    1,
+		ReadyReplicas:      1,
+		AvailableReplicas:  1,
+	}
+	Expect(k8sClient.Status().Update(ctx, deploy)).To(Succeed())
+}
@@ -1,11 +1,33 @@
 package internal
 
-import "os"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
 
 func Ptr[T any](v T) *T {
 	return &v
 }
 
+// LabelValue returns the object name as a label value. Names too long for a label value are
+// truncated and suffixed with a hash of the full name, so they stay distinct.
+func LabelValue(name string) string {
+	if len(name) <= validation.LabelValueMaxLength {
+		return name
+	}
+	sum := sha256.Sum256([]byte(name))
+	suffix := "-" + hex.EncodeToString(sum[:5])
+	// label values end with an alphanumeric character, names may contain dots and dashes
+	return strings.TrimRight(name[:validation.LabelValueMaxLength-len(suffix)], ".-") + suffix
+}
+
 func FromEnvWithDefault(key string, defaultValue string) string {
 	val := os.Getenv(key)
 	if val == "" {
@@ -13,3 +35,42 @@ func FromEnvWithDefault(key string, defaultValue string) string {
 	}
 	return val
 }
+
+func DurationFromEnvWithDefault(key string, defaultValue time.Duration) (time.Duration, error) {
+	val := os.Getenv(key)
+	if val == "" {
+		return defaultValue, nil
+	}
+
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration in %s: %w", key, err)
+	}
+	return d, nil
+}
+
+func BoolFromEnvWithDefault(key string, defaultValue bool) (bool, error) {
+	val := os.Getenv(key)
+	if val == "" {
+		return defaultValue, nil
+	}
+
+	b, err := strconv.ParseBool(val)
+	if err != nil {
+		return false, fmt.Errorf("invalid boolean in %s: %w", key, err)
+	}
+	return b, nil
+}
+
+func IntFromEnvWithDefault(key string, defaultValue int) (int, error) {
+	val := os.Getenv(key)
+	if val == "" {
+		return defaultValue, nil
+	}
+
+	i, err := strconv.Atoi(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid integer in %s: %w", key, err)
+	}
+	return i, nil
+}
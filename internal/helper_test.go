@@ -0,0 +1,20 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLabelValue(t *testing.T) {
+	long := strings.Repeat("a", 52) + "." + strings.Repeat("b", 100)
+	value := LabelValue(long)
+	if len(value) != 63 || !strings.HasPrefix(value, strings.Repeat("a", 52)+"-") {
+		t.Errorf("unexpected label value %s", value)
+	}
+	if LabelValue(long+"c") == value {
+		t.Error("expected distinct names to have distinct label values")
+	}
+	if LabelValue("website-blog") != "website-blog" {
+		t.Error("expected short names to be kept")
+	}
+}
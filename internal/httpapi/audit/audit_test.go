@@ -0,0 +1,142 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	webv1 "website-operator/api/v1"
+	"website-operator/internal/httpapi/auth"
+
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var buf bytes.Buffer
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(auth.WithUser(c.Request.Context(), &auth.User{Name: "alice"}))
+	}, Middleware(NewWriterSink(&buf), func(c *gin.Context) string { return "team-a" }))
+
+	r.GET("/api/websites", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.PUT("/api/websites/:name", func(c *gin.Context) {
+		before := &webv1.WebSite{Spec: webv1.WebSiteSpec{Hostname: "a.local"}}
+		after := &webv1.WebSite{ObjectMeta: metav1.ObjectMeta{Name: "hello"}, Spec: webv1.WebSiteSpec{Hostname: "b.local"}}
+		RecordChange(c, before, after)
+		c.Status(http.StatusAccepted)
+	})
+	r.POST("/api/websites/import", func(c *gin.Context) {
+		RecordChange(c, nil, &webv1.WebSite{ObjectMeta: metav1.ObjectMeta{Name: "blog"}, Spec: webv1.WebSiteSpec{Hostname: "blog.local"}})
+		RecordChange(c, &webv1.WebSite{ObjectMeta: metav1.ObjectMeta{Name: "shop"}, Spec: webv1.WebSiteSpec{Hostname: "a.local"}},
+			&webv1.WebSite{ObjectMeta: metav1.ObjectMeta{Name: "shop"}, Spec: webv1.WebSiteSpec{Hostname: "b.local"}})
+		c.Status(http.StatusOK)
+	})
+	r.DELETE("/api/websites/:name", func(c *gin.Context) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "websites.anexia.com \"hello\" not found"})
+	})
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/api/websites", nil),
+		httptest.NewRequest(http.MethodPut, "/api/websites/hello", nil),
+		httptest.NewRequest(http.MethodPost, "/api/websites/import", nil),
+		httptest.NewRequest(http.MethodDelete, "/api/websites/hello", nil),
+	} {
+		req.Header.Set(RequestIDHeader, "req-1")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	var events []Event
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("invalid audit line: %v", err)
+		}
+		events = append(events, e)
+	}
+
+	if len(events) != 4 {
+		t.Fatalf("expected events for the 2 mutating requests and each imported website, got %d", len(events))
+	}
+
+	update := events[0]
+	if update.User.Name != "alice" || update.RequestID != "req-1" || update.Site != "hello" || update.Namespace != "team-a" ||
+		update.Outcome != OutcomeSuccess || update.Status != http.StatusAccepted {
+		t.Errorf("unexpected update event: %+v", update)
+	}
+	if len(update.Changes) != 1 || update.Changes[0].Field != "spec.hostname" || update.Changes[0].New != "b.local" {
+		t.Errorf("unexpected changes: %+v", update.Changes)
+	}
+
+	for i, site := range []string{"blog", "shop"} {
+		if imported := events[1+i]; imported.Site != site || len(imported.Changes) == 0 {
+			t.Errorf("unexpected import event for %s: %+v", site, imported)
+		}
+	}
+
+	deletion := events[3]
+	if deletion.Outcome != OutcomeFailure || !strings.Contains(deletion.Error, "not found") {
+		t.Errorf("unexpected delete event: %+v", deletion)
+	}
+}
+
+func TestFileSinkRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	sink, err := NewFileSink(path, RotationOptions{MaxSize: 300, MaxBackups: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		sink.Write(Event{Method: http.MethodPost, Path: "/api/websites", Namespace: "default", Outcome: OutcomeSuccess})
+	}
+	sink.Close()
+
+	for _, p := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatalf("expected %s to exist: %v", p, err)
+		}
+		if info.Size() > 300 {
+			t.Errorf("%s exceeds the maximum size: %d", p, info.Size())
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected only 2 backups to be kept")
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	received := make(chan Event, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e Event
+		json.NewDecoder(r.Body).Decode(&e)
+		received <- e
+	}))
+	defer srv.Close()
+
+	sink := NewWebhookSink(srv.URL, nil)
+	sink.Write(Event{RequestID: "req-1"})
+	sink.Close()
+
+	if e := <-received; e.RequestID != "req-1" {
+		t.Fatalf("unexpected event: %+v", e)
+	}
+
+	// requests still running after a failed shutdown are audited after the sink is closed
+	sink.Write(Event{RequestID: "req-2"})
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(received) > 0 {
+		t.Errorf("expected events written after close to be dropped")
+	}
+}
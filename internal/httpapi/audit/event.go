@@ -0,0 +1,154 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+	webv1 "website-operator/api/v1"
+	"website-operator/internal/httpapi/auth"
+	"website-operator/internal/specdiff"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+
+	RequestIDHeader = "X-Request-ID"
+
+	changesContextKey = "audit.changes"
+
+	// maxCapturedErrorBody limits how much of a failed response is kept to extract its error.
+	maxCapturedErrorBody = 1024
+)
+
+// Event is an audit record of a mutating API call, written as one JSON line.
+type Event struct {
+	Time      time.Time         `json:"time"`
+	RequestID string            `json:"requestID,omitempty"`
+	User      *auth.User        `json:"user,omitempty"`
+	SourceIP  string            `json:"sourceIP"`
+	Method    string            `json:"method"`
+	Path      string            `json:"path"`
+	Site      string            `json:"site,omitempty"`
+	Namespace string            `json:"namespace"`
+	Status    int               `json:"status"`
+	Outcome   string            `json:"outcome"`
+	DryRun    bool              `json:"dryRun,omitempty"`
+	Error     string            `json:"error,omitempty"`
+	Changes   []specdiff.Change `json:"changes,omitempty"`
+}
+
+type change struct {
+	before *webv1.WebSite
+	after  *webv1.WebSite
+}
+
+// RecordChange attaches the state of a website before and after a call to its audit event.
+// before is nil for creations, after is nil for deletions.
+func RecordChange(c *gin.Context, before, after *webv1.WebSite) {
+	var changes []change
+	if v, ok := c.Get(changesContextKey); ok {
+		changes = v.([]change)
+	}
+	c.Set(changesContextKey, append(changes, change{before: before, after: after}))
+}
+
+// Middleware writes an audit event for every mutating request to sink. It must run after
+// authentication to record the caller. namespace returns the namespace a request targets.
+// Requests changing several websites, like imports, are written as one event per website.
+func Middleware(sink Sink, namespace func(c *gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !isMutating(c.Request.Method) {
+			c.Next()
+			return
+		}
+
+		writer := &errorCapturingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		event := Event{
+			Time:      time.Now().UTC(),
+			RequestID: c.GetHeader(RequestIDHeader),
+			SourceIP:  c.ClientIP(),
+			Method:    c.Request.Method,
+			Path:      c.Request.URL.Path,
+			Site:      c.Param("name"),
+			Namespace: namespace(c),
+			Status:    writer.Status(),
+			Outcome:   OutcomeSuccess,
+			DryRun:    c.Query("dryRun") == "true",
+		}
+		if user, ok := auth.UserFromContext(c.Request.Context()); ok {
+			event.User = user
+		}
+
+		if event.Status >= http.StatusBadRequest {
+			event.Outcome = OutcomeFailure
+			event.Error = writer.errorMessage()
+		}
+
+		v, ok := c.Get(changesContextKey)
+		if !ok {
+			sink.Write(event)
+			return
+		}
+		for _, ch := range v.([]change) {
+			changeEvent := event
+			var before, after *webv1.WebSiteSpec
+			if ch.before != nil {
+				before = &ch.before.Spec
+				if changeEvent.Site == "" {
+					changeEvent.Site = ch.before.Name
+				}
+			}
+			if ch.after != nil {
+				after = &ch.after.Spec
+				if changeEvent.Site == "" {
+					changeEvent.Site = ch.after.Name
+				}
+			}
+			changeEvent.Changes = specdiff.Diff("spec", before, after)
+			sink.Write(changeEvent)
+		}
+	}
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// errorCapturingWriter keeps the beginning of error responses to extract their message.
+type errorCapturingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *errorCapturingWriter) Write(b []byte) (int, error) {
+	if w.Status() >= http.StatusBadRequest && w.body.Len() < maxCapturedErrorBody {
+		w.body.Write(b[:min(len(b), maxCapturedErrorBody-w.body.Len())])
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *errorCapturingWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *errorCapturingWriter) errorMessage() string {
+	var body struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(w.body.Bytes(), &body); err == nil && body.Error != "" {
+		return body.Error
+	}
+	return w.body.String()
+}
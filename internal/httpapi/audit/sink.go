@@ -0,0 +1,231 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Sink persists audit events. Write must be safe for concurrent use and must not fail the
+// audited request, so sinks report their errors themselves.
+type Sink interface {
+	Write(event Event)
+	Close() error
+}
+
+// WriterSink writes events as JSON lines to an io.Writer, e.g. os.Stdout.
+type WriterSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+func (s *WriterSink) Write(event Event) {
+	b, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("couldn't encode audit event: %s", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.w.Write(append(b, '\n')); err != nil {
+		log.Printf("couldn't write audit event: %s", err)
+	}
+}
+
+func (s *WriterSink) Close() error {
+	return nil
+}
+
+// RotationOptions configures size based rotation of a FileSink.
+type RotationOptions struct {
+	// MaxSize is the size in bytes after which the file is rotated, 0 disables rotation.
+	MaxSize int64
+	// MaxBackups is the number of rotated files kept as <path>.1 to <path>.N, newest first.
+	MaxBackups int
+}
+
+// FileSink appends events as JSON lines to a file and rotates it by size.
+type FileSink struct {
+	path string
+	opts RotationOptions
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+func NewFileSink(path string, opts RotationOptions) (*FileSink, error) {
+	s := &FileSink{path: path, opts: opts}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileSink) open() error {
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		return fmt.Errorf("couldn't open audit log: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	s.file = f
+	s.size = info.Size()
+	return nil
+}
+
+func (s *FileSink) Write(event Event) {
+	b, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("couldn't encode audit event: %s", err)
+		return
+	}
+	b = append(b, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.opts.MaxSize > 0 && s.size > 0 && s.size+int64(len(b)) > s.opts.MaxSize {
+		if err := s.rotate(); err != nil {
+			log.Printf("couldn't rotate audit log: %s", err)
+		}
+	}
+
+	n, err := s.file.Write(b)
+	s.size += int64(n)
+	if err != nil {
+		log.Printf("couldn't write audit event: %s", err)
+	}
+}
+
+// rotate shifts the backups by one, drops the oldest and starts a new file. s.mu must be held.
+func (s *FileSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+
+	if s.opts.MaxBackups > 0 {
+		os.Remove(s.backupPath(s.opts.MaxBackups))
+		for i := s.opts.MaxBackups - 1; i >= 1; i-- {
+			os.Rename(s.backupPath(i), s.backupPath(i+1))
+		}
+		if err := os.Rename(s.path, s.backupPath(1)); err != nil {
+			return err
+		}
+	} else if err := os.Remove(s.path); err != nil {
+		return err
+	}
+
+	return s.open()
+}
+
+func (s *FileSink) backupPath(i int) string {
+	return fmt.Sprintf("%s.%d", s.path, i)
+}
+
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// webhookQueueSize bounds the events waiting for delivery, further events are dropped.
+const webhookQueueSize = 1000
+
+// WebhookSink posts every event as JSON to an HTTP endpoint, e.g. a local log shipper.
+// Events are delivered in the background so a slow endpoint doesn't delay API calls.
+type WebhookSink struct {
+	url    string
+	client *http.Client
+	queue  chan Event
+	done   chan struct{}
+
+	// mu guards closing the queue, requests may still be audited after the sink is closed
+	mu     sync.RWMutex
+	closed bool
+}
+
+func NewWebhookSink(url string, client *http.Client) *WebhookSink {
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+
+	s := &WebhookSink{
+		url:    url,
+		client: client,
+		queue:  make(chan Event, webhookQueueSize),
+		done:   make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+func (s *WebhookSink) Write(event Event) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		log.Printf("audit webhook closed, dropping event of request %s", event.RequestID)
+		return
+	}
+	select {
+	case s.queue <- event:
+	default:
+		log.Printf("audit webhook queue full, dropping event of request %s", event.RequestID)
+	}
+}
+
+func (s *WebhookSink) run() {
+	defer close(s.done)
+	for event := range s.queue {
+		if err := s.post(event); err != nil {
+			log.Printf("couldn't deliver audit event of request %s: %s", event.RequestID, err)
+		}
+	}
+}
+
+func (s *WebhookSink) post(event Event) error {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Close delivers the queued events and stops the sink. Events written afterwards are dropped.
+func (s *WebhookSink) Close() error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+
+	<-s.done
+	return nil
+}
@@ -0,0 +1,282 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	webv1 "website-operator/api/v1"
+
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+func TestStaticTokenAuthenticator(t *testing.T) {
+	authn, err := NewStaticTokenAuthenticator(strings.NewReader(`# comment
+s3cr3t,alice,"editors,admins"
+other,bob
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	user, err := authn.AuthenticateToken(context.Background(), "s3cr3t")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if user.Name != "alice" || !user.InGroup("editors") || !user.InGroup("admins") {
+		t.Fatalf("unexpected user: %+v", user)
+	}
+
+	user, err = authn.AuthenticateToken(context.Background(), "other")
+	if err != nil || user.Name != "bob" || len(user.Groups) != 0 {
+		t.Fatalf("unexpected user %+v: %v", user, err)
+	}
+
+	if _, err := authn.AuthenticateToken(context.Background(), "wrong"); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("expected ErrUnauthenticated, got %v", err)
+	}
+
+	if _, err := NewStaticTokenAuthenticator(strings.NewReader("tokenwithoutuser\n")); err == nil {
+		t.Fatalf("expected error for line without user")
+	}
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func signJWT(t *testing.T, alg, kid string, key crypto.Signer, claims map[string]any) string {
+	t.Helper()
+
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var sig []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		var err error
+		sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+
+	return signed + "." + b64(sig)
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	set, _ := json.Marshal(map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa", "use": "sig", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kty": "EC", "kid": "ec", "crv": "P-256", "x": b64(ecKey.X.Bytes()), "y": b64(ecKey.Y.Bytes())},
+	}})
+
+	authn, err := NewJWTAuthenticator(set, JWTOptions{Issuer: "https://issuer.local", Audience: "website-api"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	validClaims := func() map[string]any {
+		return map[string]any{
+			"iss":    "https://issuer.local",
+			"aud":    []string{"other", "website-api"},
+			"sub":    "alice",
+			"groups": []string{"editors"},
+			"exp":    time.Now().Add(time.Hour).Unix(),
+		}
+	}
+
+	t.Run("RS256", func(t *testing.T) {
+		user, err := authn.AuthenticateToken(context.Background(), signJWT(t, "RS256", "rsa", rsaKey, validClaims()))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if user.Name != "alice" || !user.InGroup("editors") {
+			t.Fatalf("unexpected user: %+v", user)
+		}
+	})
+
+	t.Run("ES256", func(t *testing.T) {
+		if _, err := authn.AuthenticateToken(context.Background(), signJWT(t, "ES256", "ec", ecKey, validClaims())); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	failures := map[string]func() string{
+		"Expired": func() string {
+			claims := validClaims()
+			claims["exp"] = time.Now().Add(-time.Hour).Unix()
+			return signJWT(t, "RS256", "rsa", rsaKey, claims)
+		},
+		"WrongIssuer": func() string {
+			claims := validClaims()
+			claims["iss"] = "https://evil.local"
+			return signJWT(t, "RS256", "rsa", rsaKey, claims)
+		},
+		"WrongAudience": func() string {
+			claims := validClaims()
+			claims["aud"] = "other"
+			return signJWT(t, "RS256", "rsa", rsaKey, claims)
+		},
+		"UnknownKey": func() string {
+			return signJWT(t, "RS256", "unknown", rsaKey, validClaims())
+		},
+		"AlgorithmMismatch": func() string {
+			return signJWT(t, "ES256", "rsa", ecKey, validClaims())
+		},
+		"TamperedPayload": func() string {
+			parts := strings.Split(signJWT(t, "RS256", "rsa", rsaKey, validClaims()), ".")
+			claims := validClaims()
+			claims["sub"] = "mallory"
+			payload, _ := json.Marshal(claims)
+			return parts[0] + "." + b64(payload) + "." + parts[2]
+		},
+		"Malformed": func() string {
+			return "not-a-jwt"
+		},
+	}
+
+	for name, token := range failures {
+		t.Run(name, func(t *testing.T) {
+			if _, err := authn.AuthenticateToken(context.Background(), token()); !errors.Is(err, ErrUnauthenticated) {
+				t.Fatalf("expected ErrUnauthenticated, got %v", err)
+			}
+		})
+	}
+}
+
+func TestNamespaceAuthorizer(t *testing.T) {
+	authz := NewNamespaceAuthorizer(NamespacePolicy{
+		Users:  map[string][]string{"alice": {"team-a"}},
+		Groups: map[string][]string{"admins": {AllNamespaces}},
+	})
+
+	tests := []struct {
+		user      *User
+		namespace string
+		allowed   bool
+	}{
+		{&User{Name: "alice"}, "team-a", true},
+		{&User{Name: "alice"}, "team-b", false},
+		{&User{Name: "bob", Groups: []string{"admins"}}, "team-b", true},
+		{&User{Name: "bob"}, "team-a", false},
+	}
+
+	for _, tt := range tests {
+		err := authz.Authorize(tt.user, tt.namespace)
+		if (err == nil) != tt.allowed {
+			t.Errorf("Authorize(%s, %s) = %v, want allowed=%v", tt.user.Name, tt.namespace, err, tt.allowed)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	authn, _ := NewStaticTokenAuthenticator(strings.NewReader("alice-token,alice\n"))
+	authz := NewNamespaceAuthorizer(NamespacePolicy{Users: map[string][]string{"alice": {"team-a"}}})
+
+	r := gin.New()
+	r.GET("/", Authenticate(authn), Authorize(authz, func(c *gin.Context) string {
+		return c.Query("namespace")
+	}), func(c *gin.Context) {
+		user, _ := UserFromContext(c.Request.Context())
+		c.String(http.StatusOK, user.Name)
+	})
+
+	tests := []struct {
+		name      string
+		header    string
+		namespace string
+		status    int
+	}{
+		{"NoToken", "", "team-a", http.StatusUnauthorized},
+		{"InvalidToken", "Bearer wrong", "team-a", http.StatusUnauthorized},
+		{"Forbidden", "Bearer alice-token", "team-b", http.StatusForbidden},
+		{"Allowed", "Bearer alice-token", "team-a", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/?namespace="+tt.namespace, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestImpersonatingClient(t *testing.T) {
+	impersonated := make(chan http.Header, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		impersonated <- r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"apiVersion":"anexia.com/v1","kind":"WebSite","metadata":{"name":"shop"}}`)
+	}))
+	defer server.Close()
+
+	if err := webv1.AddToScheme(scheme.Scheme); err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewImpersonatingClient(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, user := range []*User{{Name: "alice", Groups: []string{"editors", "admins"}}, {Name: "bob"}} {
+		ctx := WithUser(context.Background(), user)
+		if _, err := client.Websites("default").Get(ctx, "shop", metav1.GetOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		header := <-impersonated
+		if header.Get("Impersonate-User") != user.Name || !slices.Equal(header.Values("Impersonate-Group"), user.Groups) {
+			t.Errorf("expected %+v to be impersonated, got headers %v", user, header)
+		}
+	}
+
+	if _, err := client.Websites("default").Get(context.Background(), "shop", metav1.GetOptions{}); err == nil {
+		t.Error("expected an error for a request without user")
+	}
+	if len(impersonated) > 0 {
+		t.Error("expected a request without user not to be sent")
+	}
+}
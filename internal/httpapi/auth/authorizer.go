@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"sigs.k8s.io/yaml"
+)
+
+// AllNamespaces grants access to every namespace in a NamespaceAuthorizer policy.
+const AllNamespaces = "*"
+
+// Authorizer decides whether a user may access websites in a namespace.
+type Authorizer interface {
+	Authorize(user *User, namespace string) error
+}
+
+// NamespacePolicy maps user and group names to the namespaces they may access.
+type NamespacePolicy struct {
+	Users  map[string][]string `json:"users"`
+	Groups map[string][]string `json:"groups"`
+}
+
+// NamespaceAuthorizer authorizes requests with a static NamespacePolicy.
+type NamespaceAuthorizer struct {
+	policy NamespacePolicy
+}
+
+// NewNamespaceAuthorizerFromFile reads a NamespacePolicy from a YAML or JSON file, e.g.:
+//
+//	users:
+//	  alice: [team-a, team-b]
+//	groups:
+//	  admins: ["*"]
+func NewNamespaceAuthorizerFromFile(path string) (*NamespaceAuthorizer, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read namespace policy: %w", err)
+	}
+
+	var policy NamespacePolicy
+	if err := yaml.UnmarshalStrict(b, &policy); err != nil {
+		return nil, fmt.Errorf("couldn't parse namespace policy: %w", err)
+	}
+	return NewNamespaceAuthorizer(policy), nil
+}
+
+func NewNamespaceAuthorizer(policy NamespacePolicy) *NamespaceAuthorizer {
+	return &NamespaceAuthorizer{policy: policy}
+}
+
+func (a *NamespaceAuthorizer) Authorize(user *User, namespace string) error {
+	if allows(a.policy.Users[user.Name], namespace) {
+		return nil
+	}
+	for _, g := range user.Groups {
+		if allows(a.policy.Groups[g], namespace) {
+			return nil
+		}
+	}
+	return fmt.Errorf("user '%s' may not access namespace '%s'", user.Name, namespace)
+}
+
+func allows(namespaces []string, namespace string) bool {
+	return slices.Contains(namespaces, AllNamespaces) || slices.Contains(namespaces, namespace)
+}
@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	v1 "website-operator/clientset/v1"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
+)
+
+// ImpersonatingClient is a v1.WebsiteV1Interface that performs every call as the
+// user found in the request context, so cluster RBAC decides what a caller may do.
+// All users share its connections, each request impersonates its user by headers.
+type ImpersonatingClient struct {
+	*v1.WebsiteV1Client
+}
+
+func NewImpersonatingClient(config *rest.Config) (*ImpersonatingClient, error) {
+	config = rest.CopyConfig(config)
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &impersonatingTransport{delegate: rt}
+	})
+
+	client, err := v1.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &ImpersonatingClient{WebsiteV1Client: client}, nil
+}
+
+// impersonatingTransport sets the impersonation headers for the user in the context of each
+// request. Requests without a user fail rather than being sent as the API server itself.
+type impersonatingTransport struct {
+	delegate http.RoundTripper
+}
+
+func (t *impersonatingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	user, ok := UserFromContext(req.Context())
+	if !ok {
+		return nil, fmt.Errorf("no user to impersonate in request context")
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set(transport.ImpersonateUserHeader, user.Name)
+	req.Header.Del(transport.ImpersonateGroupHeader)
+	for _, group := range user.Groups {
+		req.Header.Add(transport.ImpersonateGroupHeader, group)
+	}
+	return t.delegate.RoundTrip(req)
+}
@@ -0,0 +1,293 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// JWTOptions configures which tokens are accepted by the JWTAuthenticator.
+type JWTOptions struct {
+	// Issuer must match the iss claim if set.
+	Issuer string
+	// Audience must be contained in the aud claim if set.
+	Audience string
+	// UsernameClaim is the claim used as user name, defaults to "sub".
+	UsernameClaim string
+	// GroupsClaim is the claim used for group memberships, defaults to "groups".
+	GroupsClaim string
+	// Leeway is the allowed clock skew when checking exp and nbf.
+	Leeway time.Duration
+}
+
+// JWTAuthenticator validates signed JWTs against a locally configured JSON Web Key Set.
+type JWTAuthenticator struct {
+	keys map[string]crypto.PublicKey
+	opts JWTOptions
+	now  func() time.Time
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// NewJWTAuthenticatorFromFile creates a JWTAuthenticator trusting the RSA and EC keys of a JWKS file.
+func NewJWTAuthenticatorFromFile(jwksPath string, opts JWTOptions) (*JWTAuthenticator, error) {
+	b, err := os.ReadFile(jwksPath)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read JWKS file: %w", err)
+	}
+	return NewJWTAuthenticator(b, opts)
+}
+
+// NewJWTAuthenticator creates a JWTAuthenticator trusting the RSA and EC keys of the given JWKS document.
+func NewJWTAuthenticator(jwksJSON []byte, opts JWTOptions) (*JWTAuthenticator, error) {
+	var set jwks
+	if err := json.Unmarshal(jwksJSON, &set); err != nil {
+		return nil, fmt.Errorf("couldn't parse JWKS: %w", err)
+	}
+
+	if opts.UsernameClaim == "" {
+		opts.UsernameClaim = "sub"
+	}
+	if opts.GroupsClaim == "" {
+		opts.GroupsClaim = "groups"
+	}
+
+	a := &JWTAuthenticator{
+		keys: map[string]crypto.PublicKey{},
+		opts: opts,
+		now:  time.Now,
+	}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid key '%s' in JWKS: %w", k.Kid, err)
+		}
+		a.keys[k.Kid] = key
+	}
+
+	if len(a.keys) == 0 {
+		return nil, fmt.Errorf("JWKS contains no signing keys")
+	}
+	return a, nil
+}
+
+func (k *jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve '%s'", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type '%s'", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func (a *JWTAuthenticator) AuthenticateToken(_ context.Context, token string) (*User, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrUnauthenticated
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrUnauthenticated
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrUnauthenticated
+	}
+
+	if err := a.verify(header, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnauthenticated, err)
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrUnauthenticated
+	}
+
+	if err := a.validateClaims(claims); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnauthenticated, err)
+	}
+
+	name, _ := claims[a.opts.UsernameClaim].(string)
+	if name == "" {
+		return nil, fmt.Errorf("%w: claim '%s' missing", ErrUnauthenticated, a.opts.UsernameClaim)
+	}
+
+	user := &User{Name: name}
+	switch groups := claims[a.opts.GroupsClaim].(type) {
+	case string:
+		user.Groups = []string{groups}
+	case []any:
+		for _, g := range groups {
+			if s, ok := g.(string); ok {
+				user.Groups = append(user.Groups, s)
+			}
+		}
+	}
+
+	return user, nil
+}
+
+func (a *JWTAuthenticator) verify(header jwtHeader, signed, signature []byte) error {
+	key, ok := a.keys[header.Kid]
+	if !ok {
+		return fmt.Errorf("unknown key id '%s'", header.Kid)
+	}
+
+	var hash crypto.Hash
+	switch header.Alg {
+	case "RS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512", "ES512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported algorithm '%s'", header.Alg)
+	}
+
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(header.Alg, "RS") {
+			return fmt.Errorf("algorithm '%s' doesn't match RSA key", header.Alg)
+		}
+		return rsa.VerifyPKCS1v15(key, hash, digest, signature)
+	case *ecdsa.PublicKey:
+		if !strings.HasPrefix(header.Alg, "ES") {
+			return fmt.Errorf("algorithm '%s' doesn't match EC key", header.Alg)
+		}
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return fmt.Errorf("invalid signature length")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(key, digest, r, s) {
+			return fmt.Errorf("invalid signature")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported key")
+	}
+}
+
+func (a *JWTAuthenticator) validateClaims(claims map[string]any) error {
+	now := a.now()
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return fmt.Errorf("claim 'exp' missing")
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(a.opts.Leeway)) {
+		return fmt.Errorf("token expired")
+	}
+
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(a.opts.Leeway).Before(time.Unix(int64(nbf), 0)) {
+		return fmt.Errorf("token not yet valid")
+	}
+
+	if a.opts.Issuer != "" && claims["iss"] != a.opts.Issuer {
+		return fmt.Errorf("issuer mismatch")
+	}
+
+	if a.opts.Audience != "" {
+		var audiences []string
+		switch aud := claims["aud"].(type) {
+		case string:
+			audiences = []string{aud}
+		case []any:
+			for _, v := range aud {
+				if s, ok := v.(string); ok {
+					audiences = append(audiences, s)
+				}
+			}
+		}
+		if !slices.Contains(audiences, a.opts.Audience) {
+			return fmt.Errorf("audience mismatch")
+		}
+	}
+
+	return nil
+}
+
+func decodeSegment(seg string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Authenticate returns a middleware that requires a valid bearer token on every request.
+// The resolved User is stored in the request context, see UserFromContext.
+func Authenticate(authn Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scheme, token, found := strings.Cut(c.GetHeader("Authorization"), " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+			c.Header("WWW-Authenticate", `Bearer realm="website-operator"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "bearer token required"})
+			return
+		}
+
+		user, err := authn.AuthenticateToken(c.Request.Context(), strings.TrimSpace(token))
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer realm="website-operator", error="invalid_token"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Request = c.Request.WithContext(WithUser(c.Request.Context(), user))
+		c.Next()
+	}
+}
+
+// Authorize returns a middleware that checks the authenticated user against authz
+// for the namespace the request targets. It must run after Authenticate.
+func Authorize(authz Authorizer, namespace func(c *gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := UserFromContext(c.Request.Context())
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+			return
+		}
+
+		if err := authz.Authorize(user, namespace(c)); err != nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"context"
+	"slices"
+)
+
+// User is the identity of an authenticated caller of the HTTP API.
+type User struct {
+	Name   string   `json:"name"`
+	Groups []string `json:"groups,omitempty"`
+}
+
+// InGroup reports whether the user is a member of the given group.
+func (u *User) InGroup(group string) bool {
+	return slices.Contains(u.Groups, group)
+}
+
+type userContextKey struct{}
+
+// WithUser returns a copy of ctx carrying the given user.
+func WithUser(ctx context.Context, user *User) context.Context {
+	return context.WithValue(ctx, userContextKey{}, user)
+}
+
+// UserFromContext returns the user stored in ctx by the authentication middleware.
+func UserFromContext(ctx context.Context) (*User, bool) {
+	user, ok := ctx.Value(userContextKey{}).(*User)
+	return user, ok && user != nil
+}
@@ -0,0 +1,316 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	webv1 "website-operator/api/v1"
+	"website-operator/httpapiclient"
+	"website-operator/internal/access"
+	"website-operator/internal/httpapi/audit"
+	"website-operator/internal/imagepolicy"
+	"website-operator/internal/nginxconf"
+	"website-operator/internal/shard"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// maxImportSize limits import bundles, which carry up to a few dozen websites.
+	maxImportSize = 64 << 20
+
+	websiteAPIVersion = "anexia.com/v1"
+	websiteKind       = "WebSite"
+
+	formatQueryParam = "format"
+	dryRunQueryParam = "dryRun"
+)
+
+// manifest is a WebSite without the fields set by the server, as exported in bundles.
+type manifest struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Metadata   manifestMetadata  `json:"metadata"`
+	Spec       webv1.WebSiteSpec `json:"spec"`
+}
+
+type manifestMetadata struct {
+	Name        string            `json:"name"`
+	Namespace   string            `json:"namespace,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// manifestList is the JSON bundle format, the same as "kubectl get -o json" produces.
+type manifestList struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Items      []json.RawMessage `json:"items"`
+}
+
+// exportedAnnotation reports whether an annotation belongs to the website rather than to the
+// tools which managed it in the source cluster.
+func exportedAnnotation(key string) bool {
+	return !strings.HasPrefix(key, "kubectl.kubernetes.io/")
+}
+
+func manifestOf(site *webv1.WebSite) manifest {
+	annotations := maps.Clone(site.Annotations)
+	maps.DeleteFunc(annotations, func(key, _ string) bool { return !exportedAnnotation(key) })
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+
+	return manifest{
+		APIVersion: websiteAPIVersion,
+		Kind:       websiteKind,
+		Metadata: manifestMetadata{
+			Name:        site.Name,
+			Labels:      site.Labels,
+			Annotations: annotations,
+		},
+		Spec: site.Spec,
+	}
+}
+
+// Export writes the websites of a namespace as a bundle, either YAML documents or a JSON List.
+// The namespace is left out, so bundles can be imported into any namespace.
+func (h *WebsiteHandler) Export(c *gin.Context) {
+	sites, err := h.kubeClient.Websites(RequestNamespace(c)).List(c.Request.Context(), metav1.ListOptions{})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	slices.SortFunc(sites.Items, func(a, b webv1.WebSite) int { return strings.Compare(a.Name, b.Name) })
+
+	if c.DefaultQuery(formatQueryParam, "yaml") == "json" {
+		list := struct {
+			APIVersion string     `json:"apiVersion"`
+			Kind       string     `json:"kind"`
+			Items      []manifest `json:"items"`
+		}{APIVersion: "v1", Kind: "List", Items: []manifest{}}
+		for i := range sites.Items {
+			list.Items = append(list.Items, manifestOf(&sites.Items[i]))
+		}
+		c.JSON(http.StatusOK, list)
+		return
+	}
+
+	var buf bytes.Buffer
+	for i := range sites.Items {
+		b, err := yaml.Marshal(manifestOf(&sites.Items[i]))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		buf.WriteString("---\n")
+		buf.Write(b)
+	}
+	c.Data(http.StatusOK, "application/yaml", buf.Bytes())
+}
+
+// Import creates or updates the websites of a bundle in the request namespace. Every website
+// is handled on its own, so the response reports the result per website instead of failing
+// the whole import.
+func (h *WebsiteHandler) Import(c *gin.Context) {
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	items, err := readBundle(body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := httpapiclient.ImportResultDTO{DryRun: DryRun(c) != nil, Items: []httpapiclient.ImportItemResultDTO{}}
+	for i, item := range items {
+		itemResult := h.importItem(c, item)
+		if itemResult.Name == "" {
+			itemResult.Name = fmt.Sprintf("#%d", i)
+		}
+		result.Items = append(result.Items, itemResult)
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *WebsiteHandler) importItem(c *gin.Context, raw json.RawMessage) httpapiclient.ImportItemResultDTO {
+	failed := func(name string, err error) httpapiclient.ImportItemResultDTO {
+		return httpapiclient.ImportItemResultDTO{Name: name, Action: httpapiclient.ImportFailed, Error: err.Error()}
+	}
+
+	var m manifest
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return failed("", fmt.Errorf("invalid manifest: %w", err))
+	}
+	name := m.Metadata.Name
+
+	if err := validateManifest(&m, RequestNamespace(c), h.images); err != nil {
+		return failed(name, err)
+	}
+
+	siteLabels := shard.SetLabel(maps.Clone(m.Metadata.Labels), RequestNamespace(c), name)
+
+	websites := h.kubeClient.Websites(RequestNamespace(c))
+	website, err := websites.Get(c.Request.Context(), name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		if err := h.hostnameAvailable(c.Request.Context(), RequestNamespace(c), name, m.Spec.Hostname); err != nil {
+			return failed(name, err)
+		}
+		created, err := websites.Create(c.Request.Context(), &webv1.WebSite{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       websiteKind,
+				APIVersion: websiteAPIVersion,
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Labels:      siteLabels,
+				Annotations: m.Metadata.Annotations,
+			},
+			Spec: m.Spec,
+		}, metav1.CreateOptions{DryRun: DryRun(c)})
+		if err != nil {
+			return failed(name, err)
+		}
+		if DryRun(c) == nil {
+			audit.RecordChange(c, nil, created)
+		}
+		return httpapiclient.ImportItemResultDTO{Name: name, Action: httpapiclient.ImportCreated}
+	}
+	if err != nil {
+		return failed(name, err)
+	}
+
+	if equality.Semantic.DeepEqual(website.Spec, m.Spec) &&
+		equality.Semantic.DeepEqual(website.Labels, siteLabels) &&
+		annotationsContain(website.Annotations, m.Metadata.Annotations) {
+		return httpapiclient.ImportItemResultDTO{Name: name, Action: httpapiclient.ImportUnchanged}
+	}
+
+	if m.Spec.Hostname != website.Spec.Hostname {
+		if err := h.hostnameAvailable(c.Request.Context(), website.Namespace, name, m.Spec.Hostname); err != nil {
+			return failed(name, err)
+		}
+	}
+
+	before := website.DeepCopy()
+	website.Spec = m.Spec
+	website.Labels = siteLabels
+	for key, value := range m.Metadata.Annotations {
+		if website.Annotations == nil {
+			website.Annotations = map[string]string{}
+		}
+		website.Annotations[key] = value
+	}
+
+	updated, err := websites.Update(c.Request.Context(), website, metav1.UpdateOptions{DryRun: DryRun(c)})
+	if err != nil {
+		return failed(name, err)
+	}
+	if DryRun(c) == nil {
+		audit.RecordChange(c, before, updated)
+	}
+	return httpapiclient.ImportItemResultDTO{Name: name, Action: httpapiclient.ImportUpdated}
+}
+
+func annotationsContain(annotations, subset map[string]string) bool {
+	for key, value := range subset {
+		if v, ok := annotations[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+// validateManifest applies the checks of the create and content endpoints to an imported website.
+func validateManifest(m *manifest, namespace string, images *imagepolicy.Policy) error {
+	if m.APIVersion != websiteAPIVersion || m.Kind != websiteKind {
+		return fmt.Errorf("expected %s %s, got %s %s", websiteAPIVersion, websiteKind, m.APIVersion, m.Kind)
+	}
+	if m.Metadata.Name == "" {
+		return errors.New("metadata.name is required")
+	}
+	// the caller is only authorized for the request namespace
+	if m.Metadata.Namespace != "" && m.Metadata.Namespace != namespace {
+		return fmt.Errorf("namespace '%s' doesn't match the target namespace '%s'", m.Metadata.Namespace, namespace)
+	}
+
+	base := httpapiclient.WebsiteBase{NginxImage: m.Spec.NginxImage}
+	if err := base.Validate(); err != nil {
+		return err
+	}
+	if err := validateImage(images, m.Spec.NginxImage); err != nil {
+		return err
+	}
+	if err := nginxconf.Validate(m.Spec.Server, field.NewPath("spec", "server")).ToAggregate(); err != nil {
+		return err
+	}
+	if err := access.Validate(m.Spec.Access, field.NewPath("spec", "access")).ToAggregate(); err != nil {
+		return err
+	}
+	if dto := MapMaintenanceToDTO(m.Spec.Maintenance); dto != nil {
+		if err := dto.Validate(); err != nil {
+			return err
+		}
+	}
+
+	files := newContentFiles()
+	for name, content := range m.Spec.Files {
+		if err := files.add(name, bytes.NewReader(content)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readBundle splits a bundle into its manifests. Bundles are YAML documents or JSON objects,
+// each either a single manifest or a List of manifests.
+func readBundle(r io.Reader) ([]json.RawMessage, error) {
+	decoder := utilyaml.NewYAMLOrJSONDecoder(r, 4096)
+
+	var items []json.RawMessage
+	for {
+		var doc json.RawMessage
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("invalid bundle: %w", err)
+		}
+		// empty YAML documents, e.g. after a leading "---"
+		if len(doc) == 0 || string(doc) == "null" {
+			continue
+		}
+
+		var list manifestList
+		if err := json.Unmarshal(doc, &list); err != nil {
+			return nil, fmt.Errorf("invalid bundle: %w", err)
+		}
+		if strings.HasSuffix(list.Kind, "List") {
+			items = append(items, list.Items...)
+			continue
+		}
+		items = append(items, doc)
+	}
+
+	if len(items) == 0 {
+		return nil, errors.New("bundle contains no websites")
+	}
+	return items, nil
+}
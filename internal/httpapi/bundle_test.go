@@ -0,0 +1,99 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	webv1 "website-operator/api/v1"
+	"website-operator/internal/imagepolicy"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestReadBundle(t *testing.T) {
+	site := &webv1.WebSite{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "blog",
+			Namespace:       "team-a",
+			ResourceVersion: "42",
+			Annotations:     map[string]string{"kubectl.kubernetes.io/last-applied-configuration": "{}", "owner": "alice"},
+		},
+		Spec: webv1.WebSiteSpec{
+			HtmlContent: "<h1>blog</h1>",
+			NginxImage:  "docker.io/nginx:latest",
+			Files:       map[string][]byte{"logo.svg": []byte("<svg/>")},
+		},
+	}
+
+	doc, err := yaml.Marshal(manifestOf(site))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"resourceVersion", "namespace", "last-applied-configuration"} {
+		if strings.Contains(string(doc), field) {
+			t.Errorf("expected %s to be stripped from the manifest:\n%s", field, doc)
+		}
+	}
+
+	list, _ := json.Marshal(map[string]any{"apiVersion": "v1", "kind": "List", "items": []any{manifestOf(site), manifestOf(site)}})
+
+	bundles := map[string]string{
+		"YAML":     "---\n" + string(doc) + "---\n" + string(doc),
+		"JSONList": string(list),
+	}
+	for name, bundle := range bundles {
+		t.Run(name, func(t *testing.T) {
+			items, err := readBundle(strings.NewReader(bundle))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(items) != 2 {
+				t.Fatalf("expected 2 items, got %d", len(items))
+			}
+
+			var m manifest
+			if err := json.Unmarshal(items[0], &m); err != nil {
+				t.Fatal(err)
+			}
+			if err := validateManifest(&m, "team-b", imagepolicy.Default()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(m.Spec.Files["logo.svg"]) != "<svg/>" || m.Metadata.Annotations["owner"] != "alice" {
+				t.Errorf("unexpected manifest: %+v", m)
+			}
+		})
+	}
+
+	if _, err := readBundle(strings.NewReader("---\n")); err == nil {
+		t.Errorf("expected error for empty bundle")
+	}
+}
+
+func TestValidateManifest(t *testing.T) {
+	valid := func() manifest {
+		return manifest{
+			APIVersion: websiteAPIVersion,
+			Kind:       websiteKind,
+			Metadata:   manifestMetadata{Name: "blog"},
+			Spec:       webv1.WebSiteSpec{NginxImage: "docker.io/nginx:latest"},
+		}
+	}
+
+	tests := map[string]func(m *manifest){
+		"WrongKind":         func(m *manifest) { m.Kind = "ConfigMap" },
+		"MissingName":       func(m *manifest) { m.Metadata.Name = "" },
+		"OtherNamespace":    func(m *manifest) { m.Metadata.Namespace = "team-b" },
+		"InvalidImage":      func(m *manifest) { m.Spec.NginxImage = "evil/nginx" },
+		"PathTraversalFile": func(m *manifest) { m.Spec.Files = map[string][]byte{"../x.html": nil} },
+	}
+	for name, modify := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := valid()
+			modify(&m)
+			if err := validateManifest(&m, "team-a", imagepolicy.Default()); err == nil {
+				t.Fatalf("expected error")
+			}
+		})
+	}
+}
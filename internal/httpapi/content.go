@@ -0,0 +1,259 @@
+package httpapi
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+)
+
+const (
+	// maxContentUploadSize limits the size of an uploaded archive.
+	maxContentUploadSize = 8 << 20
+	// maxContentSize limits the extracted content, which has to fit into a ConfigMap (1 MiB)
+	// next to the base64 overhead of the WebSite object in etcd.
+	maxContentSize = 768 << 10
+	// maxContentFiles limits the number of files of a website.
+	maxContentFiles = 250
+
+	contentFormField = "archive"
+)
+
+// allowedContentExtensions are the file types a website may be built of.
+var allowedContentExtensions = []string{
+	".html", ".htm", ".css", ".js", ".mjs", ".map", ".json", ".xml", ".txt", ".webmanifest",
+	".svg", ".png", ".jpg", ".jpeg", ".gif", ".webp", ".avif", ".ico",
+	".woff", ".woff2", ".ttf", ".otf",
+}
+
+var errContentTooLarge = fmt.Errorf("content exceeds %d bytes", maxContentSize)
+
+// ContentError is returned for archives that are malformed or violate the upload limits.
+type ContentError struct {
+	Path string
+	Err  error
+}
+
+func (e *ContentError) Error() string {
+	if e.Path == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s: %s", e.Path, e.Err)
+}
+
+func (e *ContentError) Unwrap() error {
+	return e.Err
+}
+
+// ReadContentArchive extracts the website files of an upload request. The body is either a
+// zip, tar or gzipped tar archive, or a multipart form with the archive in the "archive" field.
+func ReadContentArchive(r *http.Request) (map[string][]byte, error) {
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, &ContentError{Err: fmt.Errorf("invalid content type: %w", err)}
+	}
+
+	body := http.MaxBytesReader(nil, r.Body, maxContentUploadSize)
+
+	if mediaType == "multipart/form-data" {
+		part, err := findMultipartArchive(multipart.NewReader(body, params["boundary"]))
+		if err != nil {
+			return nil, err
+		}
+
+		mediaType = part.Header.Get("Content-Type")
+		if mediaType == "" || mediaType == "application/octet-stream" {
+			mediaType = archiveTypeByName(part.FileName())
+		}
+		return readArchive(mediaType, part)
+	}
+
+	return readArchive(mediaType, body)
+}
+
+func findMultipartArchive(reader *multipart.Reader) (*multipart.Part, error) {
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, &ContentError{Err: fmt.Errorf("multipart field '%s' missing", contentFormField)}
+		}
+		if err != nil {
+			return nil, &ContentError{Err: fmt.Errorf("invalid multipart body: %w", err)}
+		}
+		if part.FormName() == contentFormField {
+			return part, nil
+		}
+	}
+}
+
+func archiveTypeByName(name string) string {
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return "application/zip"
+	case strings.HasSuffix(name, ".tar"):
+		return "application/x-tar"
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return "application/gzip"
+	}
+	return ""
+}
+
+func readArchive(mediaType string, r io.Reader) (map[string][]byte, error) {
+	switch mediaType {
+	case "application/zip", "application/x-zip-compressed":
+		return readZip(r)
+	case "application/x-tar":
+		return readTar(r)
+	case "application/gzip", "application/x-gzip", "application/x-gtar":
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, &ContentError{Err: fmt.Errorf("invalid gzip archive: %w", err)}
+		}
+		defer gz.Close()
+		return readTar(gz)
+	default:
+		return nil, &ContentError{Err: fmt.Errorf("unsupported archive type '%s'", mediaType)}
+	}
+}
+
+func readZip(r io.Reader) (map[string][]byte, error) {
+	// zip needs random access, the upload size is limited by the caller
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, &ContentError{Err: fmt.Errorf("couldn't read archive: %w", err)}
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return nil, &ContentError{Err: fmt.Errorf("invalid zip archive: %w", err)}
+	}
+
+	files := newContentFiles()
+	for _, f := range archive.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if !f.Mode().IsRegular() {
+			return nil, &ContentError{Path: f.Name, Err: errors.New("only regular files are allowed")}
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, &ContentError{Path: f.Name, Err: err}
+		}
+		err = files.add(f.Name, rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files.result()
+}
+
+func readTar(r io.Reader) (map[string][]byte, error) {
+	archive := tar.NewReader(r)
+
+	files := newContentFiles()
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, &ContentError{Err: fmt.Errorf("invalid tar archive: %w", err)}
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir, tar.TypeXGlobalHeader:
+			continue
+		case tar.TypeReg:
+			if err := files.add(header.Name, archive); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, &ContentError{Path: header.Name, Err: errors.New("only regular files are allowed")}
+		}
+	}
+	return files.result()
+}
+
+// contentFiles collects extracted files while enforcing the content limits.
+type contentFiles struct {
+	files map[string][]byte
+	size  int
+}
+
+func newContentFiles() *contentFiles {
+	return &contentFiles{files: map[string][]byte{}}
+}
+
+func (f *contentFiles) add(name string, r io.Reader) error {
+	p, err := cleanContentPath(name)
+	if err != nil {
+		return &ContentError{Path: name, Err: err}
+	}
+
+	if len(f.files) >= maxContentFiles {
+		return &ContentError{Err: fmt.Errorf("more than %d files", maxContentFiles)}
+	}
+
+	// read one byte more than allowed to detect oversized content without trusting the headers
+	b, err := io.ReadAll(io.LimitReader(r, int64(maxContentSize-f.size+1)))
+	if err != nil {
+		return &ContentError{Path: name, Err: err}
+	}
+	f.size += len(b)
+	if f.size > maxContentSize {
+		return &ContentError{Err: errContentTooLarge}
+	}
+
+	f.files[p] = b
+	return nil
+}
+
+func (f *contentFiles) result() (map[string][]byte, error) {
+	if len(f.files) == 0 {
+		return nil, &ContentError{Err: errors.New("archive contains no files")}
+	}
+	return f.files, nil
+}
+
+// cleanContentPath normalizes an archive entry name to a path relative to the document root
+// and rejects entries escaping it or of a type that isn't allowed.
+func cleanContentPath(name string) (string, error) {
+	if strings.Contains(name, "\\") || strings.ContainsRune(name, 0) {
+		return "", errors.New("invalid characters in path")
+	}
+	if strings.HasPrefix(name, "/") {
+		return "", errors.New("absolute paths are not allowed")
+	}
+	for _, segment := range strings.Split(name, "/") {
+		if segment == ".." {
+			return "", errors.New("path traversal is not allowed")
+		}
+	}
+
+	p := path.Clean(name)
+	if p == "." || strings.HasPrefix(p, "../") {
+		return "", errors.New("invalid path")
+	}
+	for _, segment := range strings.Split(p, "/") {
+		if strings.HasPrefix(segment, ".") {
+			return "", errors.New("hidden files are not allowed")
+		}
+	}
+
+	if !slices.Contains(allowedContentExtensions, strings.ToLower(path.Ext(p))) {
+		return "", fmt.Errorf("file type '%s' is not allowed", path.Ext(p))
+	}
+	return p, nil
+}
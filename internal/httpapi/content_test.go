@@ -0,0 +1,129 @@
+package httpapi
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type archiveEntry struct {
+	name    string
+	content string
+	mode    byte
+}
+
+func tarArchive(t *testing.T, entries ...archiveEntry) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		typ := e.mode
+		if typ == 0 {
+			typ = tar.TypeReg
+		}
+		if err := tw.WriteHeader(&tar.Header{Typeflag: typ, Name: e.name, Size: int64(len(e.content)), Mode: 0o644, Linkname: "/etc/passwd"}); err != nil {
+			t.Fatal(err)
+		}
+		if typ == tar.TypeReg {
+			tw.Write([]byte(e.content))
+		}
+	}
+	tw.Close()
+	return &buf
+}
+
+func zipArchive(t *testing.T, entries ...archiveEntry) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		w, err := zw.Create(e.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(e.content))
+	}
+	zw.Close()
+	return &buf
+}
+
+func uploadRequest(contentType string, body *bytes.Buffer) *http.Request {
+	req := httptest.NewRequest(http.MethodPut, "/api/websites/site/content", body)
+	req.Header.Set("Content-Type", contentType)
+	return req
+}
+
+func TestReadContentArchive(t *testing.T) {
+	site := []archiveEntry{
+		{name: "index.html", content: "<p>hello</p>"},
+		{name: "assets/", mode: tar.TypeDir},
+		{name: "./assets/style.css", content: "p { color: red }"},
+	}
+
+	t.Run("Tar", func(t *testing.T) {
+		files, err := ReadContentArchive(uploadRequest("application/x-tar", tarArchive(t, site...)))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(files) != 2 || string(files["index.html"]) != "<p>hello</p>" || string(files["assets/style.css"]) != "p { color: red }" {
+			t.Fatalf("unexpected files: %v", files)
+		}
+	})
+
+	t.Run("Zip", func(t *testing.T) {
+		files, err := ReadContentArchive(uploadRequest("application/zip", zipArchive(t, site[0], site[2])))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(files) != 2 || string(files["assets/style.css"]) != "p { color: red }" {
+			t.Fatalf("unexpected files: %v", files)
+		}
+	})
+
+	t.Run("Multipart", func(t *testing.T) {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		w, _ := mw.CreateFormFile(contentFormField, "site.zip")
+		w.Write(zipArchive(t, site[0]).Bytes())
+		mw.Close()
+
+		files, err := ReadContentArchive(uploadRequest(mw.FormDataContentType(), &body))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(files) != 1 {
+			t.Fatalf("unexpected files: %v", files)
+		}
+	})
+
+	failures := map[string]*http.Request{
+		"PathTraversal":    uploadRequest("application/x-tar", tarArchive(t, archiveEntry{name: "../index.html", content: "x"})),
+		"NestedTraversal":  uploadRequest("application/zip", zipArchive(t, archiveEntry{name: "assets/../../index.html", content: "x"})),
+		"AbsolutePath":     uploadRequest("application/x-tar", tarArchive(t, archiveEntry{name: "/etc/index.html", content: "x"})),
+		"DisallowedType":   uploadRequest("application/x-tar", tarArchive(t, archiveEntry{name: "run.sh", content: "x"})),
+		"HiddenFile":       uploadRequest("application/x-tar", tarArchive(t, archiveEntry{name: ".git/config.txt", content: "x"})),
+		"Symlink":          uploadRequest("application/x-tar", tarArchive(t, archiveEntry{name: "index.html", mode: tar.TypeSymlink})),
+		"TooLarge":         uploadRequest("application/x-tar", tarArchive(t, archiveEntry{name: "index.html", content: strings.Repeat("x", maxContentSize+1)})),
+		"Empty":            uploadRequest("application/x-tar", tarArchive(t)),
+		"UnsupportedType":  uploadRequest("application/json", bytes.NewBufferString("{}")),
+		"MultipartMissing": uploadRequest("multipart/form-data; boundary=x", bytes.NewBufferString("--x--\r\n")),
+	}
+
+	for name, req := range failures {
+		t.Run(name, func(t *testing.T) {
+			_, err := ReadContentArchive(req)
+			var contentErr *ContentError
+			if !errors.As(err, &contentErr) {
+				t.Fatalf("expected ContentError, got %v", err)
+			}
+		})
+	}
+}
@@ -1,48 +1,122 @@
 package httpapi
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"reflect"
+	"slices"
 	webv1 "website-operator/api/v1"
 	"website-operator/clientset/v1"
 	"website-operator/httpapiclient"
+	"website-operator/internal/access"
+	"website-operator/internal/httpapi/audit"
+	"website-operator/internal/imagepolicy"
+	"website-operator/internal/nginxconf"
+	"website-operator/internal/shard"
 
 	"github.com/gin-gonic/gin"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 )
 
-func NewRouter(handler WebsiteHandlerInterface) *gin.Engine {
+// NewRouter registers the website routes of handler. The given middleware, e.g. authentication,
+// is applied to all routes below /api, followed by validation against the OpenAPI spec.
+func NewRouter(handler WebsiteHandlerInterface, middleware ...gin.HandlerFunc) *gin.Engine {
 	r := gin.Default()
+	r.Use(RequestID())
 
-	api := r.Group("/api")
+	spec := NewOpenAPISpec()
+	registerOpenAPIRoutes(r, spec)
+
+	api := r.Group("/api", append(slices.Clone(middleware), ValidateRequest(spec))...)
 	{
 		api.GET("/websites", handler.List)
+		api.GET("/websites/watch", handler.Watch)
+		api.GET("/websites/export", handler.Export)
+		api.POST("/websites/import", handler.Import)
+		api.GET("/websites/:name/watch", handler.Watch)
+		api.GET("/websites/:name", handler.Get)
 		api.POST("/websites", handler.Create)
 		api.PUT("/websites/:name", handler.Update)
+		api.PUT("/websites/:name/content", handler.UploadContent)
+		api.GET("/websites/:name/revisions", handler.Revisions)
+		api.POST("/websites/:name/rollback", handler.Rollback)
+		api.POST("/websites/:name/pause", handler.Pause)
+		api.POST("/websites/:name/resume", handler.Resume)
+		api.POST("/websites/:name/maintenance", handler.SetMaintenance)
 		api.DELETE("/websites/:name", handler.Delete)
+		api.POST("/websites/preview", handler.CreatePreview)
+		api.GET("/websites/:name/preview", handler.PreviewWebsite)
 	}
 
+	// previews are authorized by their token, so they are served outside of the API middleware
+	r.GET(previewPathPrefix+":token/*filepath", handler.ServePreview)
+
 	return r
 }
 
+const (
+	namespaceQueryParam = "namespace"
+	defaultNamespace    = "default"
+)
+
+// DryRun returns the dry-run option of a request, given by the "dryRun" query parameter.
+// Dry-run requests pass admission like real ones, but nothing is persisted.
+func DryRun(c *gin.Context) []string {
+	if c.Query(dryRunQueryParam) == "true" {
+		return []string{metav1.DryRunAll}
+	}
+	return nil
+}
+
+// RequestNamespace returns the namespace a request targets, given by the "namespace" query parameter.
+func RequestNamespace(c *gin.Context) string {
+	return c.DefaultQuery(namespaceQueryParam, defaultNamespace)
+}
+
 type WebsiteHandler struct {
 	kubeClient v1.WebsiteV1Interface
+	configMaps corev1client.ConfigMapsGetter
+	images     *imagepolicy.Policy
+	previews   *PreviewStore
 }
 
 type WebsiteHandlerInterface interface {
 	List(c *gin.Context)
+	Get(c *gin.Context)
 	Create(c *gin.Context)
 	Delete(c *gin.Context)
 	Update(c *gin.Context)
+	Watch(c *gin.Context)
+	UploadContent(c *gin.Context)
+	Revisions(c *gin.Context)
+	Rollback(c *gin.Context)
+	Pause(c *gin.Context)
+	Resume(c *gin.Context)
+	SetMaintenance(c *gin.Context)
+	Export(c *gin.Context)
+	Import(c *gin.Context)
+	CreatePreview(c *gin.Context)
+	PreviewWebsite(c *gin.Context)
+	ServePreview(c *gin.Context)
 }
 
-func NewWebsiteHandler(kubeClient v1.WebsiteV1Interface) *WebsiteHandler {
+// NewWebsiteHandler creates a handler managing websites with kubeClient. The revisions
+// recorded by the controller are read with configMaps.
+func NewWebsiteHandler(kubeClient v1.WebsiteV1Interface, configMaps corev1client.ConfigMapsGetter, images *imagepolicy.Policy) *WebsiteHandler {
 	return &WebsiteHandler{
 		kubeClient: kubeClient,
+		configMaps: configMaps,
+		images:     images,
+		previews:   NewPreviewStore(defaultPreviewTTL),
 	}
 }
 
 func (h *WebsiteHandler) List(c *gin.Context) {
-	sites, err := h.kubeClient.Websites("default").List(c.Request.Context(), metav1.ListOptions{})
+	sites, err := h.kubeClient.Websites(RequestNamespace(c)).List(c.Request.Context(), metav1.ListOptions{})
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -53,6 +127,20 @@ func (h *WebsiteHandler) List(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
+func (h *WebsiteHandler) Get(c *gin.Context) {
+	site, err := h.kubeClient.Websites(RequestNamespace(c)).Get(c.Request.Context(), c.Param("name"), metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, MapKubeWebsiteToDTO(site))
+}
+
 func (h *WebsiteHandler) Create(c *gin.Context) {
 	var dto httpapiclient.WebsiteCreateDTO
 	if err := c.ShouldBindJSON(&dto); err != nil {
@@ -65,42 +153,81 @@ func (h *WebsiteHandler) Create(c *gin.Context) {
 		return
 	}
 
-	newSite, err := h.kubeClient.Websites("default").Create(c.Request.Context(), &webv1.WebSite{
+	if err := validateImage(h.images, dto.NginxImage); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	server := MapDTOToServer(dto.Server)
+	if err := nginxconf.Validate(server, field.NewPath("server")).ToAggregate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	accessConfig := MapDTOToAccess(dto.Access)
+	if err := access.Validate(accessConfig, field.NewPath("access")).ToAggregate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.checkHostname(c, RequestNamespace(c), dto.Name, dto.Hostname) {
+		return
+	}
+
+	newSite, err := h.kubeClient.Websites(RequestNamespace(c)).Create(c.Request.Context(), &webv1.WebSite{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "WebSite",
 			APIVersion: "anexia.com/v1",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name: dto.Name,
+			Name:   dto.Name,
+			Labels: shard.SetLabel(nil, RequestNamespace(c), dto.Name),
 		},
 		Spec: webv1.WebSiteSpec{
 			HtmlContent: dto.HtmlContent,
 			Hostname:    dto.Hostname,
 			NginxImage:  dto.NginxImage,
+
+			RevisionHistoryLimit: dto.RevisionHistoryLimit,
+			Suspend:              dto.Suspend,
+			Server:               server,
+			Access:               accessConfig,
 		},
-	}, metav1.CreateOptions{})
+	}, metav1.CreateOptions{DryRun: DryRun(c)})
 
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	if DryRun(c) != nil {
+		c.JSON(http.StatusCreated, MapDryRunToDTO(nil, newSite))
+		return
+	}
+
+	audit.RecordChange(c, nil, newSite)
 	c.JSON(http.StatusCreated, MapKubeWebsiteToDTO(newSite))
 }
 
 func (h *WebsiteHandler) Delete(c *gin.Context) {
-	err := h.kubeClient.Websites("default").Delete(c.Request.Context(), c.Param("name"), metav1.DeleteOptions{})
+	// the deleted state is only needed for the audit log, so a failed lookup doesn't fail the deletion
+	before, getErr := h.kubeClient.Websites(RequestNamespace(c)).Get(c.Request.Context(), c.Param("name"), metav1.GetOptions{})
+
+	err := h.kubeClient.Websites(RequestNamespace(c)).Delete(c.Request.Context(), c.Param("name"), metav1.DeleteOptions{})
 
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	if getErr == nil {
+		audit.RecordChange(c, before, nil)
+	}
 	c.Status(http.StatusAccepted)
 }
 
 func (h *WebsiteHandler) Update(c *gin.Context) {
-	website, err := h.kubeClient.Websites("default").Get(c.Request.Context(), c.Param("name"), metav1.GetOptions{})
+	website, err := h.kubeClient.Websites(RequestNamespace(c)).Get(c.Request.Context(), c.Param("name"), metav1.GetOptions{})
 
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -118,15 +245,110 @@ func (h *WebsiteHandler) Update(c *gin.Context) {
 		return
 	}
 
+	if err := validateImage(h.images, dto.NginxImage); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// the server configuration is kept if the request omits it, and removed by an empty one
+	server := website.Spec.Server
+	if dto.Server != nil {
+		server = MapDTOToServer(dto.Server)
+		if reflect.ValueOf(*server).IsZero() {
+			server = nil
+		}
+	}
+	if err := nginxconf.Validate(server, field.NewPath("server")).ToAggregate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// the access is kept if the request omits it, and removed by an empty one
+	accessConfig := website.Spec.Access
+	if dto.Access != nil {
+		accessConfig = MapDTOToAccess(dto.Access)
+		if accessConfig.BasicAuth == nil && len(accessConfig.AllowedCIDRs) == 0 {
+			accessConfig = nil
+		}
+	}
+	if err := access.Validate(accessConfig, field.NewPath("access")).ToAggregate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if dto.Hostname != website.Spec.Hostname && !h.checkHostname(c, website.Namespace, website.Name, dto.Hostname) {
+		return
+	}
+
+	before := &webv1.WebSite{}
+	website.DeepCopyInto(before)
+
 	website.Spec.HtmlContent = dto.HtmlContent
 	website.Spec.Hostname = dto.Hostname
 	website.Spec.NginxImage = dto.NginxImage
+	if dto.RevisionHistoryLimit != nil {
+		website.Spec.RevisionHistoryLimit = dto.RevisionHistoryLimit
+	}
+	if dto.Suspend != nil {
+		website.Spec.Suspend = *dto.Suspend
+	}
+	website.Spec.Server = server
+	website.Spec.Access = accessConfig
 
-	site, err := h.kubeClient.Websites("default").Update(c.Request.Context(), website, metav1.UpdateOptions{})
+	site, err := h.kubeClient.Websites(RequestNamespace(c)).Update(c.Request.Context(), website, metav1.UpdateOptions{DryRun: DryRun(c)})
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	if DryRun(c) != nil {
+		c.JSON(http.StatusAccepted, MapDryRunToDTO(before, site))
+		return
+	}
+
+	audit.RecordChange(c, before, site)
 	c.JSON(http.StatusAccepted, MapKubeWebsiteToDTO(site))
 }
+
+// UploadContent replaces the files of a website with the contents of an uploaded archive.
+func (h *WebsiteHandler) UploadContent(c *gin.Context) {
+	website, err := h.kubeClient.Websites(RequestNamespace(c)).Get(c.Request.Context(), c.Param("name"), metav1.GetOptions{})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	files, err := ReadContentArchive(c.Request)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	before := &webv1.WebSite{}
+	website.DeepCopyInto(before)
+
+	website.Spec.Files = files
+
+	site, err := h.kubeClient.Websites(RequestNamespace(c)).Update(c.Request.Context(), website, metav1.UpdateOptions{})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	audit.RecordChange(c, before, site)
+	c.JSON(http.StatusAccepted, MapKubeWebsiteToDTO(site))
+}
+
+// validateImage checks the nginx image of a website against the image policy.
+func validateImage(policy *imagepolicy.Policy, image string) error {
+	var violation *imagepolicy.Violation
+	if _, err := policy.Check(image); errors.As(err, &violation) {
+		return fmt.Errorf("nginx image '%s' is invalid: %s", image, violation.Reason)
+	}
+	return nil
+}
@@ -1,3 +1,8 @@
+//go:build system
+
+// The system tests need the HTTP API and the controller running in a cluster, run them with
+// "go test -tags system ./internal/httpapi" and TEST_API_BASEURL pointing to the API.
+
 package httpapi
 
 import (
@@ -0,0 +1,651 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	webv1 "website-operator/api/v1"
+	"website-operator/clientset/v1/fake"
+	"website-operator/httpapiclient"
+	"website-operator/internal"
+	"website-operator/internal/imagepolicy"
+	"website-operator/internal/revision"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func testSite(namespace, name string) *webv1.WebSite {
+	return &webv1.WebSite{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: webv1.WebSiteSpec{
+			HtmlContent: "<h1>" + name + "</h1>",
+			Hostname:    name + ".local",
+			NginxImage:  "docker.io/nginx:latest",
+		},
+	}
+}
+
+const validCreateBody = `{"name":"blog","htmlContent":"<h1>blog</h1>","hostname":"blog.local","nginxImage":"docker.io/nginx:latest"}`
+const validUpdateBody = `{"htmlContent":"<h1>new</h1>","hostname":"new.local","nginxImage":"docker.io/nginx:latest"}`
+
+func TestWebsiteHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		// setup prepares the clientset, which contains default/existing and team-a/shop
+		setup func(c *fake.Clientset)
+		// revisions are stored as revision ConfigMaps before the request
+		revisions []revisionOf
+		// configMapsSetup prepares the clientset holding the revisions
+		configMapsSetup func(c *k8sfake.Clientset)
+		status          int
+		// check inspects the response body and the clientset after the request
+		check func(t *testing.T, body []byte, c *fake.Clientset)
+	}{
+		{
+			name: "ListDefaultNamespace", method: http.MethodGet, path: "/api/websites",
+			status: http.StatusOK,
+			check: func(t *testing.T, body []byte, _ *fake.Clientset) {
+				sites := decode[httpapiclient.WebsiteListDTO](t, body)
+				if len(sites) != 1 || sites[0].Name != "existing" || sites[0].Namespace != "default" {
+					t.Errorf("unexpected websites: %s", body)
+				}
+			},
+		},
+		{
+			name: "ListOtherNamespace", method: http.MethodGet, path: "/api/websites?namespace=team-a",
+			status: http.StatusOK,
+			check: func(t *testing.T, body []byte, _ *fake.Clientset) {
+				sites := decode[httpapiclient.WebsiteListDTO](t, body)
+				if len(sites) != 1 || sites[0].Name != "shop" {
+					t.Errorf("unexpected websites: %s", body)
+				}
+			},
+		},
+		{
+			name: "ListError", method: http.MethodGet, path: "/api/websites",
+			setup:  func(c *fake.Clientset) { c.SetError(fake.VerbList, errors.New("etcd unavailable")) },
+			status: http.StatusBadRequest,
+			check:  expectError("etcd unavailable"),
+		},
+		{
+			name: "Get", method: http.MethodGet, path: "/api/websites/existing",
+			status: http.StatusOK,
+			check: func(t *testing.T, body []byte, _ *fake.Clientset) {
+				site := decode[httpapiclient.WebsiteDTO](t, body)
+				if site.Hostname != "existing.local" || site.ResourceVersion == "" || site.CreationTimestamp.IsZero() {
+					t.Errorf("unexpected website: %s", body)
+				}
+			},
+		},
+		{
+			name: "GetNotFound", method: http.MethodGet, path: "/api/websites/missing",
+			status: http.StatusNotFound,
+			check:  expectError(`"missing" not found`),
+		},
+		{
+			name: "GetWrongNamespace", method: http.MethodGet, path: "/api/websites/shop",
+			status: http.StatusNotFound,
+		},
+		{
+			name: "Create", method: http.MethodPost, path: "/api/websites?namespace=team-a",
+			contentType: "application/json", body: validCreateBody,
+			status: http.StatusCreated,
+			check: func(t *testing.T, body []byte, c *fake.Clientset) {
+				site := decode[httpapiclient.WebsiteDTO](t, body)
+				if site.Name != "blog" || site.Namespace != "team-a" || site.Generation != 1 || site.HtmlContent != "<h1>blog</h1>" {
+					t.Errorf("unexpected website: %s", body)
+				}
+				expectStored(t, c, "team-a", "blog", true)
+			},
+		},
+		{
+			name: "CreateDryRun", method: http.MethodPost, path: "/api/websites?dryRun=true",
+			contentType: "application/json", body: validCreateBody,
+			status: http.StatusCreated,
+			check: func(t *testing.T, body []byte, c *fake.Clientset) {
+				site := decode[httpapiclient.WebsiteDTO](t, body)
+				if !site.DryRun || len(site.Diff) == 0 {
+					t.Errorf("expected dry-run result with diff: %s", body)
+				}
+				expectStored(t, c, "default", "blog", false)
+			},
+		},
+		{
+			name: "CreateAlreadyExists", method: http.MethodPost, path: "/api/websites",
+			contentType: "application/json", body: strings.Replace(validCreateBody, `"blog"`, `"existing"`, 1),
+			status: http.StatusBadRequest,
+			check:  expectError("already exists"),
+		},
+		{
+			name: "CreateHostnameConflict", method: http.MethodPost, path: "/api/websites",
+			contentType: "application/json", body: strings.Replace(validCreateBody, "blog.local", "shop.local", 1),
+			status: http.StatusConflict,
+			check: func(t *testing.T, body []byte, c *fake.Clientset) {
+				expectError("hostname 'shop.local' is already claimed by website 'team-a/shop'")(t, body, c)
+				expectStored(t, c, "default", "blog", false)
+			},
+		},
+		{
+			name: "CreateHostnameCheckForbidden", method: http.MethodPost, path: "/api/websites",
+			contentType: "application/json", body: strings.Replace(validCreateBody, "blog.local", "shop.local", 1),
+			setup: func(c *fake.Clientset) {
+				c.SetError(fake.VerbList, apierrors.NewForbidden(webv1.SchemeGroupVersion.WithResource("websites").GroupResource(), "", errors.New("cluster scope")))
+			},
+			status: http.StatusCreated,
+		},
+		{
+			name: "CreateInvalidImage", method: http.MethodPost, path: "/api/websites",
+			contentType: "application/json", body: strings.Replace(validCreateBody, "docker.io/nginx:latest", "evil/nginx", 1),
+			status: http.StatusBadRequest,
+			check:  expectError("nginx image 'evil/nginx' is invalid"),
+		},
+		{
+			name: "CreateServer", method: http.MethodPost, path: "/api/websites",
+			contentType: "application/json", body: strings.Replace(validCreateBody, "{", `{"server":{"spa":true,"redirects":[{"from":"/old","to":"/new"}]},`, 1),
+			status: http.StatusCreated,
+			check: func(t *testing.T, body []byte, c *fake.Clientset) {
+				site := expectStored(t, c, "default", "blog", true)
+				if site.Spec.Server == nil || !site.Spec.Server.SPA || len(site.Spec.Server.Redirects) != 1 {
+					t.Errorf("unexpected server: %+v", site.Spec.Server)
+				}
+			},
+		},
+		{
+			name: "CreateInvalidServer", method: http.MethodPost, path: "/api/websites",
+			contentType: "application/json", body: strings.Replace(validCreateBody, "{", `{"server":{"headers":{"X-Evil":"a;\nreturn 200"}},`, 1),
+			status: http.StatusBadRequest,
+			check: func(t *testing.T, body []byte, c *fake.Clientset) {
+				expectError("server.headers[X-Evil]")(t, body, c)
+				expectStored(t, c, "default", "blog", false)
+			},
+		},
+		{
+			name: "CreateAccess", method: http.MethodPost, path: "/api/websites",
+			contentType: "application/json", body: strings.Replace(validCreateBody, "{", `{"access":{"basicAuth":{"secretName":"blog-users"},"allowedCIDRs":["10.0.0.0/8"]},`, 1),
+			status: http.StatusCreated,
+			check: func(t *testing.T, body []byte, c *fake.Clientset) {
+				site := decode[httpapiclient.WebsiteDTO](t, body)
+				if site.Access == nil || site.Access.BasicAuth == nil || site.Access.BasicAuth.SecretName != "blog-users" {
+					t.Errorf("unexpected access: %s", body)
+				}
+				stored := expectStored(t, c, "default", "blog", true)
+				if stored.Spec.Access == nil || len(stored.Spec.Access.AllowedCIDRs) != 1 {
+					t.Errorf("unexpected stored access: %+v", stored.Spec.Access)
+				}
+			},
+		},
+		{
+			name: "CreateInvalidAccess", method: http.MethodPost, path: "/api/websites",
+			contentType: "application/json", body: strings.Replace(validCreateBody, "{", `{"access":{"allowedCIDRs":["everyone"]},`, 1),
+			status: http.StatusBadRequest,
+			check:  expectError("access.allowedCIDRs[0]"),
+		},
+		{
+			name: "CreateMissingField", method: http.MethodPost, path: "/api/websites",
+			contentType: "application/json", body: `{"name":"blog"}`,
+			status: http.StatusBadRequest,
+			check:  expectError("is required"),
+		},
+		{
+			name: "CreateUnknownField", method: http.MethodPost, path: "/api/websites",
+			contentType: "application/json", body: strings.Replace(validCreateBody, "{", `{"replicas":3,`, 1),
+			status: http.StatusBadRequest,
+			check:  expectError("body.replicas: unknown field"),
+		},
+		{
+			name: "CreateMalformedJSON", method: http.MethodPost, path: "/api/websites",
+			contentType: "application/json", body: `{"name":`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "CreateWrongContentType", method: http.MethodPost, path: "/api/websites",
+			contentType: "text/plain", body: validCreateBody,
+			status: http.StatusUnsupportedMediaType,
+		},
+		{
+			name: "CreateInvalidDryRun", method: http.MethodPost, path: "/api/websites?dryRun=maybe",
+			contentType: "application/json", body: validCreateBody,
+			status: http.StatusBadRequest,
+			check:  expectError("dryRun: must be a boolean"),
+		},
+		{
+			name: "Update", method: http.MethodPut, path: "/api/websites/existing",
+			contentType: "application/json", body: validUpdateBody,
+			status: http.StatusAccepted,
+			check: func(t *testing.T, body []byte, c *fake.Clientset) {
+				site := decode[httpapiclient.WebsiteDTO](t, body)
+				if site.Hostname != "new.local" || site.Generation != 2 || site.DryRun {
+					t.Errorf("unexpected website: %s", body)
+				}
+			},
+		},
+		{
+			name: "UpdateKeepsSuspend", method: http.MethodPut, path: "/api/websites/existing",
+			contentType: "application/json", body: validUpdateBody,
+			setup: func(c *fake.Clientset) {
+				mutateStored(c, "default", "existing", func(site *webv1.WebSite) { site.Spec.Suspend = true })
+			},
+			status: http.StatusAccepted,
+			check: func(t *testing.T, body []byte, c *fake.Clientset) {
+				if stored := expectStored(t, c, "default", "existing", true); !stored.Spec.Suspend {
+					t.Errorf("expected website to stay suspended")
+				}
+			},
+		},
+		{
+			name: "UpdateResumes", method: http.MethodPut, path: "/api/websites/existing",
+			contentType: "application/json", body: strings.Replace(validUpdateBody, "{", `{"suspend":false,`, 1),
+			setup: func(c *fake.Clientset) {
+				mutateStored(c, "default", "existing", func(site *webv1.WebSite) { site.Spec.Suspend = true })
+			},
+			status: http.StatusAccepted,
+			check: func(t *testing.T, body []byte, c *fake.Clientset) {
+				if stored := expectStored(t, c, "default", "existing", true); stored.Spec.Suspend {
+					t.Errorf("expected website to be resumed")
+				}
+			},
+		},
+		{
+			name: "UpdateKeepsServer", method: http.MethodPut, path: "/api/websites/existing",
+			contentType: "application/json", body: validUpdateBody,
+			setup:  configureServer,
+			status: http.StatusAccepted,
+			check: func(t *testing.T, body []byte, c *fake.Clientset) {
+				if stored := expectStored(t, c, "default", "existing", true); stored.Spec.Server == nil || len(stored.Spec.Server.Redirects) != 1 {
+					t.Errorf("expected server configuration to be kept, got %+v", stored.Spec.Server)
+				}
+			},
+		},
+		{
+			name: "UpdateRemovesServer", method: http.MethodPut, path: "/api/websites/existing",
+			contentType: "application/json", body: strings.Replace(validUpdateBody, "{", `{"server":{},`, 1),
+			setup:  configureServer,
+			status: http.StatusAccepted,
+			check: func(t *testing.T, body []byte, c *fake.Clientset) {
+				if stored := expectStored(t, c, "default", "existing", true); stored.Spec.Server != nil {
+					t.Errorf("expected server configuration to be removed, got %+v", stored.Spec.Server)
+				}
+			},
+		},
+		{
+			name: "UpdateKeepsRevisionHistoryLimit", method: http.MethodPut, path: "/api/websites/existing",
+			contentType: "application/json", body: validUpdateBody,
+			setup: func(c *fake.Clientset) {
+				mutateStored(c, "default", "existing", func(site *webv1.WebSite) { site.Spec.RevisionHistoryLimit = internal.Ptr(int32(3)) })
+			},
+			status: http.StatusAccepted,
+			check: func(t *testing.T, body []byte, c *fake.Clientset) {
+				if stored := expectStored(t, c, "default", "existing", true); stored.Spec.RevisionHistoryLimit == nil || *stored.Spec.RevisionHistoryLimit != 3 {
+					t.Errorf("expected revision history limit to be kept, got %v", stored.Spec.RevisionHistoryLimit)
+				}
+			},
+		},
+		{
+			name: "UpdateKeepsAccess", method: http.MethodPut, path: "/api/websites/existing",
+			contentType: "application/json", body: validUpdateBody,
+			setup: func(c *fake.Clientset) {
+				mutateStored(c, "default", "existing", func(site *webv1.WebSite) {
+					site.Spec.Access = &webv1.AccessConfig{BasicAuth: &webv1.BasicAuth{SecretName: "existing-users"}}
+				})
+			},
+			status: http.StatusAccepted,
+			check: func(t *testing.T, body []byte, c *fake.Clientset) {
+				if stored := expectStored(t, c, "default", "existing", true); stored.Spec.Access == nil || stored.Spec.Access.BasicAuth == nil {
+					t.Errorf("expected access to be kept, got %+v", stored.Spec.Access)
+				}
+			},
+		},
+		{
+			name: "UpdateRemovesAccess", method: http.MethodPut, path: "/api/websites/existing",
+			contentType: "application/json", body: strings.Replace(validUpdateBody, "{", `{"access":{},`, 1),
+			setup: func(c *fake.Clientset) {
+				mutateStored(c, "default", "existing", func(site *webv1.WebSite) {
+					site.Spec.Access = &webv1.AccessConfig{BasicAuth: &webv1.BasicAuth{SecretName: "existing-users"}}
+				})
+			},
+			status: http.StatusAccepted,
+			check: func(t *testing.T, body []byte, c *fake.Clientset) {
+				if stored := expectStored(t, c, "default", "existing", true); stored.Spec.Access != nil {
+					t.Errorf("expected access to be removed, got %+v", stored.Spec.Access)
+				}
+			},
+		},
+		{
+			name: "UpdateHostnameConflict", method: http.MethodPut, path: "/api/websites/existing",
+			contentType: "application/json", body: strings.Replace(validUpdateBody, "new.local", "shop.local", 1),
+			status: http.StatusConflict,
+			check:  expectError("is already claimed by website 'team-a/shop'"),
+		},
+		{
+			name: "UpdateDryRun", method: http.MethodPut, path: "/api/websites/existing?dryRun=true",
+			contentType: "application/json", body: validUpdateBody,
+			status: http.StatusAccepted,
+			check: func(t *testing.T, body []byte, c *fake.Clientset) {
+				site := decode[httpapiclient.WebsiteDTO](t, body)
+				fields := map[string]bool{}
+				for _, change := range site.Diff {
+					fields[change.Field] = true
+				}
+				if !site.DryRun || !fields["spec.hostname"] || !fields["spec.htmlContent"] || fields["spec.nginxImage"] {
+					t.Errorf("unexpected dry-run result: %s", body)
+				}
+				if stored := expectStored(t, c, "default", "existing", true); stored.Spec.Hostname != "existing.local" {
+					t.Errorf("dry-run update must not be persisted")
+				}
+			},
+		},
+		{
+			name: "UpdateNotFound", method: http.MethodPut, path: "/api/websites/missing",
+			contentType: "application/json", body: validUpdateBody,
+			status: http.StatusBadRequest,
+			check:  expectError("not found"),
+		},
+		{
+			name: "UpdateError", method: http.MethodPut, path: "/api/websites/existing",
+			contentType: "application/json", body: validUpdateBody,
+			setup:  func(c *fake.Clientset) { c.SetError(fake.VerbUpdate, errors.New("admission denied")) },
+			status: http.StatusBadRequest,
+			check:  expectError("admission denied"),
+		},
+		{
+			name: "Delete", method: http.MethodDelete, path: "/api/websites/shop?namespace=team-a",
+			status: http.StatusAccepted,
+			check: func(t *testing.T, body []byte, c *fake.Clientset) {
+				if len(body) != 0 {
+					t.Errorf("expected empty body, got %s", body)
+				}
+				expectStored(t, c, "team-a", "shop", false)
+			},
+		},
+		{
+			name: "DeleteNotFound", method: http.MethodDelete, path: "/api/websites/shop",
+			status: http.StatusBadRequest,
+			check: func(t *testing.T, body []byte, c *fake.Clientset) {
+				expectStored(t, c, "team-a", "shop", true)
+			},
+		},
+		{
+			name: "RevisionsOfMissingWebsite", method: http.MethodGet, path: "/api/websites/missing/revisions",
+			status: http.StatusNotFound,
+		},
+		{
+			name: "PreviewOfMissingWebsite", method: http.MethodGet, path: "/api/websites/missing/preview",
+			status: http.StatusNotFound,
+		},
+		{
+			name: "RollbackOfMissingWebsite", method: http.MethodPost, path: "/api/websites/missing/rollback",
+			contentType: "application/json", body: `{"revision":1}`,
+			status: http.StatusNotFound,
+		},
+		{
+			name: "RollbackToMissingRevision", method: http.MethodPost, path: "/api/websites/existing/rollback",
+			contentType: "application/json", body: `{"revision":3}`,
+			status: http.StatusNotFound,
+		},
+		{
+			name: "RollbackForbidden", method: http.MethodPost, path: "/api/websites/existing/rollback",
+			contentType: "application/json", body: `{"revision":1}`,
+			configMapsSetup: func(c *k8sfake.Clientset) {
+				c.PrependReactor("get", "configmaps", func(k8stesting.Action) (bool, runtime.Object, error) {
+					return true, nil, apierrors.NewForbidden(corev1.Resource("configmaps"), "website-existing-rev-1", errors.New("no access"))
+				})
+			},
+			status: http.StatusForbidden,
+			check:  expectError("no access"),
+		},
+		{
+			name: "Rollback", method: http.MethodPost, path: "/api/websites/existing/rollback",
+			contentType: "application/json", body: `{"revision":1}`,
+			revisions: []revisionOf{{site: testSite("default", "existing"), number: 1, hostname: "old.local"}},
+			status:    http.StatusAccepted,
+			check: func(t *testing.T, body []byte, c *fake.Clientset) {
+				if stored := expectStored(t, c, "default", "existing", true); stored.Spec.Hostname != "old.local" {
+					t.Errorf("expected hostname of the revision to be restored, got %q", stored.Spec.Hostname)
+				}
+			},
+		},
+		{
+			name: "RollbackHostnameConflict", method: http.MethodPost, path: "/api/websites/existing/rollback",
+			contentType: "application/json", body: `{"revision":1}`,
+			revisions: []revisionOf{{site: testSite("default", "existing"), number: 1, hostname: "shop.local"}},
+			status:    http.StatusConflict,
+			check: func(t *testing.T, body []byte, c *fake.Clientset) {
+				expectError("hostname 'shop.local' is already claimed by website 'team-a/shop'")(t, body, c)
+				if stored := expectStored(t, c, "default", "existing", true); stored.Spec.Hostname != "existing.local" {
+					t.Errorf("expected rollback to be rejected, got hostname %q", stored.Spec.Hostname)
+				}
+			},
+		},
+		{
+			name: "Pause", method: http.MethodPost, path: "/api/websites/existing/pause",
+			status: http.StatusAccepted,
+			check: func(t *testing.T, body []byte, c *fake.Clientset) {
+				if site := decode[httpapiclient.WebsiteDTO](t, body); !site.Paused {
+					t.Errorf("expected paused website: %s", body)
+				}
+				if stored := expectStored(t, c, "default", "existing", true); stored.Annotations[webv1.AnnotationReconcile] != webv1.ReconcilePaused {
+					t.Errorf("expected pause annotation, got %v", stored.Annotations)
+				}
+			},
+		},
+		{
+			name: "Resume", method: http.MethodPost, path: "/api/websites/existing/resume",
+			setup: func(c *fake.Clientset) {
+				mutateStored(c, "default", "existing", func(site *webv1.WebSite) {
+					site.Annotations = map[string]string{webv1.AnnotationReconcile: webv1.ReconcilePaused}
+				})
+			},
+			status: http.StatusAccepted,
+			check: func(t *testing.T, body []byte, c *fake.Clientset) {
+				if site := decode[httpapiclient.WebsiteDTO](t, body); site.Paused {
+					t.Errorf("expected resumed website: %s", body)
+				}
+				if stored := expectStored(t, c, "default", "existing", true); stored.Paused() {
+					t.Errorf("expected pause annotation to be removed, got %v", stored.Annotations)
+				}
+			},
+		},
+		{
+			name: "ResumeSuspended", method: http.MethodPost, path: "/api/websites/existing/resume",
+			setup: func(c *fake.Clientset) {
+				mutateStored(c, "default", "existing", func(site *webv1.WebSite) { site.Spec.Suspend = true })
+			},
+			status: http.StatusAccepted,
+			check: func(t *testing.T, body []byte, _ *fake.Clientset) {
+				if site := decode[httpapiclient.WebsiteDTO](t, body); !site.Paused || !site.Suspend {
+					t.Errorf("expected suspended website to stay paused: %s", body)
+				}
+			},
+		},
+		{
+			name: "PauseNotFound", method: http.MethodPost, path: "/api/websites/missing/pause",
+			status: http.StatusNotFound,
+		},
+		{
+			name: "EnableMaintenance", method: http.MethodPost, path: "/api/websites/existing/maintenance",
+			contentType: "application/json", body: `{"enabled":true,"html":"<p>back soon</p>","retryAfterSeconds":600}`,
+			status: http.StatusAccepted,
+			check: func(t *testing.T, body []byte, c *fake.Clientset) {
+				if site := decode[httpapiclient.WebsiteDTO](t, body); site.Maintenance == nil || !site.Maintenance.Enabled {
+					t.Errorf("expected website in maintenance: %s", body)
+				}
+				stored := expectStored(t, c, "default", "existing", true)
+				expected := webv1.Maintenance{Enabled: true, HTML: "<p>back soon</p>", RetryAfterSeconds: 600}
+				if stored.Spec.Maintenance == nil || *stored.Spec.Maintenance != expected {
+					t.Errorf("expected maintenance %+v, got %+v", expected, stored.Spec.Maintenance)
+				}
+			},
+		},
+		{
+			name: "InvalidMaintenance", method: http.MethodPost, path: "/api/websites/existing/maintenance",
+			contentType: "application/json", body: `{"enabled":true,"retryAfterSeconds":-1}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "MaintenanceNotFound", method: http.MethodPost, path: "/api/websites/missing/maintenance",
+			contentType: "application/json", body: `{"enabled":true}`,
+			status: http.StatusNotFound,
+		},
+		{
+			name: "ExportJSON", method: http.MethodGet, path: "/api/websites/export?format=json",
+			status: http.StatusOK,
+			check: func(t *testing.T, body []byte, _ *fake.Clientset) {
+				if strings.Contains(string(body), "resourceVersion") || !strings.Contains(string(body), `"kind":"List"`) {
+					t.Errorf("unexpected export: %s", body)
+				}
+			},
+		},
+		{
+			name: "ExportInvalidFormat", method: http.MethodGet, path: "/api/websites/export?format=xml",
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "Import", method: http.MethodPost, path: "/api/websites/import",
+			contentType: "application/yaml",
+			body: `apiVersion: anexia.com/v1
+kind: WebSite
+metadata:
+  name: existing
+spec:
+  hostname: imported.local
+  nginxImage: docker.io/nginx:latest
+---
+apiVersion: anexia.com/v1
+kind: WebSite
+metadata:
+  name: broken
+spec:
+  nginxImage: evil/nginx
+`,
+			status: http.StatusOK,
+			check: func(t *testing.T, body []byte, c *fake.Clientset) {
+				result := decode[httpapiclient.ImportResultDTO](t, body)
+				if len(result.Items) != 2 || result.Items[0].Action != httpapiclient.ImportUpdated || result.Items[1].Action != httpapiclient.ImportFailed {
+					t.Errorf("unexpected import result: %s", body)
+				}
+				if stored := expectStored(t, c, "default", "existing", true); stored.Spec.Hostname != "imported.local" {
+					t.Errorf("expected import to update the website")
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			websites := fake.NewClientset(testSite("default", "existing"), testSite("team-a", "shop"))
+			if tt.setup != nil {
+				tt.setup(websites)
+			}
+			configMaps := k8sfake.NewClientset()
+			for _, rev := range tt.revisions {
+				if err := configMaps.Tracker().Add(rev.configMap(t)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.configMapsSetup != nil {
+				tt.configMapsSetup(configMaps)
+			}
+			router := NewRouter(NewWebsiteHandler(websites, configMaps.CoreV1(), imagepolicy.Default()))
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+			if tt.check != nil {
+				tt.check(t, w.Body.Bytes(), websites)
+			}
+		})
+	}
+}
+
+// configureServer gives default/existing a redirect.
+func configureServer(c *fake.Clientset) {
+	mutateStored(c, "default", "existing", func(site *webv1.WebSite) {
+		site.Spec.Server = &webv1.ServerConfig{Redirects: []webv1.Redirect{{From: "/old", To: "/new"}}}
+	})
+}
+
+// revisionOf describes a revision of site, which had the given hostname.
+type revisionOf struct {
+	site     *webv1.WebSite
+	number   int64
+	hostname string
+}
+
+func (r revisionOf) configMap(t *testing.T) *corev1.ConfigMap {
+	t.Helper()
+
+	site := r.site.DeepCopy()
+	site.Spec.Hostname = r.hostname
+	cm, err := revision.NewConfigMap(site, r.number)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cm.Namespace = site.Namespace
+	return cm
+}
+
+func decode[T any](t *testing.T, body []byte) T {
+	t.Helper()
+
+	var v T
+	if err := json.Unmarshal(body, &v); err != nil {
+		t.Fatalf("couldn't decode response %s: %v", body, err)
+	}
+	return v
+}
+
+func expectError(message string) func(t *testing.T, body []byte, _ *fake.Clientset) {
+	return func(t *testing.T, body []byte, _ *fake.Clientset) {
+		t.Helper()
+
+		response := decode[map[string]string](t, body)
+		if !strings.Contains(response["error"], message) {
+			t.Errorf("expected error containing %q, got %q", message, response["error"])
+		}
+	}
+}
+
+func expectStored(t *testing.T, c *fake.Clientset, namespace, name string, exists bool) *webv1.WebSite {
+	t.Helper()
+
+	site, err := c.Websites(namespace).Get(t.Context(), name, metav1.GetOptions{})
+	if (err == nil) != exists {
+		t.Fatalf("expected %s/%s to exist=%v, got error %v", namespace, name, exists, err)
+	}
+	return site
+}
+
+// mutateStored changes a website of the clientset, which must exist.
+func mutateStored(c *fake.Clientset, namespace, name string, mutate func(site *webv1.WebSite)) {
+	site, err := c.Websites(namespace).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		panic(err)
+	}
+	mutate(site)
+	if _, err := c.Websites(namespace).Update(context.Background(), site, metav1.UpdateOptions{}); err != nil {
+		panic(err)
+	}
+}
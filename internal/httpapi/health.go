@@ -0,0 +1,45 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	healthzPath = "/healthz"
+	readyzPath  = "/readyz"
+
+	readinessTimeout = 3 * time.Second
+)
+
+// ReadinessCheck reports whether a dependency of the HTTP API is usable.
+type ReadinessCheck func(ctx context.Context) error
+
+// RegisterHealthRoutes serves a liveness probe, which succeeds as long as the process serves
+// requests, and a readiness probe, which runs the given checks.
+func RegisterHealthRoutes(r *gin.Engine, checks map[string]ReadinessCheck) {
+	r.GET(healthzPath, func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	r.GET(readyzPath, func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+		defer cancel()
+
+		failed := gin.H{}
+		for name, check := range checks {
+			if err := check(ctx); err != nil {
+				failed[name] = err.Error()
+			}
+		}
+
+		if len(failed) > 0 {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "not ready", "checks": failed})
+			return
+		}
+		c.String(http.StatusOK, "ok")
+	})
+}
@@ -0,0 +1,56 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHealthAndMetricsRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var apiErr error
+	metrics := NewMetrics()
+	r := NewRouter(NewWebsiteHandler(nil, nil, nil), metrics.Middleware())
+	metrics.Register(r)
+	RegisterHealthRoutes(r, map[string]ReadinessCheck{
+		"kubernetes-api": func(ctx context.Context) error { return apiErr },
+	})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	if w := get(healthzPath); w.Code != http.StatusOK {
+		t.Fatalf("expected healthz to succeed, got %d", w.Code)
+	}
+	if w := get(readyzPath); w.Code != http.StatusOK {
+		t.Fatalf("expected readyz to succeed, got %d", w.Code)
+	}
+
+	apiErr = errors.New("connection refused")
+	if w := get(readyzPath); w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), "connection refused") {
+		t.Fatalf("expected readyz to fail, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodPut, "/api/websites/a/content", strings.NewReader("a"))
+	req.Header.Set("Content-Type", "text/plain")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	body := get(metricsPath).Body.String()
+	for _, expected := range []string{
+		`httpapi_requests_total{code="415",method="PUT",route="/api/websites/:name/content"} 1`,
+		`httpapi_request_duration_seconds_count{method="PUT",route="/api/websites/:name/content"} 1`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("metrics don't contain %s", expected)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+// hostnameClaimedError is returned if the hostname of a website is already claimed by another one.
+type hostnameClaimedError struct {
+	hostname  string
+	claimedBy string
+}
+
+func (e *hostnameClaimedError) Error() string {
+	return fmt.Sprintf("hostname '%s' is already claimed by website '%s'", e.hostname, e.claimedBy)
+}
+
+// checkHostname responds with 409 Conflict and returns false if hostname is claimed by another
+// website than namespace/name.
+func (h *WebsiteHandler) checkHostname(c *gin.Context, namespace, name, hostname string) bool {
+	err := h.hostnameAvailable(c.Request.Context(), namespace, name, hostname)
+	var claimed *hostnameClaimedError
+	switch {
+	case errors.As(err, &claimed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return false
+	case err != nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// hostnameAvailable checks the websites of all namespaces for a claim on hostname. If the caller
+// may not list them, or the cluster doesn't support selecting websites by hostname, the check is
+// left to the controller, which withholds the ingress of a conflicting website.
+func (h *WebsiteHandler) hostnameAvailable(ctx context.Context, namespace, name, hostname string) error {
+	if hostname == "" {
+		return nil
+	}
+
+	sites, err := h.kubeClient.Websites(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.hostname", hostname).String(),
+	})
+	if apierrors.IsForbidden(err) || apierrors.IsBadRequest(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't check hostname: %w", err)
+	}
+
+	for _, site := range sites.Items {
+		if site.Namespace != namespace || site.Name != name {
+			return &hostnameClaimedError{hostname: hostname, claimedBy: site.Namespace + "/" + site.Name}
+		}
+	}
+	return nil
+}
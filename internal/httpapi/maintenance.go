@@ -0,0 +1,51 @@
+package httpapi
+
+import (
+	"net/http"
+	webv1 "website-operator/api/v1"
+	"website-operator/httpapiclient"
+	"website-operator/internal/httpapi/audit"
+
+	"github.com/gin-gonic/gin"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SetMaintenance swaps a website to a maintenance page answering all requests with status 503,
+// or back to its content. The content is kept meanwhile and may still be updated.
+func (h *WebsiteHandler) SetMaintenance(c *gin.Context) {
+	var dto httpapiclient.MaintenanceDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := dto.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	websites := h.kubeClient.Websites(RequestNamespace(c))
+	website, err := websites.Get(c.Request.Context(), c.Param("name"), metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	before := &webv1.WebSite{}
+	website.DeepCopyInto(before)
+	website.Spec.Maintenance = MapDTOToMaintenance(&dto)
+
+	site, err := websites.Update(c.Request.Context(), website, metav1.UpdateOptions{})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	audit.RecordChange(c, before, site)
+	c.JSON(http.StatusAccepted, MapKubeWebsiteToDTO(site))
+}
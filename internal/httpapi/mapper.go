@@ -1,8 +1,13 @@
 package httpapi
 
 import (
+	"slices"
 	"website-operator/api/v1"
 	"website-operator/httpapiclient"
+	"website-operator/internal/revision"
+	"website-operator/internal/specdiff"
+
+	corev1 "k8s.io/api/core/v1"
 )
 
 func MapKubeWebsiteToDTO(site *v1.WebSite) *httpapiclient.WebsiteDTO {
@@ -11,12 +16,53 @@ func MapKubeWebsiteToDTO(site *v1.WebSite) *httpapiclient.WebsiteDTO {
 			HtmlContent: site.Spec.HtmlContent,
 			Hostname:    site.Spec.Hostname,
 			NginxImage:  site.Spec.NginxImage,
+
+			RevisionHistoryLimit: site.Spec.RevisionHistoryLimit,
+			Server:               MapServerToDTO(site.Spec.Server),
+			Access:               MapAccessToDTO(site.Spec.Access),
 		},
+		Suspend:           site.Spec.Suspend,
+		Maintenance:       MapMaintenanceToDTO(site.Spec.Maintenance),
 		Name:              site.Name,
+		Namespace:         site.Namespace,
 		Labels:            site.Labels,
 		Generation:        site.Generation,
+		ResourceVersion:   site.ResourceVersion,
 		CreationTimestamp: site.CreationTimestamp.Time,
+		Files:             mapFileNames(site.Spec.Files),
+		Paused:            site.Paused(),
+		ImageDigest:       site.Status.ImageDigest,
+	}
+}
+
+// MapDryRunToDTO maps the would-be state of a website after a dry-run request, including the
+// changes of its spec compared to before, which is nil for creations.
+func MapDryRunToDTO(before, after *v1.WebSite) *httpapiclient.WebsiteDTO {
+	var beforeSpec *v1.WebSiteSpec
+	if before != nil {
+		beforeSpec = &before.Spec
+	}
+
+	dto := MapKubeWebsiteToDTO(after)
+	dto.DryRun = true
+	dto.Diff = []httpapiclient.ChangeDTO{}
+	for _, change := range specdiff.Diff("spec", beforeSpec, &after.Spec) {
+		dto.Diff = append(dto.Diff, httpapiclient.ChangeDTO{Field: change.Field, Old: change.Old, New: change.New})
+	}
+	return dto
+}
+
+func mapFileNames(files map[string][]byte) []string {
+	if len(files) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
 	}
+	slices.Sort(names)
+	return names
 }
 
 func MapKubeWebsiteListToDTO(sites *v1.WebSiteList) httpapiclient.WebsiteListDTO {
@@ -27,3 +73,97 @@ func MapKubeWebsiteListToDTO(sites *v1.WebSiteList) httpapiclient.WebsiteListDTO
 	}
 	return result
 }
+
+func MapRevisionToDTO(cm *corev1.ConfigMap) (*httpapiclient.RevisionDTO, error) {
+	spec, err := revision.SpecFromConfigMap(cm)
+	if err != nil {
+		return nil, err
+	}
+
+	return &httpapiclient.RevisionDTO{
+		Revision:          revision.Number(cm),
+		SpecHash:          cm.Annotations[revision.AnnotationSpecHash],
+		CreationTimestamp: cm.CreationTimestamp.Time,
+		HtmlContent:       spec.HtmlContent,
+		Hostname:          spec.Hostname,
+		NginxImage:        spec.NginxImage,
+		Files:             mapFileNames(spec.Files),
+		Server:            MapServerToDTO(spec.Server),
+	}, nil
+}
+
+func MapMaintenanceToDTO(maintenance *v1.Maintenance) *httpapiclient.MaintenanceDTO {
+	if maintenance == nil {
+		return nil
+	}
+	dto := httpapiclient.MaintenanceDTO(*maintenance)
+	return &dto
+}
+
+func MapDTOToMaintenance(dto *httpapiclient.MaintenanceDTO) *v1.Maintenance {
+	if dto == nil {
+		return nil
+	}
+	maintenance := v1.Maintenance(*dto)
+	return &maintenance
+}
+
+func MapAccessToDTO(config *v1.AccessConfig) *httpapiclient.AccessDTO {
+	if config == nil {
+		return nil
+	}
+
+	dto := &httpapiclient.AccessDTO{AllowedCIDRs: config.AllowedCIDRs}
+	if config.BasicAuth != nil {
+		dto.BasicAuth = &httpapiclient.BasicAuthDTO{SecretName: config.BasicAuth.SecretName, Realm: config.BasicAuth.Realm}
+	}
+	return dto
+}
+
+func MapDTOToAccess(dto *httpapiclient.AccessDTO) *v1.AccessConfig {
+	if dto == nil {
+		return nil
+	}
+
+	config := &v1.AccessConfig{AllowedCIDRs: dto.AllowedCIDRs}
+	if dto.BasicAuth != nil {
+		config.BasicAuth = &v1.BasicAuth{SecretName: dto.BasicAuth.SecretName, Realm: dto.BasicAuth.Realm}
+	}
+	return config
+}
+
+func MapServerToDTO(server *v1.ServerConfig) *httpapiclient.ServerDTO {
+	if server == nil {
+		return nil
+	}
+
+	dto := &httpapiclient.ServerDTO{SPA: server.SPA, Gzip: server.Gzip, Headers: server.Headers}
+	for _, rule := range server.Caching {
+		dto.Caching = append(dto.Caching, httpapiclient.CachingRuleDTO(rule))
+	}
+	for _, page := range server.ErrorPages {
+		dto.ErrorPages = append(dto.ErrorPages, httpapiclient.ErrorPageDTO(page))
+	}
+	for _, redirect := range server.Redirects {
+		dto.Redirects = append(dto.Redirects, httpapiclient.RedirectDTO(redirect))
+	}
+	return dto
+}
+
+func MapDTOToServer(dto *httpapiclient.ServerDTO) *v1.ServerConfig {
+	if dto == nil {
+		return nil
+	}
+
+	server := &v1.ServerConfig{SPA: dto.SPA, Gzip: dto.Gzip, Headers: dto.Headers}
+	for _, rule := range dto.Caching {
+		server.Caching = append(server.Caching, v1.CachingRule(rule))
+	}
+	for _, page := range dto.ErrorPages {
+		server.ErrorPages = append(server.ErrorPages, v1.ErrorPage(page))
+	}
+	for _, redirect := range dto.Redirects {
+		server.Redirects = append(server.Redirects, v1.Redirect(redirect))
+	}
+	return server
+}
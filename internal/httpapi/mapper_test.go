@@ -0,0 +1,30 @@
+package httpapi
+
+import (
+	"testing"
+	webv1 "website-operator/api/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMapDryRunToDTO(t *testing.T) {
+	before := &webv1.WebSite{
+		ObjectMeta: metav1.ObjectMeta{Name: "blog"},
+		Spec:       webv1.WebSiteSpec{HtmlContent: "<h1>old</h1>", Hostname: "blog.local", NginxImage: "docker.io/nginx:1.27"},
+	}
+	after := before.DeepCopyObject().(*webv1.WebSite)
+	after.Spec.NginxImage = "docker.io/nginx:1.28"
+
+	dto := MapDryRunToDTO(before, after)
+	if !dto.DryRun || dto.NginxImage != "docker.io/nginx:1.28" {
+		t.Fatalf("unexpected website: %+v", dto)
+	}
+	if len(dto.Diff) != 1 || dto.Diff[0].Field != "spec.nginxImage" || dto.Diff[0].Old != "docker.io/nginx:1.27" {
+		t.Fatalf("unexpected diff: %+v", dto.Diff)
+	}
+
+	created := MapDryRunToDTO(nil, after)
+	if len(created.Diff) != 3 {
+		t.Fatalf("expected all set fields in the diff of a creation, got %+v", created.Diff)
+	}
+}
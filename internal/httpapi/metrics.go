@@ -0,0 +1,133 @@
+package httpapi
+
+import (
+	"context"
+	"strconv"
+	"time"
+	webv1 "website-operator/api/v1"
+	v1 "website-operator/clientset/v1"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+const metricsPath = "/metrics"
+
+// Metrics collects the Prometheus metrics of the HTTP API.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	clientErrors    *prometheus.CounterVec
+}
+
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "httpapi_requests_total",
+			Help: "Number of HTTP requests by route, method and status code.",
+		}, []string{"route", "method", "code"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "httpapi_request_duration_seconds",
+			Help:    "Latency of HTTP requests by route and method.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		clientErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "httpapi_kubernetes_client_errors_total",
+			Help: "Number of failed Kubernetes API calls by operation and reason.",
+		}, []string{"operation", "reason"}),
+	}
+
+	m.registry.MustRegister(
+		m.requests,
+		m.requestDuration,
+		m.clientErrors,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Middleware records count and latency of every request by its route template.
+func (m *Metrics) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		m.requests.WithLabelValues(c.FullPath(), c.Request.Method, strconv.Itoa(c.Writer.Status())).Inc()
+		m.requestDuration.WithLabelValues(c.FullPath(), c.Request.Method).Observe(time.Since(start).Seconds())
+	}
+}
+
+// Register serves the collected metrics on r.
+func (m *Metrics) Register(r *gin.Engine) {
+	r.GET(metricsPath, gin.WrapH(promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})))
+}
+
+// InstrumentClient wraps client to count failed calls to the Kubernetes API.
+func (m *Metrics) InstrumentClient(client v1.WebsiteV1Interface) v1.WebsiteV1Interface {
+	return &instrumentedClient{client: client, metrics: m}
+}
+
+func (m *Metrics) observeClientError(operation string, err error) {
+	if err != nil {
+		m.clientErrors.WithLabelValues(operation, string(apierrors.ReasonForError(err))).Inc()
+	}
+}
+
+type instrumentedClient struct {
+	client  v1.WebsiteV1Interface
+	metrics *Metrics
+}
+
+func (c *instrumentedClient) Websites(namespace string) v1.WebsiteInterface {
+	return &instrumentedWebsiteClient{client: c.client.Websites(namespace), metrics: c.metrics}
+}
+
+type instrumentedWebsiteClient struct {
+	client  v1.WebsiteInterface
+	metrics *Metrics
+}
+
+func (c *instrumentedWebsiteClient) List(ctx context.Context, opts metav1.ListOptions) (*webv1.WebSiteList, error) {
+	result, err := c.client.List(ctx, opts)
+	c.metrics.observeClientError("list", err)
+	return result, err
+}
+
+func (c *instrumentedWebsiteClient) Get(ctx context.Context, name string, opts metav1.GetOptions) (*webv1.WebSite, error) {
+	result, err := c.client.Get(ctx, name, opts)
+	c.metrics.observeClientError("get", err)
+	return result, err
+}
+
+func (c *instrumentedWebsiteClient) Create(ctx context.Context, site *webv1.WebSite, opts metav1.CreateOptions) (*webv1.WebSite, error) {
+	result, err := c.client.Create(ctx, site, opts)
+	c.metrics.observeClientError("create", err)
+	return result, err
+}
+
+func (c *instrumentedWebsiteClient) Update(ctx context.Context, site *webv1.WebSite, opts metav1.UpdateOptions) (*webv1.WebSite, error) {
+	result, err := c.client.Update(ctx, site, opts)
+	c.metrics.observeClientError("update", err)
+	return result, err
+}
+
+func (c *instrumentedWebsiteClient) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	err := c.client.Delete(ctx, name, opts)
+	c.metrics.observeClientError("delete", err)
+	return err
+}
+
+func (c *instrumentedWebsiteClient) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	result, err := c.client.Watch(ctx, opts)
+	c.metrics.observeClientError("watch", err)
+	return result, err
+}
@@ -0,0 +1,433 @@
+package httpapi
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"website-operator/httpapiclient"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OpenAPI is the subset of an OpenAPI 3 document the HTTP API is described with.
+type OpenAPI struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       OpenAPIInfo                      `json:"info"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components OpenAPIComponents                `json:"components"`
+}
+
+type OpenAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type OpenAPIComponents struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+const (
+	openAPIPath     = "/api/openapi.json"
+	openAPIDocsPath = "/api/docs"
+
+	schemaRefPrefix = "#/components/schemas/"
+)
+
+// apiOperation describes a route of NewRouter. Path uses the gin syntax for parameters.
+type apiOperation struct {
+	method      string
+	path        string
+	id          string
+	summary     string
+	query       []Parameter
+	request     any
+	contentType []string
+	status      int
+	response    any
+	stream      bool
+	// produces lists the media types of responses which aren't described by a schema.
+	produces []string
+}
+
+var (
+	namespaceParameter = Parameter{Name: namespaceQueryParam, In: "query", Schema: &Schema{Type: "string"}}
+	dryRunParameter    = Parameter{Name: dryRunQueryParam, In: "query", Schema: &Schema{Type: "boolean"}}
+)
+
+var apiOperations = []apiOperation{
+	{
+		method: http.MethodGet, path: "/api/websites", id: "listWebsites",
+		summary: "List websites",
+		query:   []Parameter{namespaceParameter},
+		status:  http.StatusOK, response: httpapiclient.WebsiteListDTO{},
+	},
+	{
+		method: http.MethodGet, path: "/api/websites/:name", id: "getWebsite",
+		summary: "Get a website",
+		query:   []Parameter{namespaceParameter},
+		status:  http.StatusOK, response: httpapiclient.WebsiteDTO{},
+	},
+	{
+		method: http.MethodPost, path: "/api/websites", id: "createWebsite",
+		summary: "Create a website",
+		query:   []Parameter{namespaceParameter, dryRunParameter},
+		request: httpapiclient.WebsiteCreateDTO{},
+		status:  http.StatusCreated, response: httpapiclient.WebsiteDTO{},
+	},
+	{
+		method: http.MethodPut, path: "/api/websites/:name", id: "updateWebsite",
+		summary: "Update a website",
+		query:   []Parameter{namespaceParameter, dryRunParameter},
+		request: httpapiclient.WebsiteUpdateDTO{},
+		status:  http.StatusAccepted, response: httpapiclient.WebsiteDTO{},
+	},
+	{
+		method: http.MethodDelete, path: "/api/websites/:name", id: "deleteWebsite",
+		summary: "Delete a website",
+		query:   []Parameter{namespaceParameter},
+		status:  http.StatusAccepted,
+	},
+	{
+		method: http.MethodPut, path: "/api/websites/:name/content", id: "uploadWebsiteContent",
+		summary:     "Replace the files of a website with a zip or tar archive",
+		query:       []Parameter{namespaceParameter},
+		contentType: []string{"application/zip", "application/x-tar", "application/gzip", "multipart/form-data"},
+		status:      http.StatusAccepted, response: httpapiclient.WebsiteDTO{},
+	},
+	{
+		method: http.MethodGet, path: "/api/websites/:name/revisions", id: "listWebsiteRevisions",
+		summary: "List the content revisions of a website, newest first",
+		query:   []Parameter{namespaceParameter},
+		status:  http.StatusOK, response: httpapiclient.RevisionListDTO{},
+	},
+	{
+		method: http.MethodPost, path: "/api/websites/:name/rollback", id: "rollbackWebsite",
+		summary: "Restore the content of a website from a revision",
+		query:   []Parameter{namespaceParameter},
+		request: httpapiclient.RollbackDTO{},
+		status:  http.StatusAccepted, response: httpapiclient.WebsiteDTO{},
+	},
+	{
+		method: http.MethodPost, path: "/api/websites/:name/pause", id: "pauseWebsite",
+		summary: "Pause the reconciliation of a website, so its objects can be edited by hand",
+		query:   []Parameter{namespaceParameter},
+		status:  http.StatusAccepted, response: httpapiclient.WebsiteDTO{},
+	},
+	{
+		method: http.MethodPost, path: "/api/websites/:name/resume", id: "resumeWebsite",
+		summary: "Resume the reconciliation of a paused website, reverting changes made to its objects",
+		query:   []Parameter{namespaceParameter},
+		status:  http.StatusAccepted, response: httpapiclient.WebsiteDTO{},
+	},
+	{
+		method: http.MethodPost, path: "/api/websites/:name/maintenance", id: "setWebsiteMaintenance",
+		summary: "Swap a website to a maintenance page answering with status 503, or back to its content",
+		query:   []Parameter{namespaceParameter},
+		request: httpapiclient.MaintenanceDTO{},
+		status:  http.StatusAccepted, response: httpapiclient.WebsiteDTO{},
+	},
+	{
+		method: http.MethodGet, path: "/api/websites/watch", id: "watchWebsites",
+		summary: "Stream changes of all websites as Server-Sent Events",
+		query:   []Parameter{namespaceParameter, {Name: "resourceVersion", In: "query", Schema: &Schema{Type: "string"}}},
+		status:  http.StatusOK, response: httpapiclient.WebsiteEventDTO{}, stream: true,
+	},
+	{
+		method: http.MethodGet, path: "/api/websites/:name/watch", id: "watchWebsite",
+		summary: "Stream changes of a website as Server-Sent Events",
+		query:   []Parameter{namespaceParameter, {Name: "resourceVersion", In: "query", Schema: &Schema{Type: "string"}}},
+		status:  http.StatusOK, response: httpapiclient.WebsiteEventDTO{}, stream: true,
+	},
+	{
+		method: http.MethodGet, path: "/api/websites/export", id: "exportWebsites",
+		summary: "Export the websites of a namespace as YAML documents or a JSON List of manifests",
+		query: []Parameter{namespaceParameter,
+			{Name: formatQueryParam, In: "query", Schema: &Schema{Type: "string", Enum: []string{"yaml", "json"}}}},
+		status: http.StatusOK, produces: []string{"application/yaml", "application/json"},
+	},
+	{
+		method: http.MethodPost, path: "/api/websites/import", id: "importWebsites",
+		summary:     "Create or update the websites of an exported bundle",
+		query:       []Parameter{namespaceParameter, dryRunParameter},
+		contentType: []string{"application/yaml", "application/json"},
+		status:      http.StatusOK, response: httpapiclient.ImportResultDTO{},
+	},
+	{
+		method: http.MethodPost, path: "/api/websites/preview", id: "createPreview",
+		summary:     "Render content under a temporary URL without publishing it, as JSON or a zip or tar archive",
+		request:     httpapiclient.PreviewCreateDTO{},
+		contentType: []string{"application/zip", "application/x-tar", "application/gzip", "multipart/form-data"},
+		status:      http.StatusCreated, response: httpapiclient.PreviewDTO{},
+	},
+	{
+		method: http.MethodGet, path: "/api/websites/:name/preview", id: "previewWebsite",
+		summary: "Render the current content of a website under a temporary URL",
+		query:   []Parameter{namespaceParameter},
+		status:  http.StatusOK, response: httpapiclient.PreviewDTO{},
+	},
+}
+
+// NewOpenAPISpec describes the routes registered by NewRouter.
+func NewOpenAPISpec() *OpenAPI {
+	spec := &OpenAPI{
+		OpenAPI: "3.0.3",
+		Info: OpenAPIInfo{
+			Title:   "Website Operator HTTP API",
+			Version: "v1",
+		},
+		Paths:      map[string]map[string]*Operation{},
+		Components: OpenAPIComponents{Schemas: map[string]*Schema{}},
+	}
+
+	errorSchema := &Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{"error": {Type: "string"}},
+		Required:   []string{"error"},
+	}
+	spec.Components.Schemas["Error"] = errorSchema
+
+	for _, o := range apiOperations {
+		op := &Operation{
+			OperationID: o.id,
+			Summary:     o.summary,
+			Responses: map[string]*Response{
+				"400": {
+					Description: http.StatusText(http.StatusBadRequest),
+					Content:     map[string]*MediaType{"application/json": {Schema: &Schema{Ref: schemaRefPrefix + "Error"}}},
+				},
+			},
+		}
+
+		path, params := openAPIPathOf(o.path)
+		op.Parameters = append(params, o.query...)
+
+		if o.request != nil {
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content:  map[string]*MediaType{"application/json": {Schema: spec.schemaRef(reflect.TypeOf(o.request))}},
+			}
+		}
+		if len(o.contentType) > 0 && op.RequestBody == nil {
+			op.RequestBody = &RequestBody{Required: true, Content: map[string]*MediaType{}}
+		}
+		if len(o.contentType) > 0 {
+			for _, ct := range o.contentType {
+				op.RequestBody.Content[ct] = &MediaType{Schema: &Schema{Type: "string", Format: "binary"}}
+			}
+		}
+
+		response := &Response{Description: http.StatusText(o.status)}
+		if o.response != nil {
+			mediaType := "application/json"
+			if o.stream {
+				mediaType = "text/event-stream"
+			}
+			response.Content = map[string]*MediaType{mediaType: {Schema: spec.schemaRef(reflect.TypeOf(o.response))}}
+		}
+		for _, mediaType := range o.produces {
+			if response.Content == nil {
+				response.Content = map[string]*MediaType{}
+			}
+			response.Content[mediaType] = &MediaType{Schema: &Schema{Type: "string", Format: "binary"}}
+		}
+		op.Responses[strconv.Itoa(o.status)] = response
+
+		if spec.Paths[path] == nil {
+			spec.Paths[path] = map[string]*Operation{}
+		}
+		spec.Paths[path][strings.ToLower(o.method)] = op
+	}
+
+	return spec
+}
+
+// openAPIPathOf converts a gin route path to OpenAPI syntax and returns its path parameters.
+func openAPIPathOf(ginPath string) (string, []Parameter) {
+	var params []Parameter
+	segments := strings.Split(ginPath, "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, ":") {
+			name := s[1:]
+			segments[i] = "{" + name + "}"
+			params = append(params, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// schemaRef returns a reference to the component schema of a named struct type, registering
+// it if necessary, or an inline schema for all other types.
+func (spec *OpenAPI) schemaRef(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t.Kind() == reflect.Struct && t.Name() != "" && t != reflect.TypeOf(time.Time{}) {
+		if _, ok := spec.Components.Schemas[t.Name()]; !ok {
+			// register before descending to terminate on recursive types
+			spec.Components.Schemas[t.Name()] = &Schema{}
+			*spec.Components.Schemas[t.Name()] = *spec.schemaOf(t)
+		}
+		return &Schema{Ref: schemaRefPrefix + t.Name()}
+	}
+	return spec.schemaOf(t)
+}
+
+func (spec *OpenAPI) schemaOf(t reflect.Type) *Schema {
+	switch t {
+	case reflect.TypeOf(time.Time{}):
+		return &Schema{Type: "string", Format: "date-time"}
+	case reflect.TypeOf([]byte{}):
+		return &Schema{Type: "string", Format: "byte"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return spec.schemaOf(t.Elem())
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: spec.schemaRef(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: spec.schemaRef(t.Elem())}
+	case reflect.Struct:
+		s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		spec.addStructFields(s, t)
+		return s
+	default:
+		return &Schema{}
+	}
+}
+
+// addStructFields adds the JSON fields of t to s. Fields of embedded structs are inlined, like
+// encoding/json does. Fields are required if their binding rules require them, which gin
+// enforces when the request is bound as well.
+func (spec *OpenAPI) addStructFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" {
+			spec.addStructFields(s, f.Type)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		s.Properties[name] = spec.schemaRef(f.Type)
+		if slices.Contains(strings.Split(f.Tag.Get("binding"), ","), "required") {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// operation returns the operation registered for a gin route.
+func (spec *OpenAPI) operation(method, ginPath string) *Operation {
+	path, _ := openAPIPathOf(ginPath)
+	return spec.Paths[path][strings.ToLower(method)]
+}
+
+// resolve returns the component schema s refers to, or s itself.
+func (spec *OpenAPI) resolve(s *Schema) *Schema {
+	if s.Ref != "" {
+		return spec.Components.Schemas[strings.TrimPrefix(s.Ref, schemaRefPrefix)]
+	}
+	return s
+}
+
+// DefaultSwaggerUIURL is where the documentation page loads the Swagger UI assets from by default.
+const DefaultSwaggerUIURL = "https://unpkg.com/swagger-ui-dist@5"
+
+func registerOpenAPIRoutes(r *gin.Engine, spec *OpenAPI) {
+	r.GET(openAPIPath, func(c *gin.Context) {
+		c.JSON(http.StatusOK, spec)
+	})
+}
+
+// RegisterDocsRoutes serves a Swagger UI page for the OpenAPI spec, loading the assets from
+// swaggerUIURL, e.g. a mirror of the swagger-ui-dist package for clusters without internet access.
+func RegisterDocsRoutes(r *gin.Engine, swaggerUIURL string) {
+	page := fmt.Sprintf(swaggerUIPage, html.EscapeString(strings.TrimSuffix(swaggerUIURL, "/")), openAPIPath)
+	r.GET(openAPIDocsPath, func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(page))
+	})
+}
+
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Website Operator HTTP API</title>
+  <link rel="stylesheet" href="%[1]s/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="%[1]s/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "%[2]s", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
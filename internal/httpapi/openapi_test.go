@@ -0,0 +1,128 @@
+package httpapi
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestOpenAPISpecMatchesRoutes fails if routes are added to NewRouter without describing
+// them in the OpenAPI spec, or vice versa.
+func TestOpenAPISpecMatchesRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	spec := NewOpenAPISpec()
+	router := NewRouter(NewWebsiteHandler(nil, nil, nil))
+
+	routes := map[string]bool{}
+	for _, route := range router.Routes() {
+		if route.Path == openAPIPath || route.Path == openAPIDocsPath || !strings.HasPrefix(route.Path, "/api/") {
+			continue
+		}
+
+		path, _ := openAPIPathOf(route.Path)
+		routes[route.Method+" "+path] = true
+
+		if spec.Paths[path][strings.ToLower(route.Method)] == nil {
+			t.Errorf("route %s %s is missing in the OpenAPI spec", route.Method, path)
+		}
+	}
+
+	for path, ops := range spec.Paths {
+		for method := range ops {
+			if !routes[strings.ToUpper(method)+" "+path] {
+				t.Errorf("OpenAPI operation %s %s has no route", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
+
+func TestOpenAPISpecSchemas(t *testing.T) {
+	spec := NewOpenAPISpec()
+
+	create := spec.Components.Schemas["WebsiteCreateDTO"]
+	if create == nil {
+		t.Fatalf("schema WebsiteCreateDTO missing")
+	}
+	for _, field := range []string{"name", "htmlContent", "hostname", "nginxImage"} {
+		if create.Properties[field] == nil {
+			t.Errorf("field %s of WebsiteCreateDTO missing", field)
+		}
+	}
+
+	// the content of a website may be uploaded as files after creating it
+	if !slices.Equal(create.Required, []string{"nginxImage", "name"}) {
+		t.Errorf("expected name and nginxImage to be required, got %v", create.Required)
+	}
+
+	website := spec.Components.Schemas["WebsiteDTO"]
+	if website.Properties["creationTimestamp"].Format != "date-time" {
+		t.Errorf("creationTimestamp should be a date-time")
+	}
+	if website.Properties["labels"].AdditionalProperties.Type != "string" {
+		t.Errorf("labels should be a string map")
+	}
+}
+
+func TestValidateRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(ValidateRequest(NewOpenAPISpec()))
+	r.POST("/api/websites", func(c *gin.Context) { c.Status(http.StatusCreated) })
+	r.PUT("/api/websites/:name/content", func(c *gin.Context) { c.Status(http.StatusAccepted) })
+
+	tests := []struct {
+		name        string
+		path        string
+		method      string
+		contentType string
+		body        string
+		status      int
+	}{
+		{"Valid", "/api/websites", http.MethodPost, "application/json",
+			`{"name":"a","hostname":"a.local","htmlContent":"<p>a</p>","nginxImage":"docker.io/nginx:1.28"}`, http.StatusCreated},
+		{"WithoutContent", "/api/websites", http.MethodPost, "application/json",
+			`{"name":"a","hostname":"a.local","nginxImage":"docker.io/nginx:1.28"}`, http.StatusCreated},
+		{"MissingField", "/api/websites", http.MethodPost, "application/json",
+			`{"hostname":"a.local","htmlContent":"<p>a</p>","nginxImage":"docker.io/nginx:1.28"}`, http.StatusBadRequest},
+		{"WrongType", "/api/websites", http.MethodPost, "application/json",
+			`{"name":1,"hostname":"a.local","htmlContent":"<p>a</p>","nginxImage":"docker.io/nginx:1.28"}`, http.StatusBadRequest},
+		{"UnknownField", "/api/websites", http.MethodPost, "application/json",
+			`{"name":"a","hostname":"a.local","htmlContent":"","nginxImage":"docker.io/nginx:1.28","replicas":3}`, http.StatusBadRequest},
+		{"InvalidJSON", "/api/websites", http.MethodPost, "application/json", `{`, http.StatusBadRequest},
+		{"WrongContentType", "/api/websites", http.MethodPost, "text/plain", `a`, http.StatusUnsupportedMediaType},
+		{"ArchiveUpload", "/api/websites/a/content", http.MethodPut, "application/zip", `zip`, http.StatusAccepted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestSwaggerUIURL(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := NewRouter(NewWebsiteHandler(nil, nil, nil))
+	RegisterDocsRoutes(router, "https://assets.example.com/swagger-ui/")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, openAPIDocsPath, nil))
+	if !strings.Contains(w.Body.String(), `src="https://assets.example.com/swagger-ui/swagger-ui-bundle.js"`) {
+		t.Errorf("expected the assets to be loaded from the configured URL: %s", w.Body.String())
+	}
+}
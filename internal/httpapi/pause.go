@@ -0,0 +1,57 @@
+package httpapi
+
+import (
+	"net/http"
+	webv1 "website-operator/api/v1"
+	"website-operator/internal/httpapi/audit"
+
+	"github.com/gin-gonic/gin"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Pause stops the controller from reconciling a website until it's resumed, e.g. to hand-edit
+// its deployment during an incident.
+func (h *WebsiteHandler) Pause(c *gin.Context) {
+	h.setPaused(c, true)
+}
+
+// Resume continues the reconciliation of a paused website. A website suspended by its spec
+// stays paused until the spec is changed.
+func (h *WebsiteHandler) Resume(c *gin.Context) {
+	h.setPaused(c, false)
+}
+
+func (h *WebsiteHandler) setPaused(c *gin.Context, paused bool) {
+	websites := h.kubeClient.Websites(RequestNamespace(c))
+	website, err := websites.Get(c.Request.Context(), c.Param("name"), metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	before := &webv1.WebSite{}
+	website.DeepCopyInto(before)
+
+	if paused {
+		if website.Annotations == nil {
+			website.Annotations = map[string]string{}
+		}
+		website.Annotations[webv1.AnnotationReconcile] = webv1.ReconcilePaused
+	} else {
+		delete(website.Annotations, webv1.AnnotationReconcile)
+	}
+
+	site, err := websites.Update(c.Request.Context(), website, metav1.UpdateOptions{})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	audit.RecordChange(c, before, site)
+	c.JSON(http.StatusAccepted, MapKubeWebsiteToDTO(site))
+}
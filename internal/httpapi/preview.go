@@ -0,0 +1,197 @@
+package httpapi
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+	"website-operator/httpapiclient"
+
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	previewPathPrefix = "/preview/"
+	indexFile         = "index.html"
+
+	defaultPreviewTTL = 15 * time.Minute
+	// maxPreviews bounds the memory used for previews, the oldest preview is evicted beyond it.
+	maxPreviews = 100
+)
+
+type preview struct {
+	files   map[string][]byte
+	created time.Time
+	expires time.Time
+}
+
+// PreviewStore keeps rendered previews in memory under unguessable, expiring tokens.
+type PreviewStore struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu       sync.Mutex
+	previews map[string]*preview
+}
+
+func NewPreviewStore(ttl time.Duration) *PreviewStore {
+	return &PreviewStore{
+		ttl:      ttl,
+		now:      time.Now,
+		previews: map[string]*preview{},
+	}
+}
+
+// Add stores files as a new preview and returns its token and expiry.
+func (s *PreviewStore) Add(files map[string][]byte) (string, time.Time) {
+	b := make([]byte, 32)
+	rand.Read(b)
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.purge()
+	if len(s.previews) >= maxPreviews {
+		s.evictOldest()
+	}
+
+	now := s.now()
+	p := &preview{files: files, created: now, expires: now.Add(s.ttl)}
+	s.previews[token] = p
+	return token, p.expires
+}
+
+// Get returns a file of a preview, or false if the preview or file doesn't exist.
+func (s *PreviewStore) Get(token, name string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.previews[token]
+	if !ok || s.now().After(p.expires) {
+		return nil, false
+	}
+
+	content, ok := p.files[name]
+	return content, ok
+}
+
+// purge removes expired previews. s.mu must be held.
+func (s *PreviewStore) purge() {
+	now := s.now()
+	for token, p := range s.previews {
+		if now.After(p.expires) {
+			delete(s.previews, token)
+		}
+	}
+}
+
+// evictOldest removes the preview created first. s.mu must be held.
+func (s *PreviewStore) evictOldest() {
+	var oldestToken string
+	var oldest *preview
+	for token, p := range s.previews {
+		if oldest == nil || p.created.Before(oldest.created) {
+			oldestToken, oldest = token, p
+		}
+	}
+	delete(s.previews, oldestToken)
+}
+
+// previewFiles merges the HTML content and the files of a website like the controller does.
+func previewFiles(htmlContent string, files map[string][]byte) map[string][]byte {
+	result := make(map[string][]byte, len(files)+1)
+	result[indexFile] = []byte(htmlContent)
+	for name, content := range files {
+		result[name] = content
+	}
+	return result
+}
+
+func (h *WebsiteHandler) respondPreview(c *gin.Context, status int, files map[string][]byte) {
+	token, expires := h.previews.Add(files)
+	c.JSON(status, httpapiclient.PreviewDTO{
+		Token:     token,
+		URL:       previewPathPrefix + token + "/",
+		ExpiresAt: expires,
+	})
+}
+
+// CreatePreview renders submitted content under a temporary URL without creating any
+// Kubernetes objects. The content is either JSON or an archive, like for UploadContent.
+func (h *WebsiteHandler) CreatePreview(c *gin.Context) {
+	if c.ContentType() != "application/json" {
+		files, err := ReadContentArchive(c.Request)
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		h.respondPreview(c, http.StatusCreated, files)
+		return
+	}
+
+	var dto httpapiclient.PreviewCreateDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	files := newContentFiles()
+	for name, content := range dto.Files {
+		if err := files.add(name, strings.NewReader(string(content))); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	h.respondPreview(c, http.StatusCreated, previewFiles(dto.HtmlContent, files.files))
+}
+
+// PreviewWebsite renders the stored content of a website under a temporary URL.
+func (h *WebsiteHandler) PreviewWebsite(c *gin.Context) {
+	website, err := h.kubeClient.Websites(RequestNamespace(c)).Get(c.Request.Context(), c.Param("name"), metav1.GetOptions{})
+	if err != nil {
+		c.JSON(apiErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.respondPreview(c, http.StatusOK, previewFiles(website.Spec.HtmlContent, website.Spec.Files))
+}
+
+// ServePreview serves a file of a preview. The token is the only credential, so previews
+// can be shared with people without API access until they expire.
+func (h *WebsiteHandler) ServePreview(c *gin.Context) {
+	name := strings.TrimPrefix(path.Clean(c.Param("filepath")), "/")
+	if name == "" || strings.HasSuffix(c.Param("filepath"), "/") {
+		name = path.Join(name, indexFile)
+	}
+
+	content, ok := h.previews.Get(c.Param("token"), name)
+	if !ok {
+		c.String(http.StatusNotFound, "preview not found or expired")
+		return
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	// previews run in an opaque origin, so scripts of previewed sites can't act on the API
+	c.Header("Content-Security-Policy", "sandbox allow-scripts allow-forms allow-popups")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("X-Robots-Tag", "noindex")
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, contentType, content)
+}
@@ -0,0 +1,95 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"website-operator/httpapiclient"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPreview(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewWebsiteHandler(nil, nil, nil)
+	now := time.Now()
+	handler.previews.now = func() time.Time { return now }
+	router := NewRouter(handler)
+
+	body := `{"htmlContent":"<h1>draft</h1>","files":{"css/site.css":"Ym9keSB7fQ=="}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/websites/preview", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	var preview httpapiclient.PreviewDTO
+	if err := json.Unmarshal(w.Body.Bytes(), &preview); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	w = get(preview.URL)
+	if w.Code != http.StatusOK || w.Body.String() != "<h1>draft</h1>" {
+		t.Fatalf("unexpected index: %d %q", w.Code, w.Body.String())
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Security-Policy"), "sandbox") {
+		t.Errorf("expected preview to be sandboxed")
+	}
+
+	w = get(preview.URL + "css/site.css")
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/css") {
+		t.Fatalf("unexpected stylesheet: %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	if w := get("/preview/unknown/"); w.Code != http.StatusNotFound {
+		t.Errorf("expected unknown token to be rejected, got %d", w.Code)
+	}
+
+	now = now.Add(defaultPreviewTTL + time.Second)
+	if w := get(preview.URL); w.Code != http.StatusNotFound {
+		t.Errorf("expected expired preview to be rejected, got %d", w.Code)
+	}
+}
+
+func TestPreviewRejectsInvalidFiles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := NewRouter(NewWebsiteHandler(nil, nil, nil))
+
+	body := `{"htmlContent":"","files":{"../secret.html":"PGgxPg=="}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/websites/preview", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+}
+
+func TestPreviewRejectsOversizedArchive(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := NewRouter(NewWebsiteHandler(nil, nil, nil))
+
+	body := bytes.NewReader(make([]byte, maxContentUploadSize+1))
+	req := httptest.NewRequest(http.MethodPost, "/api/websites/preview", body)
+	req.Header.Set("Content-Type", "application/zip")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusRequestEntityTooLarge, w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,26 @@
+package httpapi
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"website-operator/internal/httpapi/audit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestID returns a middleware that assigns every request an ID, unless the caller already
+// sent one, and echoes it in the response, so log and audit entries can be correlated.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(audit.RequestIDHeader)
+		if id == "" {
+			b := make([]byte, 16)
+			rand.Read(b)
+			id = hex.EncodeToString(b)
+			c.Request.Header.Set(audit.RequestIDHeader, id)
+		}
+
+		c.Header(audit.RequestIDHeader, id)
+		c.Next()
+	}
+}
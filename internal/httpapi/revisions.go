@@ -0,0 +1,114 @@
+package httpapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	webv1 "website-operator/api/v1"
+	"website-operator/httpapiclient"
+	"website-operator/internal/httpapi/audit"
+	"website-operator/internal/revision"
+
+	"github.com/gin-gonic/gin"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Revisions lists the recorded revisions of a website, newest first.
+func (h *WebsiteHandler) Revisions(c *gin.Context) {
+	namespace := RequestNamespace(c)
+
+	// revisions are read with the API's own credentials, fetching the website first
+	// ensures the caller may access it
+	website, err := h.kubeClient.Websites(namespace).Get(c.Request.Context(), c.Param("name"), metav1.GetOptions{})
+	if err != nil {
+		c.JSON(apiErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	revisions, err := h.configMaps.ConfigMaps(namespace).List(c.Request.Context(), metav1.ListOptions{LabelSelector: revision.Selector(website.Name)})
+	if err != nil {
+		c.JSON(apiErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	revision.SortNewestFirst(revisions.Items)
+
+	result := make(httpapiclient.RevisionListDTO, 0, len(revisions.Items))
+	for i := range revisions.Items {
+		dto, err := MapRevisionToDTO(&revisions.Items[i])
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		result = append(result, dto)
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// Rollback restores the content of a website from one of its revisions. The controller
+// records the restored state as a new revision.
+func (h *WebsiteHandler) Rollback(c *gin.Context) {
+	namespace := RequestNamespace(c)
+
+	var dto httpapiclient.RollbackDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	website, err := h.kubeClient.Websites(namespace).Get(c.Request.Context(), c.Param("name"), metav1.GetOptions{})
+	if err != nil {
+		c.JSON(apiErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	cm, err := h.configMaps.ConfigMaps(namespace).Get(c.Request.Context(), revision.ObjectName(website.Name, dto.Revision), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("revision %d of website '%s' not found", dto.Revision, website.Name)})
+		return
+	}
+	if err != nil {
+		c.JSON(apiErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	spec, err := revision.SpecFromConfigMap(cm)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	before := &webv1.WebSite{}
+	website.DeepCopyInto(before)
+
+	// the history limit, suspension, access restrictions and maintenance aren't part of a
+	// revision and stay as is
+	spec.RevisionHistoryLimit = website.Spec.RevisionHistoryLimit
+	spec.Suspend = website.Spec.Suspend
+	spec.Access = website.Spec.Access
+	spec.Maintenance = website.Spec.Maintenance
+	if spec.Hostname != website.Spec.Hostname && !h.checkHostname(c, namespace, website.Name, spec.Hostname) {
+		return
+	}
+	website.Spec = spec
+
+	site, err := h.kubeClient.Websites(namespace).Update(c.Request.Context(), website, metav1.UpdateOptions{})
+	if err != nil {
+		c.JSON(apiErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	audit.RecordChange(c, before, site)
+	c.JSON(http.StatusAccepted, MapKubeWebsiteToDTO(site))
+}
+
+// apiErrorStatus returns the status code of an error of the API server, or 502 if the API
+// server couldn't be reached.
+func apiErrorStatus(err error) int {
+	var status apierrors.APIStatus
+	if errors.As(err, &status) && status.Status().Code != 0 {
+		return int(status.Status().Code)
+	}
+	return http.StatusBadGateway
+}
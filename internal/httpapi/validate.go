@@ -0,0 +1,186 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxJSONBodySize limits JSON request bodies, which carry at most the content of one website.
+const maxJSONBodySize = 2 << 20
+
+// ValidateRequest returns a middleware rejecting requests that don't match the parameters and
+// request body schema of their operation in spec.
+func ValidateRequest(spec *OpenAPI) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		op := spec.operation(c.Request.Method, c.FullPath())
+		if op == nil {
+			c.Next()
+			return
+		}
+
+		for _, p := range op.Parameters {
+			if p.In != "query" {
+				continue
+			}
+			value, ok := c.GetQuery(p.Name)
+			if !ok {
+				if p.Required {
+					c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("query parameter '%s' is required", p.Name)})
+					return
+				}
+				continue
+			}
+			if err := spec.validateValue(p.Schema, value, p.Name); err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		if op.RequestBody != nil {
+			mediaType, ok := op.RequestBody.Content[c.ContentType()]
+			if !ok {
+				c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, gin.H{"error": fmt.Sprintf("content type '%s' is not supported", c.ContentType())})
+				return
+			}
+
+			// binary JSON bodies, like import bundles, are validated by their handler
+			if c.ContentType() == "application/json" && mediaType.Schema.Format != "binary" {
+				if err := spec.validateJSONBody(c, mediaType.Schema); err != nil {
+					c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+					return
+				}
+			}
+		}
+
+		c.Next()
+	}
+}
+
+func (spec *OpenAPI) validateJSONBody(c *gin.Context, schema *Schema) error {
+	b, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxJSONBodySize))
+	if err != nil {
+		return fmt.Errorf("couldn't read request body: %w", err)
+	}
+	// the handler decodes the body again
+	c.Request.Body = io.NopCloser(bytes.NewReader(b))
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var body any
+	if err := dec.Decode(&body); err != nil {
+		return fmt.Errorf("invalid JSON body: %w", err)
+	}
+
+	return spec.validateValue(schema, body, "body")
+}
+
+// validateValue checks a decoded JSON value, or a query parameter string, against a schema.
+func (spec *OpenAPI) validateValue(schema *Schema, value any, path string) error {
+	schema = spec.resolve(schema)
+	if schema == nil {
+		return nil
+	}
+
+	switch schema.Type {
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: must be an object", path)
+		}
+		for _, name := range schema.Required {
+			if _, ok := obj[name]; !ok {
+				return fmt.Errorf("%s.%s: is required", path, name)
+			}
+		}
+		for name, v := range obj {
+			propSchema, ok := schema.Properties[name]
+			if !ok {
+				propSchema = schema.AdditionalProperties
+			}
+			if propSchema == nil {
+				return fmt.Errorf("%s.%s: unknown field", path, name)
+			}
+			if v == nil && !slices.Contains(schema.Required, name) {
+				continue
+			}
+			if err := spec.validateValue(propSchema, v, path+"."+name); err != nil {
+				return err
+			}
+		}
+	case "array":
+		arr, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("%s: must be an array", path)
+		}
+		for i, v := range arr {
+			if err := spec.validateValue(schema.Items, v, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s: must be a string", path)
+		}
+		if len(schema.Enum) > 0 && !slices.Contains(schema.Enum, s) {
+			return fmt.Errorf("%s: must be one of %v", path, schema.Enum)
+		}
+		if schema.Format == "byte" {
+			if _, err := base64.StdEncoding.DecodeString(s); err != nil {
+				return fmt.Errorf("%s: must be base64 encoded", path)
+			}
+		}
+	case "integer":
+		if !isJSONInteger(value) {
+			return fmt.Errorf("%s: must be an integer", path)
+		}
+	case "number":
+		if !isJSONNumber(value) {
+			return fmt.Errorf("%s: must be a number", path)
+		}
+	case "boolean":
+		switch v := value.(type) {
+		case bool:
+		case string:
+			if v != "true" && v != "false" {
+				return fmt.Errorf("%s: must be a boolean", path)
+			}
+		default:
+			return fmt.Errorf("%s: must be a boolean", path)
+		}
+	}
+
+	return nil
+}
+
+func isJSONInteger(value any) bool {
+	switch v := value.(type) {
+	case json.Number:
+		_, err := v.Int64()
+		return err == nil
+	case string:
+		_, err := json.Number(v).Int64()
+		return err == nil
+	}
+	return false
+}
+
+func isJSONNumber(value any) bool {
+	switch v := value.(type) {
+	case json.Number:
+		_, err := v.Float64()
+		return err == nil
+	case string:
+		_, err := json.Number(v).Float64()
+		return err == nil
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package httpapi
+
+import (
+	"io"
+	"net/http"
+	"time"
+	webv1 "website-operator/api/v1"
+	"website-operator/httpapiclient"
+	"website-operator/internal/httpserver"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+const (
+	lastEventIDHeader  = "Last-Event-ID"
+	sseHeartbeatPeriod = 15 * time.Second
+)
+
+// Watch streams changes of all websites, or of the website given by the name parameter,
+// as Server-Sent Events. The event ID is the resourceVersion of the website, so clients
+// resume a dropped stream by sending it back as Last-Event-ID.
+func (h *WebsiteHandler) Watch(c *gin.Context) {
+	opts := metav1.ListOptions{
+		ResourceVersion: c.GetHeader(lastEventIDHeader),
+	}
+	if opts.ResourceVersion == "" {
+		opts.ResourceVersion = c.Query("resourceVersion")
+	}
+	if name := c.Param("name"); name != "" {
+		opts.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+	}
+
+	watcher, err := h.kubeClient.Websites(RequestNamespace(c)).Watch(c.Request.Context(), opts)
+	if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+		// clients watch from the current state instead
+		c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer watcher.Stop()
+
+	// the server's write timeout would cut off the stream, it's ended by the client or the watch instead
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Header("Content-Type", sse.ContentType)
+
+	// send the headers right away, clients wait for them before the first event
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeatPeriod)
+	defer heartbeat.Stop()
+	// the server waits for all requests on shutdown, clients resume the stream from another instance
+	stopping := httpserver.Stopping(c.Request.Context())
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-stopping:
+			return false
+		case <-heartbeat.C:
+			// comment lines keep proxies from closing idle streams
+			_, err := io.WriteString(w, ": heartbeat\n\n")
+			return err == nil
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return false
+			}
+			return writeWatchEvent(c, event)
+		}
+	})
+}
+
+func writeWatchEvent(c *gin.Context, event watch.Event) bool {
+	if event.Type == watch.Error {
+		dto := httpapiclient.WebsiteEventDTO{Type: string(watch.Error), Error: apierrors.FromObject(event.Object).Error()}
+		if status, ok := event.Object.(*metav1.Status); ok {
+			// clients watch from the current state instead if the resourceVersion is gone
+			dto.Code = int(status.Code)
+		}
+		c.Render(-1, sse.Event{Event: string(watch.Error), Data: dto})
+		return false
+	}
+
+	site, ok := event.Object.(*webv1.WebSite)
+	if !ok {
+		return true
+	}
+
+	c.Render(-1, sse.Event{
+		Id:    site.ResourceVersion,
+		Event: string(event.Type),
+		Data: httpapiclient.WebsiteEventDTO{
+			Type:    string(event.Type),
+			Website: MapKubeWebsiteToDTO(site),
+		},
+	})
+	return true
+}
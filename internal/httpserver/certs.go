@@ -0,0 +1,84 @@
+package httpserver
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// certCheckInterval limits how often the certificate files are checked for changes.
+const certCheckInterval = 10 * time.Second
+
+// CertReloader serves a TLS certificate from files and picks up renewed certificates,
+// e.g. written by cert-manager, without restarting the server.
+type CertReloader struct {
+	certFile string
+	keyFile  string
+
+	mu          sync.Mutex
+	cert        *tls.Certificate
+	certModTime time.Time
+	keyModTime  time.Time
+	lastCheck   time.Time
+	now         func() time.Time
+}
+
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("both a TLS certificate and key file are required")
+	}
+
+	r := &CertReloader{certFile: certFile, keyFile: keyFile, now: time.Now}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate.
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.now().Sub(r.lastCheck) >= certCheckInterval {
+		if err := r.reload(); err != nil {
+			// keep serving the previous certificate, the files may be mid-rotation
+			log.Printf("couldn't reload TLS certificate: %s", err)
+		}
+	}
+	return r.cert, nil
+}
+
+// reload loads the key pair if either file changed since the last load. r.mu must be held.
+func (r *CertReloader) reload() error {
+	r.lastCheck = r.now()
+
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return err
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return err
+	}
+
+	if r.cert != nil && certInfo.ModTime().Equal(r.certModTime) && keyInfo.ModTime().Equal(r.keyModTime) {
+		return nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("couldn't load TLS key pair: %w", err)
+	}
+
+	if r.cert != nil {
+		log.Printf("reloaded TLS certificate from %s", r.certFile)
+	}
+	r.cert = &cert
+	r.certModTime = certInfo.ModTime()
+	r.keyModTime = keyInfo.ModTime()
+	return nil
+}
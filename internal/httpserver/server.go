@@ -0,0 +1,148 @@
+package httpserver
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Options configures a Server. Zero timeouts disable the respective limit.
+type Options struct {
+	Addr string
+
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+
+	// DrainPeriod is the time the server keeps serving after a shutdown signal while reporting
+	// not ready, so load balancers stop sending new requests before connections are closed.
+	DrainPeriod time.Duration
+	// ShutdownTimeout limits how long in-flight requests may take to complete on shutdown.
+	ShutdownTimeout time.Duration
+
+	// TLSCertFile and TLSKeyFile enable HTTPS. Both files are reloaded when they change.
+	TLSCertFile string
+	TLSKeyFile  string
+}
+
+// Server is an HTTP server with graceful shutdown and optional TLS.
+type Server struct {
+	opts     Options
+	server   *http.Server
+	certs    *CertReloader
+	draining atomic.Bool
+	// stopping is closed once the shutdown starts, see Stopping
+	stopping chan struct{}
+}
+
+type stoppingKey struct{}
+
+// Stopping returns a channel which is closed once the server of the request with ctx shuts down.
+// The shutdown waits for all requests to complete, so long-lived responses such as event streams
+// end on it. The channel is nil for requests not served by a Server.
+func Stopping(ctx context.Context) <-chan struct{} {
+	stopping, _ := ctx.Value(stoppingKey{}).(chan struct{})
+	return stopping
+}
+
+func New(handler http.Handler, opts Options) (*Server, error) {
+	s := &Server{
+		opts:     opts,
+		stopping: make(chan struct{}),
+		server: &http.Server{
+			Addr:              opts.Addr,
+			Handler:           handler,
+			ReadHeaderTimeout: opts.ReadHeaderTimeout,
+			ReadTimeout:       opts.ReadTimeout,
+			WriteTimeout:      opts.WriteTimeout,
+			IdleTimeout:       opts.IdleTimeout,
+		},
+	}
+	s.server.BaseContext = func(net.Listener) context.Context {
+		return context.WithValue(context.Background(), stoppingKey{}, s.stopping)
+	}
+	s.server.RegisterOnShutdown(func() { close(s.stopping) })
+
+	if opts.TLSCertFile != "" || opts.TLSKeyFile != "" {
+		certs, err := NewCertReloader(opts.TLSCertFile, opts.TLSKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		s.certs = certs
+		s.server.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: certs.GetCertificate,
+		}
+	}
+
+	return s, nil
+}
+
+// Ready fails once the server is draining, to be used as readiness check.
+func (s *Server) Ready(context.Context) error {
+	if s.draining.Load() {
+		return errors.New("server is shutting down")
+	}
+	return nil
+}
+
+// Run serves until ctx is cancelled, then drains and gracefully shuts down the server.
+func (s *Server) Run(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.opts.Addr)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(ctx, listener)
+}
+
+// Serve is like Run, but accepts connections on the given listener.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		if s.certs != nil {
+			log.Printf("serving HTTPS on %s", listener.Addr())
+			serveErr <- s.server.ServeTLS(listener, "", "")
+		} else {
+			log.Printf("serving HTTP on %s", listener.Addr())
+			serveErr <- s.server.Serve(listener)
+		}
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	s.draining.Store(true)
+	if s.opts.DrainPeriod > 0 {
+		log.Printf("draining for %s before shutdown", s.opts.DrainPeriod)
+		time.Sleep(s.opts.DrainPeriod)
+	}
+
+	shutdownCtx := context.Background()
+	if s.opts.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		shutdownCtx, cancel = context.WithTimeout(shutdownCtx, s.opts.ShutdownTimeout)
+		defer cancel()
+	}
+
+	log.Printf("shutting down, waiting for in-flight requests")
+	if err := s.server.Shutdown(shutdownCtx); err != nil {
+		s.server.Close()
+		return fmt.Errorf("graceful shutdown failed: %w", err)
+	}
+
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package httpserver
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeCert(t *testing.T, dir, commonName string, modTime time.Time) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	os.Chtimes(certFile, modTime, modTime)
+	os.Chtimes(keyFile, modTime, modTime)
+	return certFile, keyFile
+}
+
+func commonName(t *testing.T, cert *tls.Certificate) string {
+	t.Helper()
+
+	parsed, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed.Subject.CommonName
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Now().Add(-time.Minute)
+	certFile, keyFile := writeCert(t, dir, "first", start)
+
+	r, err := NewCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	cert, _ := r.GetCertificate(nil)
+	if commonName(t, cert) != "first" {
+		t.Fatalf("expected first certificate")
+	}
+
+	writeCert(t, dir, "second", start.Add(time.Second))
+
+	cert, _ = r.GetCertificate(nil)
+	if commonName(t, cert) != "first" {
+		t.Fatalf("expected files not to be checked before the interval passed")
+	}
+
+	now = now.Add(certCheckInterval)
+	cert, _ = r.GetCertificate(nil)
+	if commonName(t, cert) != "second" {
+		t.Fatalf("expected renewed certificate to be served")
+	}
+
+	os.WriteFile(keyFile, []byte("broken"), 0o600)
+	now = now.Add(certCheckInterval)
+	cert, _ = r.GetCertificate(nil)
+	if commonName(t, cert) != "second" {
+		t.Fatalf("expected previous certificate to be kept on reload errors")
+	}
+}
+
+func TestServerGracefulShutdown(t *testing.T) {
+	requestStarted, streamStarted := make(chan struct{}), make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/stream" {
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			close(streamStarted)
+			select {
+			case <-Stopping(r.Context()):
+				io.WriteString(w, "stopped")
+			case <-r.Context().Done():
+			}
+			return
+		}
+		close(requestStarted)
+		time.Sleep(200 * time.Millisecond)
+		io.WriteString(w, "done")
+	})
+
+	server, err := New(handler, Options{DrainPeriod: 50 * time.Millisecond, ShutdownTimeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() { runErr <- server.Serve(ctx, listener) }()
+
+	get := func(path string) <-chan string {
+		body := make(chan string, 1)
+		go func() {
+			resp, err := http.Get("http://" + listener.Addr().String() + path)
+			if err != nil {
+				body <- err.Error()
+				return
+			}
+			defer resp.Body.Close()
+			b, _ := io.ReadAll(resp.Body)
+			body <- string(b)
+		}()
+		return body
+	}
+
+	// an open stream mustn't hold up the shutdown until its timeout
+	stream := get("/stream")
+	<-streamStarted
+	body := get("/")
+
+	<-requestStarted
+	started := time.Now()
+	cancel()
+
+	time.Sleep(10 * time.Millisecond)
+	if server.Ready(context.Background()) == nil {
+		t.Errorf("expected server not to be ready while draining")
+	}
+
+	if b := <-body; b != "done" {
+		t.Fatalf("expected in-flight request to complete, got %q", b)
+	}
+	if b := <-stream; b != "stopped" {
+		t.Fatalf("expected stream to end on shutdown, got %q", b)
+	}
+	if err := <-runErr; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("expected shutdown without waiting for the stream, took %s", elapsed)
+	}
+}
@@ -0,0 +1,106 @@
+// Package imagepolicy restricts the nginx images websites may run, and resolves image tags to
+// digests, so a website keeps running the image it was rolled out with.
+package imagepolicy
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"website-operator/internal"
+)
+
+// Policy restricts the images of websites. The HTTP API rejects websites violating it, and the
+// controller doesn't roll them out.
+type Policy struct {
+	// Allowed lists the registries, e.g. registry.example.com, and repositories, e.g.
+	// docker.io/nginx, images may be pulled from. A trailing /* allows all repositories below a
+	// path, e.g. registry.example.com/web/*.
+	Allowed []string
+	// TagPattern must match the whole tag of images, unless nil.
+	TagPattern *regexp.Regexp
+	// RequireDigest rejects images which aren't pinned to a digest.
+	RequireDigest bool
+}
+
+// Violation is returned for images which don't comply with a policy.
+type Violation struct {
+	Image  string
+	Reason string
+}
+
+func (v *Violation) Error() string {
+	return fmt.Sprintf("image '%s' violates the image policy: %s", v.Image, v.Reason)
+}
+
+// Default allows the official nginx images with any tag, as the API did before policies were
+// configurable.
+func Default() *Policy {
+	return &Policy{Allowed: []string{"docker.io/nginx"}}
+}
+
+// FromEnv reads the policy from the environment, shared by the controller and the HTTP API:
+//
+//	IMAGE_POLICY_ALLOWED          comma-separated registries and repositories, default docker.io/nginx
+//	IMAGE_POLICY_TAG_PATTERN      regular expression tags must match, default any tag
+//	IMAGE_POLICY_REQUIRE_DIGEST   rejects images without a digest, default false
+func FromEnv() (*Policy, error) {
+	policy := Default()
+
+	if allowed := internal.FromEnvWithDefault("IMAGE_POLICY_ALLOWED", ""); allowed != "" {
+		policy.Allowed = nil
+		for _, entry := range strings.Split(allowed, ",") {
+			if entry = strings.TrimSpace(entry); entry != "" {
+				policy.Allowed = append(policy.Allowed, entry)
+			}
+		}
+	}
+
+	if pattern := internal.FromEnvWithDefault("IMAGE_POLICY_TAG_PATTERN", ""); pattern != "" {
+		var err error
+		if policy.TagPattern, err = regexp.Compile("^(?:" + pattern + ")$"); err != nil {
+			return nil, fmt.Errorf("invalid regular expression in IMAGE_POLICY_TAG_PATTERN: %w", err)
+		}
+	}
+
+	var err error
+	if policy.RequireDigest, err = internal.BoolFromEnvWithDefault("IMAGE_POLICY_REQUIRE_DIGEST", false); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// Check parses image and checks it against the policy. Errors are always a *Violation.
+func (p *Policy) Check(image string) (Reference, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return Reference{}, &Violation{Image: image, Reason: err.Error()}
+	}
+
+	if !p.allows(ref) {
+		return Reference{}, &Violation{Image: image, Reason: fmt.Sprintf("%s isn't an allowed registry or repository", ref.Name())}
+	}
+	if p.RequireDigest && ref.Digest == "" {
+		return Reference{}, &Violation{Image: image, Reason: "the image must be pinned to a digest"}
+	}
+	// images without a tag or digest are pulled as latest, pinned images may come without a tag
+	tag := ref.Tag
+	if tag == "" && ref.Digest == "" {
+		tag = "latest"
+	}
+	if p.TagPattern != nil && tag != "" && !p.TagPattern.MatchString(tag) {
+		return Reference{}, &Violation{Image: image, Reason: fmt.Sprintf("tag '%s' doesn't match %s", tag, p.TagPattern)}
+	}
+	return ref, nil
+}
+
+func (p *Policy) allows(ref Reference) bool {
+	for _, entry := range p.Allowed {
+		switch {
+		case entry == ref.Registry || entry == ref.Name():
+			return true
+		case strings.HasSuffix(entry, "/*") && strings.HasPrefix(ref.Name(), strings.TrimSuffix(entry, "*")):
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package imagepolicy
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+const testDigest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		image   string
+		want    Reference
+		wantErr bool
+	}{
+		{image: "nginx", want: Reference{Registry: "docker.io", Repository: "nginx"}},
+		{image: "docker.io/nginx:1.28", want: Reference{Registry: "docker.io", Repository: "nginx", Tag: "1.28"}},
+		{image: "team/site:v1", want: Reference{Registry: "docker.io", Repository: "team/site", Tag: "v1"}},
+		{image: "localhost:5000/web/site", want: Reference{Registry: "localhost:5000", Repository: "web/site"}},
+		{image: "registry.example.com/nginx:1.28@" + testDigest,
+			want: Reference{Registry: "registry.example.com", Repository: "nginx", Tag: "1.28", Digest: testDigest}},
+		{image: "docker.io/nginx@sha256:abc", wantErr: true},
+		{image: "docker.io/Nginx:1.28", wantErr: true},
+		{image: "docker.io/nginx:", wantErr: true},
+		{image: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			got, err := ParseReference(tt.image)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseReference() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseReference() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyCheck(t *testing.T) {
+	policy := &Policy{
+		Allowed:    []string{"docker.io/nginx", "registry.example.com", "ghcr.io/web/*"},
+		TagPattern: regexp.MustCompile(`^(?:1\.\d+(-alpine)?|latest)$`),
+	}
+
+	tests := []struct {
+		image string
+		// violation is a part of the reason, empty if the image is allowed
+		violation string
+	}{
+		{image: "docker.io/nginx:1.28"},
+		{image: "nginx:1.28-alpine"},
+		{image: "nginx"},
+		{image: "docker.io/nginx@" + testDigest},
+		{image: "registry.example.com/any/repo:1.0"},
+		{image: "ghcr.io/web/site:1.2"},
+		{image: "ghcr.io/other/site:1.2", violation: "ghcr.io/other/site isn't an allowed registry or repository"},
+		{image: "docker.io/nginxx:1.28", violation: "isn't an allowed registry"},
+		{image: "evil/nginx", violation: "docker.io/evil/nginx isn't an allowed registry"},
+		{image: "docker.io/nginx:mainline", violation: "tag 'mainline' doesn't match"},
+		{image: "docker.io/nginx:1.28 ", violation: "invalid tag"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			_, err := policy.Check(tt.image)
+			if tt.violation == "" {
+				if err != nil {
+					t.Errorf("Check() error = %v", err)
+				}
+				return
+			}
+
+			var violation *Violation
+			if !errors.As(err, &violation) || !strings.Contains(violation.Reason, tt.violation) {
+				t.Errorf("Check() error = %v, want violation %q", err, tt.violation)
+			}
+		})
+	}
+}
+
+func TestPolicyRequireDigest(t *testing.T) {
+	policy := Default()
+	policy.RequireDigest = true
+
+	if _, err := policy.Check("docker.io/nginx:1.28"); err == nil {
+		t.Error("expected image without digest to be rejected")
+	}
+	ref, err := policy.Check("docker.io/nginx:1.28@" + testDigest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref.Digest != testDigest {
+		t.Errorf("expected digest %s, got %s", testDigest, ref.Digest)
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("IMAGE_POLICY_ALLOWED", "registry.example.com, docker.io/nginx")
+	t.Setenv("IMAGE_POLICY_TAG_PATTERN", `1\.\d+`)
+	t.Setenv("IMAGE_POLICY_REQUIRE_DIGEST", "true")
+
+	policy, err := FromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(policy.Allowed) != 2 || policy.Allowed[0] != "registry.example.com" || !policy.RequireDigest {
+		t.Errorf("unexpected policy: %+v", policy)
+	}
+	// the pattern has to match the whole tag
+	if policy.TagPattern.MatchString("1.28-alpine") {
+		t.Errorf("expected tag pattern %s not to match a part of the tag", policy.TagPattern)
+	}
+
+	t.Setenv("IMAGE_POLICY_TAG_PATTERN", "(")
+	if _, err := FromEnv(); err == nil {
+		t.Error("expected invalid tag pattern to fail")
+	}
+}
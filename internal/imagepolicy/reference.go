@@ -0,0 +1,77 @@
+package imagepolicy
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultRegistry is the registry of images which don't name one, e.g. nginx:1.28.
+const DefaultRegistry = "docker.io"
+
+var (
+	pathComponentPattern = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*$`)
+	tagPattern           = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+	digestPattern        = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+)
+
+// Reference is a parsed image reference, e.g. docker.io/nginx:1.28@sha256:...
+type Reference struct {
+	// Registry is the host of the registry, defaulting to docker.io.
+	Registry string
+	// Repository is the path of the image within the registry, e.g. nginx or team/site.
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// ParseReference parses image, which may name a registry, tag and digest.
+func ParseReference(image string) (Reference, error) {
+	var ref Reference
+	name := image
+
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, ref.Digest = name[:i], name[i+1:]
+		if !digestPattern.MatchString(ref.Digest) {
+			return Reference{}, fmt.Errorf("invalid digest '%s'", ref.Digest)
+		}
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.Tag = name[:i], name[i+1:]
+		if !tagPattern.MatchString(ref.Tag) {
+			return Reference{}, fmt.Errorf("invalid tag '%s'", ref.Tag)
+		}
+	}
+
+	ref.Registry, ref.Repository = DefaultRegistry, name
+	if host, path, ok := strings.Cut(name, "/"); ok && (strings.ContainsAny(host, ".:") || host == "localhost") {
+		ref.Registry, ref.Repository = host, path
+	}
+
+	if ref.Repository == "" {
+		return Reference{}, fmt.Errorf("image '%s' has no repository", image)
+	}
+	for _, component := range strings.Split(ref.Repository, "/") {
+		if !pathComponentPattern.MatchString(component) {
+			return Reference{}, fmt.Errorf("invalid repository '%s'", ref.Repository)
+		}
+	}
+	return ref, nil
+}
+
+// Name returns the registry and repository of the image, e.g. docker.io/nginx.
+func (r Reference) Name() string {
+	return r.Registry + "/" + r.Repository
+}
+
+// String returns the full reference, including the registry.
+func (r Reference) String() string {
+	s := r.Name()
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
@@ -0,0 +1,157 @@
+package imagepolicy
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// manifestMediaTypes are accepted for manifests, so the digest of a multi-arch image is the one
+// of its index rather than of a single platform.
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// Resolver resolves image tags to digests with the registry HTTP API. Registries requiring
+// authentication are accessed with an anonymous token.
+type Resolver struct {
+	client *http.Client
+	// insecure registries are accessed via plain HTTP, e.g. a registry on localhost
+	insecure []string
+}
+
+// NewResolver creates a resolver sending its requests with client.
+func NewResolver(client *http.Client, insecureRegistries []string) *Resolver {
+	return &Resolver{client: client, insecure: insecureRegistries}
+}
+
+// Resolve returns the digest the tag of ref points to. Images without a tag resolve latest.
+func (r *Resolver) Resolve(ctx context.Context, ref Reference) (string, error) {
+	registry, repository := ref.Registry, ref.Repository
+	// docker.io is served by registry-1.docker.io, where official images live below library/
+	if registry == DefaultRegistry {
+		registry = "registry-1.docker.io"
+		if !strings.Contains(repository, "/") {
+			repository = "library/" + repository
+		}
+	}
+
+	tag := ref.Tag
+	if tag == "" {
+		tag = "latest"
+	}
+
+	scheme := "https"
+	if slices.Contains(r.insecure, ref.Registry) {
+		scheme = "http"
+	}
+	manifestURL := fmt.Sprintf("%s://%s/v2/%s/manifests/%s", scheme, registry, repository, tag)
+
+	res, err := r.getManifest(ctx, manifestURL, "")
+	if err != nil {
+		return "", err
+	}
+	if res.StatusCode == http.StatusUnauthorized {
+		res.Body.Close()
+		token, err := r.token(ctx, res.Header.Get("WWW-Authenticate"))
+		if err != nil {
+			return "", err
+		}
+		if res, err = r.getManifest(ctx, manifestURL, token); err != nil {
+			return "", err
+		}
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry responded with %s for %s", res.Status, ref)
+	}
+	if digest := res.Header.Get("Docker-Content-Digest"); digestPattern.MatchString(digest) {
+		return digest, nil
+	}
+
+	// registries aren't required to send the digest, it's the hash of the manifest then
+	hash := sha256.New()
+	if _, err := io.Copy(hash, res.Body); err != nil {
+		return "", fmt.Errorf("couldn't read manifest of %s: %w", ref, err)
+	}
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func (r *Resolver) getManifest(ctx context.Context, manifestURL, token string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get manifest: %w", err)
+	}
+	return res, nil
+}
+
+// token requests an anonymous token from the realm of a Bearer challenge.
+func (r *Resolver) token(ctx context.Context, challenge string) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", fmt.Errorf("unsupported authentication challenge '%s'", challenge)
+	}
+
+	attributes := map[string]string{}
+	for _, param := range strings.Split(params, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		attributes[key] = strings.Trim(value, `"`)
+	}
+	realm, err := url.Parse(attributes["realm"])
+	if err != nil || realm.Host == "" {
+		return "", fmt.Errorf("invalid realm in authentication challenge '%s'", challenge)
+	}
+
+	query := realm.Query()
+	for _, key := range []string{"service", "scope"} {
+		if attributes[key] != "" {
+			query.Set(key, attributes[key])
+		}
+	}
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	res, err := r.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("couldn't get registry token: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry token endpoint responded with %s", res.Status)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("couldn't decode registry token: %w", err)
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	return body.AccessToken, nil
+}
@@ -0,0 +1,78 @@
+package imagepolicy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// registry is a stand-in for a registry serving the manifests of web/site by tag, which requires
+// an anonymous token issued by its own realm. Tags with an empty digest are served without one.
+func registry(t *testing.T, digests map[string]string) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			if r.URL.Query().Get("scope") != "repository:web/site:pull" {
+				t.Errorf("unexpected token scope %q", r.URL.Query().Get("scope"))
+			}
+			_, _ = w.Write([]byte(`{"token":"anonymous"}`))
+		case r.Header.Get("Authorization") != "Bearer anonymous":
+			w.Header().Set("WWW-Authenticate",
+				`Bearer realm="`+server.URL+`/token",service="registry",scope="repository:web/site:pull"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		case strings.HasPrefix(r.URL.Path, "/v2/web/site/manifests/"):
+			if !strings.Contains(r.Header.Get("Accept"), "application/vnd.oci.image.index.v1+json") {
+				t.Errorf("expected image indexes to be accepted, got %q", r.Header.Get("Accept"))
+			}
+			digest, ok := digests[strings.TrimPrefix(r.URL.Path, "/v2/web/site/manifests/")]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			if digest != "" {
+				w.Header().Set("Docker-Content-Digest", digest)
+			}
+			_, _ = w.Write([]byte(`{"schemaVersion":2}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestResolverResolve(t *testing.T) {
+	server := registry(t, map[string]string{"1.28": testDigest, "latest": ""})
+	host := strings.TrimPrefix(server.URL, "http://")
+	resolver := NewResolver(server.Client(), []string{host})
+
+	ref, err := ParseReference(host + "/web/site:1.28")
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest, err := resolver.Resolve(context.Background(), ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digest != testDigest {
+		t.Errorf("expected digest %s, got %s", testDigest, digest)
+	}
+
+	// without a Docker-Content-Digest header the manifest is hashed
+	ref.Tag = ""
+	digest, err = resolver.Resolve(context.Background(), ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "sha256:bafebd36189ad3688b7b3915ea55d461e0bfcfbdde11e54b0a123999fb6be50f"; digest != want {
+		t.Errorf("expected hash of the manifest %s, got %s", want, digest)
+	}
+
+	ref.Tag = "missing"
+	if _, err := resolver.Resolve(context.Background(), ref); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("expected missing tag to fail, got %v", err)
+	}
+}
@@ -0,0 +1,336 @@
+// Package nginxconf renders the server configuration of a website into an nginx configuration
+// file, which replaces the default server of the nginx image.
+package nginxconf
+
+import (
+	"fmt"
+	"maps"
+	"net/netip"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	webv1 "website-operator/api/v1"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// File is the name of the rendered configuration, which is mounted into /etc/nginx/conf.d.
+const File = "default.conf"
+
+// The values of a server configuration are restricted to characters without a meaning in the
+// nginx configuration syntax, so they can't inject directives.
+var (
+	headerNamePattern = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+	pathPattern       = regexp.MustCompile(`^/[A-Za-z0-9._~%@+/-]*$`)
+	targetPattern     = regexp.MustCompile(`^(https?://[A-Za-z0-9.-]+(:[0-9]+)?)?(/[A-Za-z0-9._~%@+/?&=#:,-]*)?$`)
+	extensionPattern  = regexp.MustCompile(`^[A-Za-z0-9]+$`)
+	fileNamePattern   = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+)
+
+var serverTemplate = template.Must(template.New(File).Parse(`# rendered by the website controller, changes are overwritten
+server {
+    listen 80;
+    listen [::]:80;
+    server_name _;
+
+    root /usr/share/nginx/html;
+    index index.html;
+{{- with .Access}}
+{{- if .TrustedProxies}}
+{{range .TrustedProxies}}
+    set_real_ip_from {{.}};
+{{- end}}
+    real_ip_header X-Forwarded-For;
+    real_ip_recursive on;
+{{- end}}
+{{- if .AllowedCIDRs}}
+{{range .AllowedCIDRs}}
+    allow {{.}};
+{{- end}}
+    deny all;
+{{- end}}
+{{- if .UserFile}}
+
+    auth_basic "{{.Realm}}";
+    auth_basic_user_file {{.UserFile}};
+{{- end}}
+{{- end}}
+{{- with .Maintenance}}
+
+    # maintenance mode, the content is kept but every request is answered by the maintenance page.
+    # try_files answers after the access checks, whereas return would answer before them.
+    error_page 503 @maintenance;
+
+    location / {
+        root {{.Root}};
+        try_files /.unavailable =503;
+    }
+
+    location @maintenance {
+        root {{.Root}};
+        rewrite ^ /{{.File}} break;
+        add_header Cache-Control "no-store" always;
+{{- if .RetryAfterSeconds}}
+        add_header Retry-After {{.RetryAfterSeconds}} always;
+{{- end}}
+    }
+{{- end}}
+{{- if .Gzip}}
+
+    gzip on;
+    gzip_vary on;
+    gzip_types text/plain text/css text/xml application/javascript application/json application/xml image/svg+xml;
+{{- end}}
+{{- range .Headers}}
+    add_header {{.Name}} "{{.Value}}" always;
+{{- end}}
+{{- if not .Maintenance}}
+{{- range .ErrorPages}}
+    error_page {{.Codes}} {{.Path}};
+{{- end}}
+{{- range .Redirects}}
+
+    location = {{.From}} {
+        return {{.Status}} "{{.To}}";
+    }
+{{- end}}
+{{- range .Caching}}
+
+    location ~ "{{.Pattern}}" {
+{{- range $.Headers}}
+        add_header {{.Name}} "{{.Value}}" always;
+{{- end}}
+        add_header Cache-Control "{{.CacheControl}}" always;
+        try_files $uri =404;
+    }
+{{- end}}
+
+    location / {
+        try_files $uri $uri/ {{if .SPA}}/index.html{{else}}=404{{end}};
+    }
+{{- end}}
+}
+`))
+
+type header struct{ Name, Value string }
+
+type errorPage struct{ Codes, Path string }
+
+type redirect struct {
+	From, To string
+	Status   int
+}
+
+type cachingRule struct{ Pattern, CacheControl string }
+
+type server struct {
+	SPA, Gzip   bool
+	Access      *Access
+	Maintenance *Maintenance
+	Headers     []header
+	ErrorPages  []errorPage
+	Redirects   []redirect
+	Caching     []cachingRule
+}
+
+// Access restricts the access to the server, if the nginx of a website enforces it rather than
+// the ingress controller.
+type Access struct {
+	// AllowedCIDRs are the networks clients are allowed from, all if empty.
+	AllowedCIDRs []string
+	// TrustedProxies are the networks of the ingress controller, whose X-Forwarded-For header
+	// is trusted for the client address.
+	TrustedProxies []string
+	// UserFile is the htpasswd file of the users allowed to log in to Realm, basic auth is
+	// disabled if empty.
+	UserFile, Realm string
+}
+
+// Maintenance answers all requests with status 503 and the maintenance page File in the
+// directory Root.
+type Maintenance struct {
+	Root, File        string
+	RetryAfterSeconds int32
+}
+
+// Options are the restrictions of the server set by the controller rather than by the server
+// configuration of a website.
+type Options struct {
+	// Access is enforced by nginx unless nil.
+	Access *Access
+	// Maintenance is served instead of the content unless nil.
+	Maintenance *Maintenance
+}
+
+// Validate checks the server configuration at path, which is valid if nil.
+func Validate(config *webv1.ServerConfig, path *field.Path) field.ErrorList {
+	if config == nil {
+		return nil
+	}
+
+	var errs field.ErrorList
+	for _, name := range slices.Sorted(maps.Keys(config.Headers)) {
+		value := config.Headers[name]
+		if !headerNamePattern.MatchString(name) {
+			errs = append(errs, field.Invalid(path.Child("headers").Key(name), name, "must be a header name"))
+		}
+		if invalidValue(value) {
+			errs = append(errs, field.Invalid(path.Child("headers").Key(name), value, "must not contain control characters or $"))
+		}
+	}
+
+	for i, rule := range config.Caching {
+		rulePath := path.Child("caching").Index(i)
+		if rule.Path != "" && !pathPattern.MatchString(rule.Path) {
+			errs = append(errs, field.Invalid(rulePath.Child("path"), rule.Path, "must be an absolute path"))
+		}
+		for j, extension := range rule.Extensions {
+			if !extensionPattern.MatchString(extension) {
+				errs = append(errs, field.Invalid(rulePath.Child("extensions").Index(j), extension, "must be alphanumeric"))
+			}
+		}
+		if rule.MaxAgeSeconds < 0 {
+			errs = append(errs, field.Invalid(rulePath.Child("maxAgeSeconds"), rule.MaxAgeSeconds, "must not be negative"))
+		}
+	}
+
+	for i, page := range config.ErrorPages {
+		pagePath := path.Child("errorPages").Index(i)
+		if len(page.Codes) == 0 {
+			errs = append(errs, field.Required(pagePath.Child("codes"), ""))
+		}
+		for j, code := range page.Codes {
+			if code < 300 || code > 599 {
+				errs = append(errs, field.Invalid(pagePath.Child("codes").Index(j), code, "must be between 300 and 599"))
+			}
+		}
+		if !pathPattern.MatchString(page.Path) {
+			errs = append(errs, field.Invalid(pagePath.Child("path"), page.Path, "must be an absolute path"))
+		}
+	}
+
+	from := sets.New[string]()
+	for i, r := range config.Redirects {
+		redirectPath := path.Child("redirects").Index(i)
+		if !pathPattern.MatchString(r.From) {
+			errs = append(errs, field.Invalid(redirectPath.Child("from"), r.From, "must be an absolute path"))
+		} else if from.Has(r.From) {
+			errs = append(errs, field.Duplicate(redirectPath.Child("from"), r.From))
+		}
+		from.Insert(r.From)
+		if r.To == "" || !targetPattern.MatchString(r.To) {
+			errs = append(errs, field.Invalid(redirectPath.Child("to"), r.To, "must be an absolute path or an http(s) URL"))
+		}
+	}
+	return errs
+}
+
+// Render validates the server configuration and renders it into an nginx configuration file
+// with opts. A nil configuration renders a server like the default one of the nginx image.
+func Render(config *webv1.ServerConfig, opts Options) (string, error) {
+	if config == nil {
+		config = &webv1.ServerConfig{}
+	}
+	if err := Validate(config, field.NewPath("spec", "server")).ToAggregate(); err != nil {
+		return "", err
+	}
+
+	s := server{SPA: config.SPA, Gzip: config.Gzip}
+	for _, name := range slices.Sorted(maps.Keys(config.Headers)) {
+		s.Headers = append(s.Headers, header{Name: name, Value: quote(config.Headers[name])})
+	}
+	if opts.Access != nil {
+		var err error
+		if s.Access, err = renderAccess(opts.Access); err != nil {
+			return "", err
+		}
+	}
+	if m := opts.Maintenance; m != nil {
+		if !pathPattern.MatchString(m.Root) || !fileNamePattern.MatchString(m.File) || m.RetryAfterSeconds < 0 {
+			return "", fmt.Errorf("invalid maintenance page '%s' in '%s' or retry after %d seconds", m.File, m.Root, m.RetryAfterSeconds)
+		}
+		s.Maintenance = m
+	}
+	for _, page := range config.ErrorPages {
+		codes := make([]string, len(page.Codes))
+		for i, code := range page.Codes {
+			codes[i] = strconv.Itoa(int(code))
+		}
+		s.ErrorPages = append(s.ErrorPages, errorPage{Codes: strings.Join(codes, " "), Path: page.Path})
+	}
+	for _, r := range config.Redirects {
+		status := 302
+		if r.Permanent {
+			status = 301
+		}
+		s.Redirects = append(s.Redirects, redirect{From: r.From, To: r.To, Status: status})
+	}
+	for _, rule := range config.Caching {
+		s.Caching = append(s.Caching, cachingRule{Pattern: cachingPattern(rule), CacheControl: cacheControl(rule)})
+	}
+
+	var b strings.Builder
+	if err := serverTemplate.Execute(&b, s); err != nil {
+		return "", fmt.Errorf("couldn't render nginx configuration: %w", err)
+	}
+	return b.String(), nil
+}
+
+func renderAccess(access *Access) (*Access, error) {
+	rendered := &Access{UserFile: access.UserFile, Realm: quote(access.Realm)}
+	for _, cidr := range access.AllowedCIDRs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed CIDR: %w", err)
+		}
+		rendered.AllowedCIDRs = append(rendered.AllowedCIDRs, prefix.String())
+	}
+	for _, cidr := range access.TrustedProxies {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy CIDR: %w", err)
+		}
+		rendered.TrustedProxies = append(rendered.TrustedProxies, prefix.String())
+	}
+	if access.UserFile != "" && (!pathPattern.MatchString(access.UserFile) || invalidValue(access.Realm)) {
+		return nil, fmt.Errorf("invalid basic auth realm '%s' or user file '%s'", access.Realm, access.UserFile)
+	}
+	return rendered, nil
+}
+
+// invalidValue reports whether value contains characters which can't be quoted in the nginx
+// configuration syntax.
+func invalidValue(value string) bool {
+	return strings.ContainsFunc(value, func(r rune) bool { return r < ' ' || r == 0x7f || r == '$' })
+}
+
+func quote(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value)
+}
+
+// cachingPattern returns the regular expression of the location a caching rule applies to.
+// Regular expression locations are matched in order, so the first matching rule applies.
+func cachingPattern(rule webv1.CachingRule) string {
+	pattern := "^" + regexp.QuoteMeta(rule.Path)
+	if rule.Path == "" {
+		pattern = "^/"
+	}
+	if len(rule.Extensions) > 0 {
+		pattern += `.*\.(?:` + strings.Join(rule.Extensions, "|") + ")$"
+	}
+	return pattern
+}
+
+func cacheControl(rule webv1.CachingRule) string {
+	if rule.NoStore {
+		return "no-store"
+	}
+	value := fmt.Sprintf("public, max-age=%d", rule.MaxAgeSeconds)
+	if rule.Immutable {
+		value += ", immutable"
+	}
+	return value
+}
@@ -0,0 +1,167 @@
+package nginxconf
+
+import (
+	"strings"
+	"testing"
+	webv1 "website-operator/api/v1"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestRender(t *testing.T) {
+	config, err := Render(&webv1.ServerConfig{
+		SPA:  true,
+		Gzip: true,
+		Headers: map[string]string{
+			"X-Frame-Options":         "DENY",
+			"Content-Security-Policy": `default-src 'self'; img-src "data:"`,
+		},
+		Caching: []webv1.CachingRule{
+			{Path: "/assets/", Extensions: []string{"css", "js"}, MaxAgeSeconds: 31536000, Immutable: true},
+			{Path: "/api.json", NoStore: true},
+		},
+		ErrorPages: []webv1.ErrorPage{{Codes: []int32{404, 410}, Path: "/404.html"}},
+		Redirects: []webv1.Redirect{
+			{From: "/old", To: "/new", Permanent: true},
+			{From: "/docs", To: "https://docs.example.com/"},
+		},
+	}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"    gzip on;\n",
+		"    add_header Content-Security-Policy \"default-src 'self'; img-src \\\"data:\\\"\" always;\n" +
+			"    add_header X-Frame-Options \"DENY\" always;\n",
+		"    error_page 404 410 /404.html;\n",
+		"    location = /old {\n        return 301 \"/new\";\n    }\n",
+		"    location = /docs {\n        return 302 \"https://docs.example.com/\";\n    }\n",
+		"    location ~ \"^/assets/.*\\.(?:css|js)$\" {\n",
+		"        add_header X-Frame-Options \"DENY\" always;\n" +
+			"        add_header Cache-Control \"public, max-age=31536000, immutable\" always;\n",
+		"    location ~ \"^/api\\.json\" {\n",
+		"        add_header Cache-Control \"no-store\" always;\n",
+		"        try_files $uri $uri/ /index.html;\n",
+	} {
+		if !strings.Contains(config, want) {
+			t.Errorf("expected configuration to contain\n%s\ngot\n%s", want, config)
+		}
+	}
+
+	// caching rules are matched in order, so their order is kept
+	if strings.Index(config, "/assets/") > strings.Index(config, "/api") {
+		t.Errorf("expected caching rules in their order, got\n%s", config)
+	}
+}
+
+func TestRenderDefault(t *testing.T) {
+	config, err := Render(nil, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(config, "try_files $uri $uri/ =404;") || strings.Contains(config, "add_header") ||
+		strings.Contains(config, "deny") || strings.Contains(config, "auth_basic") {
+		t.Errorf("unexpected default configuration:\n%s", config)
+	}
+}
+
+func TestRenderAccess(t *testing.T) {
+	config, err := Render(nil, Options{Access: &Access{
+		AllowedCIDRs:   []string{"192.168.0.0/16", "2001:db8::/32"},
+		TrustedProxies: []string{"10.0.0.0/8"},
+		UserFile:       "/etc/nginx/auth/auth",
+		Realm:          "Intranet",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "    index index.html;\n\n" +
+		"    set_real_ip_from 10.0.0.0/8;\n" +
+		"    real_ip_header X-Forwarded-For;\n" +
+		"    real_ip_recursive on;\n\n" +
+		"    allow 192.168.0.0/16;\n" +
+		"    allow 2001:db8::/32;\n" +
+		"    deny all;\n\n" +
+		"    auth_basic \"Intranet\";\n" +
+		"    auth_basic_user_file /etc/nginx/auth/auth;\n"
+	if !strings.Contains(config, want) {
+		t.Errorf("expected configuration to contain\n%s\ngot\n%s", want, config)
+	}
+
+	if _, err := Render(nil, Options{Access: &Access{AllowedCIDRs: []string{"all; allow 0.0.0.0/0"}}}); err == nil {
+		t.Error("expected an error for an invalid CIDR")
+	}
+}
+
+func TestRenderMaintenance(t *testing.T) {
+	config, err := Render(&webv1.ServerConfig{
+		ErrorPages: []webv1.ErrorPage{{Codes: []int32{503}, Path: "/503.html"}},
+		Redirects:  []webv1.Redirect{{From: "/old", To: "/new"}},
+	}, Options{
+		Access:      &Access{AllowedCIDRs: []string{"10.0.0.0/8"}},
+		Maintenance: &Maintenance{Root: "/usr/share/nginx/maintenance", File: "maintenance.html", RetryAfterSeconds: 300},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "    error_page 503 @maintenance;\n\n" +
+		"    location / {\n" +
+		"        root /usr/share/nginx/maintenance;\n" +
+		"        try_files /.unavailable =503;\n" +
+		"    }\n\n" +
+		"    location @maintenance {\n" +
+		"        root /usr/share/nginx/maintenance;\n" +
+		"        rewrite ^ /maintenance.html break;\n" +
+		"        add_header Cache-Control \"no-store\" always;\n" +
+		"        add_header Retry-After 300 always;\n" +
+		"    }\n"
+	if !strings.Contains(config, want) {
+		t.Errorf("expected configuration to contain\n%s\ngot\n%s", want, config)
+	}
+	// the error pages and redirects of the content would replace the maintenance page
+	if strings.Contains(config, "/503.html") || strings.Contains(config, "/old") {
+		t.Errorf("expected no error pages and redirects in maintenance mode, got\n%s", config)
+	}
+	// return would answer before the access checks
+	if strings.Contains(config, "return 503") || !strings.Contains(config, "deny all;") {
+		t.Errorf("expected the access checks to apply in maintenance mode, got\n%s", config)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		config webv1.ServerConfig
+		// field is the path of the expected error, empty if valid
+		field string
+	}{
+		{name: "valid", config: webv1.ServerConfig{Redirects: []webv1.Redirect{{From: "/a", To: "http://example.com:8080/b?c=d"}}}},
+		{name: "header name", config: webv1.ServerConfig{Headers: map[string]string{"X Injected;": "x"}}, field: "server.headers[X Injected;]"},
+		{name: "header variable", config: webv1.ServerConfig{Headers: map[string]string{"X-Host": "$host"}}, field: "server.headers[X-Host]"},
+		{name: "header newline", config: webv1.ServerConfig{Headers: map[string]string{"X-A": "a\n}"}}, field: "server.headers[X-A]"},
+		{name: "caching path", config: webv1.ServerConfig{Caching: []webv1.CachingRule{{Path: "/a { }"}}}, field: "server.caching[0].path"},
+		{name: "extension", config: webv1.ServerConfig{Caching: []webv1.CachingRule{{Extensions: []string{"js|.*"}}}}, field: "server.caching[0].extensions[0]"},
+		{name: "error code", config: webv1.ServerConfig{ErrorPages: []webv1.ErrorPage{{Codes: []int32{200}, Path: "/e.html"}}}, field: "server.errorPages[0].codes[0]"},
+		{name: "error page path", config: webv1.ServerConfig{ErrorPages: []webv1.ErrorPage{{Codes: []int32{404}, Path: "404.html"}}}, field: "server.errorPages[0].path"},
+		{name: "duplicate redirect", config: webv1.ServerConfig{Redirects: []webv1.Redirect{{From: "/a", To: "/b"}, {From: "/a", To: "/c"}}}, field: "server.redirects[1].from"},
+		{name: "redirect target", config: webv1.ServerConfig{Redirects: []webv1.Redirect{{From: "/a", To: "javascript:alert(1)"}}}, field: "server.redirects[0].to"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := Validate(&tt.config, field.NewPath("server"))
+			if tt.field == "" {
+				if len(errs) > 0 {
+					t.Errorf("unexpected errors: %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || errs[0].Field != tt.field {
+				t.Errorf("expected an error for %s, got %v", tt.field, errs)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package revision
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	webv1 "website-operator/api/v1"
+	"website-operator/internal"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Revisions of a website are stored as immutable ConfigMaps, one per distinct spec.
+const (
+	LabelWebsite       = "anexia.com/website"
+	LabelRevision      = "anexia.com/revision"
+	AnnotationSpecHash = "anexia.com/spec-hash"
+
+	DefaultHistoryLimit = 10
+
+	specKey  = "spec.json"
+	filesKey = "files.json"
+)
+
+// ObjectName returns the name of the ConfigMap holding a revision of a website.
+func ObjectName(siteName string, number int64) string {
+	return fmt.Sprintf("website-%s-rev-%d", siteName, number)
+}
+
+// Selector selects all revision ConfigMaps of a website.
+func Selector(siteName string) string {
+	return labels.SelectorFromSet(labels.Set{LabelWebsite: internal.LabelValue(siteName)}).String()
+}
+
+// HistoryLimit returns the number of revisions to keep for a website.
+func HistoryLimit(spec webv1.WebSiteSpec) int {
+	if spec.RevisionHistoryLimit == nil {
+		return DefaultHistoryLimit
+	}
+	return int(*spec.RevisionHistoryLimit)
+}
+
+// contentSpec returns the part of a spec that is versioned, i.e. everything but the settings
+// of the revision history itself, whether the website is suspended or in maintenance and its
+// access restrictions.
+func contentSpec(spec webv1.WebSiteSpec) webv1.WebSiteSpec {
+	spec.RevisionHistoryLimit = nil
+	spec.Suspend = false
+	spec.Access = nil
+	spec.Maintenance = nil
+	return spec
+}
+
+// Hash identifies the versioned content of a spec.
+func Hash(spec webv1.WebSiteSpec) string {
+	b, _ := json.Marshal(contentSpec(spec))
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:16])
+}
+
+// NewConfigMap stores the spec of website as revision number. Files are kept as binary data,
+// so the revision fits into a ConfigMap as long as the website does.
+func NewConfigMap(website *webv1.WebSite, number int64) (*corev1.ConfigMap, error) {
+	spec := contentSpec(website.Spec)
+	files := spec.Files
+	spec.Files = nil
+
+	specJSON, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	fileKeys := make(map[string]string, len(files))
+	binaryData := make(map[string][]byte, len(files))
+	for path, content := range files {
+		key := fmt.Sprintf("file-%d", len(fileKeys))
+		fileKeys[path] = key
+		binaryData[key] = content
+	}
+	filesJSON, err := json.Marshal(fileKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: ObjectName(website.Name, number),
+			Labels: map[string]string{
+				"apptype":     "website-revision",
+				LabelWebsite:  internal.LabelValue(website.Name),
+				LabelRevision: strconv.FormatInt(number, 10),
+			},
+			Annotations: map[string]string{
+				AnnotationSpecHash: Hash(website.Spec),
+			},
+		},
+		Immutable: internal.Ptr(true),
+		Data: map[string]string{
+			specKey:  string(specJSON),
+			filesKey: string(filesJSON),
+		},
+		BinaryData: binaryData,
+	}, nil
+}
+
+// SpecFromConfigMap restores the versioned spec stored in a revision ConfigMap.
+func SpecFromConfigMap(cm *corev1.ConfigMap) (webv1.WebSiteSpec, error) {
+	var spec webv1.WebSiteSpec
+	if err := json.Unmarshal([]byte(cm.Data[specKey]), &spec); err != nil {
+		return spec, fmt.Errorf("invalid revision %s: %w", cm.Name, err)
+	}
+
+	var fileKeys map[string]string
+	if err := json.Unmarshal([]byte(cm.Data[filesKey]), &fileKeys); err != nil {
+		return spec, fmt.Errorf("invalid revision %s: %w", cm.Name, err)
+	}
+	if len(fileKeys) > 0 {
+		spec.Files = make(map[string][]byte, len(fileKeys))
+		for path, key := range fileKeys {
+			spec.Files[path] = cm.BinaryData[key]
+		}
+	}
+
+	return spec, nil
+}
+
+// Number returns the revision number of a revision ConfigMap, 0 if it has none.
+func Number(cm *corev1.ConfigMap) int64 {
+	n, _ := strconv.ParseInt(cm.Labels[LabelRevision], 10, 64)
+	return n
+}
+
+// SortNewestFirst orders revision ConfigMaps by descending revision number.
+func SortNewestFirst(revisions []corev1.ConfigMap) {
+	slices.SortFunc(revisions, func(a, b corev1.ConfigMap) int {
+		return int(Number(&b) - Number(&a))
+	})
+}
@@ -0,0 +1,103 @@
+package revision
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	webv1 "website-operator/api/v1"
+	"website-operator/internal"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+func TestConfigMapRoundTrip(t *testing.T) {
+	website := &webv1.WebSite{
+		ObjectMeta: metav1.ObjectMeta{Name: "hello"},
+		Spec: webv1.WebSiteSpec{
+			HtmlContent:          "<p>hello</p>",
+			Hostname:             "hello.local",
+			NginxImage:           "docker.io/nginx:1.28",
+			Files:                map[string][]byte{"assets/style.css": []byte("p {}"), "logo.png": {0x89, 0x50}},
+			RevisionHistoryLimit: internal.Ptr(int32(3)),
+		},
+	}
+
+	cm, err := NewConfigMap(website, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cm.Name != "website-hello-rev-7" || Number(cm) != 7 || cm.Labels[LabelWebsite] != "hello" || !*cm.Immutable {
+		t.Fatalf("unexpected revision metadata: %+v", cm.ObjectMeta)
+	}
+
+	spec, err := SpecFromConfigMap(cm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := website.Spec
+	expected.RevisionHistoryLimit = nil
+	if !reflect.DeepEqual(spec, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, spec)
+	}
+}
+
+func TestLongWebsiteName(t *testing.T) {
+	website := &webv1.WebSite{ObjectMeta: metav1.ObjectMeta{Name: strings.Repeat("a", 100)}}
+
+	cm, err := NewConfigMap(website, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := validation.IsValidLabelValue(cm.Labels[LabelWebsite]); len(errs) != 0 {
+		t.Fatalf("invalid website label: %v", errs)
+	}
+	selector, err := labels.Parse(Selector(website.Name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !selector.Matches(labels.Set(cm.Labels)) {
+		t.Errorf("expected selector %s to match the revision labels %v", selector, cm.Labels)
+	}
+}
+
+func TestHashIgnoresHistoryLimit(t *testing.T) {
+	spec := webv1.WebSiteSpec{HtmlContent: "a", Hostname: "a.local"}
+	limited := spec
+	limited.RevisionHistoryLimit = internal.Ptr(int32(1))
+
+	if Hash(spec) != Hash(limited) {
+		t.Errorf("expected history limit not to change the hash")
+	}
+
+	restricted := spec
+	restricted.Access = &webv1.AccessConfig{AllowedCIDRs: []string{"10.0.0.0/8"}}
+	restricted.Maintenance = &webv1.Maintenance{Enabled: true}
+	if Hash(spec) != Hash(restricted) {
+		t.Errorf("expected access restrictions and maintenance not to change the hash")
+	}
+
+	changed := spec
+	changed.HtmlContent = "b"
+	if Hash(spec) == Hash(changed) {
+		t.Errorf("expected content to change the hash")
+	}
+}
+
+func TestSortNewestFirst(t *testing.T) {
+	revisions := []corev1.ConfigMap{
+		{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{LabelRevision: "2"}}},
+		{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{LabelRevision: "10"}}},
+		{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{LabelRevision: "1"}}},
+	}
+	SortNewestFirst(revisions)
+
+	for i, n := range []int64{10, 2, 1} {
+		if Number(&revisions[i]) != n {
+			t.Fatalf("expected revision %d at position %d, got %d", n, i, Number(&revisions[i]))
+		}
+	}
+}
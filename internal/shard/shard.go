@@ -0,0 +1,54 @@
+// Package shard splits websites between several controller instances. Every website is
+// assigned to one of a fixed number of buckets by a hash of its namespace and name, recorded
+// in a label. Each instance handles the buckets of its shard, so the number of instances can
+// change without relabeling any website.
+package shard
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+const (
+	// Label holds the bucket of a website.
+	Label = "anexia.com/shard"
+
+	// Buckets is the number of buckets websites are hashed to, which is also the maximum number of shards.
+	Buckets = 32
+)
+
+// Bucket returns the bucket of the website with the given namespace and name.
+func Bucket(namespace, name string) string {
+	h := fnv.New32a()
+	h.Write([]byte(namespace + "/" + name))
+	return strconv.FormatUint(uint64(h.Sum32()%Buckets), 10)
+}
+
+// SetLabel assigns a website to its bucket by setting the shard label in set, which may be nil.
+func SetLabel(set map[string]string, namespace, name string) map[string]string {
+	if set == nil {
+		set = map[string]string{}
+	}
+	set[Label] = Bucket(namespace, name)
+	return set
+}
+
+// Requirement selects the websites of a shard, one out of shards.
+func Requirement(shard, shards int) (*labels.Requirement, error) {
+	if shards < 1 || shards > Buckets {
+		return nil, fmt.Errorf("number of shards must be between 1 and %d, got %d", Buckets, shards)
+	}
+	if shard < 0 || shard >= shards {
+		return nil, fmt.Errorf("shard must be between 0 and %d, got %d", shards-1, shard)
+	}
+
+	var buckets []string
+	for bucket := shard; bucket < Buckets; bucket += shards {
+		buckets = append(buckets, strconv.Itoa(bucket))
+	}
+	return labels.NewRequirement(Label, selection.In, buckets)
+}
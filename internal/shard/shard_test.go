@@ -0,0 +1,46 @@
+package shard
+
+import (
+	"strconv"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestBucket(t *testing.T) {
+	if Bucket("default", "a") != Bucket("default", "a") {
+		t.Error("bucket of a website isn't stable")
+	}
+	if got := SetLabel(nil, "default", "a")[Label]; got != Bucket("default", "a") {
+		t.Errorf("SetLabel() = %q, want %q", got, Bucket("default", "a"))
+	}
+}
+
+func TestRequirementCoversEveryBucketOnce(t *testing.T) {
+	for _, shards := range []int{1, 3, 5, Buckets} {
+		for bucket := 0; bucket < Buckets; bucket++ {
+			site := labels.Set{Label: strconv.Itoa(bucket)}
+			matches := 0
+			for shard := 0; shard < shards; shard++ {
+				requirement, err := Requirement(shard, shards)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if requirement.Matches(site) {
+					matches++
+				}
+			}
+			if matches != 1 {
+				t.Errorf("bucket %d matched by %d of %d shards, want 1", bucket, matches, shards)
+			}
+		}
+	}
+}
+
+func TestRequirementInvalid(t *testing.T) {
+	for _, tt := range []struct{ shard, shards int }{{0, 0}, {0, Buckets + 1}, {3, 3}, {-1, 2}} {
+		if _, err := Requirement(tt.shard, tt.shards); err == nil {
+			t.Errorf("Requirement(%d, %d) succeeded, want error", tt.shard, tt.shards)
+		}
+	}
+}
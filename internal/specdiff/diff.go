@@ -0,0 +1,135 @@
+package specdiff
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// maxValueLength is the length up to which values are reported verbatim. Longer strings and
+// binary content, e.g. HTML documents, are summarized by their size and hash.
+const maxValueLength = 256
+
+// Change is a field that differs between two objects.
+type Change struct {
+	// Field is the JSON path of the field, e.g. "spec.hostname" or "spec.files[index.html]".
+	Field string `json:"field"`
+	Old   any    `json:"old,omitempty"`
+	New   any    `json:"new,omitempty"`
+}
+
+// Diff compares two values of the same struct type field by field, using the JSON names of the
+// fields prefixed with root. A nil old or new value compares as the zero value, so creations
+// and deletions are reported with all their set fields.
+func Diff(root string, old, new any) []Change {
+	oldVal, newVal := reflect.ValueOf(old), reflect.ValueOf(new)
+	if !oldVal.IsValid() && !newVal.IsValid() {
+		return nil
+	}
+	if !oldVal.IsValid() {
+		oldVal = reflect.Zero(newVal.Type())
+	}
+	if !newVal.IsValid() {
+		newVal = reflect.Zero(oldVal.Type())
+	}
+
+	var changes []Change
+	diffValue(root, indirect(oldVal), indirect(newVal), &changes)
+	return changes
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return reflect.Zero(v.Type().Elem())
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func diffValue(path string, old, new reflect.Value, changes *[]Change) {
+	old, new = indirect(old), indirect(new)
+
+	switch old.Kind() {
+	case reflect.Struct:
+		for i := 0; i < old.NumField(); i++ {
+			f := old.Type().Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+
+			fieldPath := path
+			if !f.Anonymous || name != "" {
+				if name == "" {
+					name = f.Name
+				}
+				fieldPath = path + "." + name
+			}
+			diffValue(fieldPath, old.Field(i), new.Field(i), changes)
+		}
+	case reflect.Map:
+		keys := map[string]reflect.Value{}
+		for _, k := range old.MapKeys() {
+			keys[fmt.Sprint(k.Interface())] = k
+		}
+		for _, k := range new.MapKeys() {
+			keys[fmt.Sprint(k.Interface())] = k
+		}
+
+		names := make([]string, 0, len(keys))
+		for name := range keys {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+
+		for _, name := range names {
+			k := keys[name]
+			oldElem, newElem := old.MapIndex(k), new.MapIndex(k)
+			if !oldElem.IsValid() {
+				oldElem = reflect.Zero(old.Type().Elem())
+			}
+			if !newElem.IsValid() {
+				newElem = reflect.Zero(new.Type().Elem())
+			}
+			diffValue(fmt.Sprintf("%s[%s]", path, name), oldElem, newElem, changes)
+		}
+	default:
+		if !reflect.DeepEqual(old.Interface(), new.Interface()) {
+			*changes = append(*changes, Change{
+				Field: path,
+				Old:   summarize(old),
+				New:   summarize(new),
+			})
+		}
+	}
+}
+
+func summarize(v reflect.Value) any {
+	if v.IsZero() {
+		return nil
+	}
+
+	var b []byte
+	switch {
+	case v.Kind() == reflect.String:
+		if v.Len() <= maxValueLength {
+			return v.Interface()
+		}
+		b = []byte(v.String())
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		b = v.Bytes()
+	default:
+		return v.Interface()
+	}
+
+	sum := sha256.Sum256(b)
+	return fmt.Sprintf("sha256:%s (%d bytes)", hex.EncodeToString(sum[:]), len(b))
+}
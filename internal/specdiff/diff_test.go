@@ -0,0 +1,51 @@
+package specdiff
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type spec struct {
+	Hostname string            `json:"hostname"`
+	Content  string            `json:"content"`
+	Files    map[string][]byte `json:"files,omitempty"`
+	Nested   *nested           `json:"nested,omitempty"`
+}
+
+type nested struct {
+	Enabled bool `json:"enabled"`
+}
+
+func TestDiff(t *testing.T) {
+	old := &spec{Hostname: "a.local", Content: "x", Files: map[string][]byte{"a.css": []byte("a"), "b.css": []byte("b")}}
+	new := &spec{Hostname: "b.local", Content: "x", Files: map[string][]byte{"b.css": []byte("b"), "c.css": []byte("c")}, Nested: &nested{Enabled: true}}
+
+	changes := Diff("spec", old, new)
+
+	fields := make([]string, 0, len(changes))
+	for _, c := range changes {
+		fields = append(fields, c.Field)
+	}
+	expected := []string{"spec.hostname", "spec.files[a.css]", "spec.files[c.css]", "spec.nested.enabled"}
+	if !reflect.DeepEqual(fields, expected) {
+		t.Fatalf("expected changes of %v, got %v", expected, fields)
+	}
+
+	if changes[0].Old != "a.local" || changes[0].New != "b.local" {
+		t.Errorf("unexpected hostname change: %+v", changes[0])
+	}
+	if changes[1].New != nil || !strings.HasPrefix(changes[1].Old.(string), "sha256:") {
+		t.Errorf("expected removed file to be summarized: %+v", changes[1])
+	}
+}
+
+func TestDiffCreation(t *testing.T) {
+	changes := Diff("spec", nil, &spec{Hostname: "a.local", Content: strings.Repeat("x", maxValueLength+1)})
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %+v", changes)
+	}
+	if !strings.HasSuffix(changes[1].New.(string), "(257 bytes)") {
+		t.Errorf("expected long content to be summarized: %+v", changes[1])
+	}
+}